@@ -15,6 +15,8 @@ import (
 	"vk-inter-test-go/internal/db"
 	"vk-inter-test-go/internal/io"
 	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/mail"
+	"vk-inter-test-go/internal/utils"
 )
 
 func main() {
@@ -23,10 +25,34 @@ func main() {
 		log.Fatal("cannot initialize config")
 	}
 
+	activeKid, jwtKeys, err := configSrv.Options.LoadJwtKeys()
+	if err != nil {
+		configSrv.Logger.Fatal("jwt keys", zap.Error(err))
+	}
+	keySet, err := utils.NewKeySet(configSrv.Options.JwtAlg, activeKid, jwtKeys)
+	if err != nil {
+		configSrv.Logger.Fatal("jwt keys", zap.Error(err))
+	}
+	utils.SetKeySet(keySet)
+
 	dbRepo := db.NewDBRepo(configSrv)
 	defer dbRepo.Close()
-	blInst := bl.NewBL(dbRepo, configSrv.Logger.Named("bl"))
-	controller := handlers.NewController(blInst, configSrv.Logger.Named("io"))
+	var mailer mail.Mailer
+	if configSrv.Options.MailSender == "smtp" {
+		mailer = mail.NewSMTPMailer(configSrv.Options.SmtpHost, configSrv.Options.SmtpPort, configSrv.Options.SmtpUser,
+			configSrv.Options.SmtpPass, configSrv.Options.MailFrom)
+	} else {
+		mailer, err = mail.NewOutboxMailer(configSrv.Options.MailOutboxDir, configSrv.Options.MailFrom)
+		if err != nil {
+			configSrv.Logger.Fatal("mail outbox", zap.Error(err))
+		}
+	}
+
+	blInst, err := bl.NewBL(dbRepo, configSrv.Options, mailer, configSrv.Logger.Named("bl"))
+	if err != nil {
+		configSrv.Logger.Fatal("bl", zap.Error(err))
+	}
+	controller := handlers.NewController(blInst, configSrv.Options, configSrv.Logger.Named("io"))
 
 	mux := io.SetupRoutes(controller)
 	srvStr := fmt.Sprintf("%s:%s", configSrv.Options.Host, configSrv.Options.Port)
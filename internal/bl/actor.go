@@ -1,32 +1,41 @@
 package bl
 
 import (
+	"context"
 	"vk-inter-test-go/internal/db/repo"
 	"vk-inter-test-go/internal/io/models"
 	"vk-inter-test-go/internal/utils"
 )
 
-func (b *BL) CreateActor(actor repo.Actor) (repo.Actor, error) {
+func (b *BL) CreateActor(ctx context.Context, actor repo.Actor) (repo.Actor, error) {
 	b.logger.Info("create actor")
 
 	err := b.Db.Actor.CreateActor(&actor)
 	if err != nil {
 		return repo.Actor{}, err
 	}
-	return actor, nil
+	err = b.audit(ctx, AuditActionCreate, AuditEntityActor, actor.ID, nil, models.ActorFromRepo(actor))
+	return actor, err
 }
 
-func (b *BL) DeleteActor(name string) (int64, error) {
+func (b *BL) DeleteActor(ctx context.Context, name string) (int64, error) {
 	b.logger.Info("delete actor")
 
+	dbActor, err := b.Db.Actor.GetActorByName(name)
+	if err != nil {
+		return 0, err
+	}
 	res, err := b.Db.Actor.DeleteActorByName(name)
 	if err != nil {
 		return 0, err
 	}
-	return res, nil
+	if res > 0 {
+		err = b.audit(ctx, AuditActionDelete, AuditEntityActor, dbActor.ID, models.ActorFromRepo(dbActor), nil)
+	}
+	return res, err
 }
 
-func (b *BL) UpdateActor(actor repo.Actor) (repo.Actor, error) {
+func (b *BL) UpdateActor(ctx context.Context, actor repo.Actor) (repo.Actor, error) {
 	b.logger.Info("update actor")
 	dbActor, err := b.Db.Actor.GetActorById(actor.ID)
 	if err != nil {
@@ -35,8 +44,7 @@ func (b *BL) UpdateActor(actor repo.Actor) (repo.Actor, error) {
 	if len(actor.Name) == 0 {
 		actor.Name = dbActor.Name
 	}
-	if len(actor.BirthDateJson) == 0 {
-		actor.BirthDateJson = dbActor.BirthDate.String()
+	if actor.BirthDate.IsZero() {
 		actor.BirthDate = dbActor.BirthDate
 	}
 
@@ -48,7 +56,8 @@ func (b *BL) UpdateActor(actor repo.Actor) (repo.Actor, error) {
 	if err != nil {
 		return repo.Actor{}, err
 	}
-	return actor, nil
+	err = b.audit(ctx, AuditActionUpdate, AuditEntityActor, actor.ID, models.ActorFromRepo(dbActor), models.ActorFromRepo(actor))
+	return actor, err
 }
 
 func (b *BL) GetAllActorsLikeName(name string, orderBy string) ([]models.ActorIo, error) {
@@ -62,7 +71,7 @@ func (b *BL) GetAllActorsLikeName(name string, orderBy string) ([]models.ActorIo
 	var actorsIDs []int
 	for _, actor := range allActors {
 		actorsIDs = append(actorsIDs, actor.ID)
-		actors = append(actors, models.ActorIo{Actor: actor})
+		actors = append(actors, models.ActorIo{Actor: models.ActorFromRepo(actor)})
 	}
 
 	actorIDsWithMovieIDs, err := b.Db.MovieActor.GetRelationByActorIDs(actorsIDs)
@@ -77,7 +86,7 @@ func (b *BL) GetAllActorsLikeName(name string, orderBy string) ([]models.ActorIo
 
 	for i, _ := range actors {
 		for _, val := range actorIDsWithMovieIDs[actors[i].Actor.ID] {
-			actors[i].Movies = append(actors[i].Movies, movieMap[val])
+			actors[i].Movies = append(actors[i].Movies, models.MovieFromRepo(movieMap[val]))
 		}
 	}
 	return actors, nil
@@ -0,0 +1,135 @@
+package bl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/utils"
+)
+
+const (
+	PermApiKeyManage = "apikey:manage"
+
+	// apiKeyPrefixLen is the part of the key kept in clear text to tell keys apart.
+	apiKeyPrefixLen = 8
+)
+
+var (
+	ErrInvalidApiKey     = errors.New("invalid api key")
+	ErrApiKeyNotFound    = errors.New("api key not found")
+	ErrApiKeyExpiry      = errors.New("expiry must be in the future")
+	ErrPermissionNotHeld = errors.New("cannot grant a permission the caller does not have")
+)
+
+// AuthenticateApiKey verifies an API key and returns a principal that carries
+// the permissions of the key instead of a role.
+func (b *BL) AuthenticateApiKey(key string) (Principal, error) {
+	if len(key) == 0 {
+		return Principal{}, ErrInvalidApiKey
+	}
+	apiKey, err := b.Db.ApiKey.GetApiKeyByHash(utils.HashToken(key))
+	if err != nil {
+		b.logger.Info("err", zap.Error(err))
+		return Principal{}, ErrInvalidApiKey
+	}
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt)) {
+		return Principal{}, ErrInvalidApiKey
+	}
+	if err := b.Db.ApiKey.TouchApiKey(apiKey.ID); err != nil {
+		b.logger.Error("touch api key", zap.Error(err))
+	}
+	b.logger.Info("check api key ok", zap.Int("key", apiKey.ID))
+	return Principal{
+		ApiKeyID:    apiKey.ID,
+		Login:       "apikey:" + apiKey.Name,
+		Permissions: apiKey.Permissions,
+	}, nil
+}
+
+// CreateApiKey issues a key with the requested permissions. The caller can grant
+// only permissions it holds itself. The key is returned once, only its hash is stored.
+func (b *BL) CreateApiKey(ctx context.Context, req models.ApiKeyCreateRequest) (models.ApiKeyCreated, error) {
+	b.logger.Info("create api key")
+
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return models.ApiKeyCreated{}, ErrPermissionNotHeld
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return models.ApiKeyCreated{}, ErrApiKeyExpiry
+	}
+	permissions := sortedUnique(req.Permissions)
+	for _, permission := range permissions {
+		held, err := b.hasPermission(principal, permission)
+		if err != nil {
+			return models.ApiKeyCreated{}, err
+		}
+		if !held {
+			return models.ApiKeyCreated{}, fmt.Errorf("%w: %s", ErrPermissionNotHeld, permission)
+		}
+	}
+
+	key, err := utils.NewOpaqueToken()
+	if err != nil {
+		return models.ApiKeyCreated{}, err
+	}
+	apiKey := repo.ApiKey{
+		Name:        req.Name,
+		Prefix:      key[:apiKeyPrefixLen],
+		KeyHash:     utils.HashToken(key),
+		ExpiresAt:   req.ExpiresAt,
+		Permissions: permissions,
+	}
+	if principal.UserID != 0 {
+		apiKey.CreatedBy = &principal.UserID
+	}
+	if err := b.Db.ApiKey.CreateApiKey(&apiKey); err != nil {
+		return models.ApiKeyCreated{}, err
+	}
+	return models.ApiKeyCreated{ApiKey: apiKeyIo(apiKey), Key: key}, nil
+}
+
+func (b *BL) GetApiKeys() ([]models.ApiKeyIo, error) {
+	b.logger.Info("get api keys")
+
+	keys, err := b.Db.ApiKey.GetApiKeys()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.ApiKeyIo, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, apiKeyIo(key))
+	}
+	return res, nil
+}
+
+func (b *BL) RevokeApiKey(id int) error {
+	b.logger.Info("revoke api key")
+
+	rows, err := b.Db.ApiKey.RevokeApiKey(id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrApiKeyNotFound
+	}
+	return nil
+}
+
+func apiKeyIo(key repo.ApiKey) models.ApiKeyIo {
+	return models.ApiKeyIo{
+		ID:          key.ID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Permissions: key.Permissions,
+		CreatedBy:   key.CreatedBy,
+		CreatedAt:   key.CreatedAt,
+		ExpiresAt:   key.ExpiresAt,
+		LastUsedAt:  key.LastUsedAt,
+		RevokedAt:   key.RevokedAt,
+	}
+}
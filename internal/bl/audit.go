@@ -0,0 +1,108 @@
+package bl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+)
+
+const (
+	PermAuditRead = "audit:read"
+
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+
+	AuditEntityMovie = "movie"
+	AuditEntityActor = "actor"
+	AuditEntityGenre = "genre"
+)
+
+var ErrAuditFailed = errors.New("the change is saved, but its audit event is not recorded")
+
+// audit records a change made for the request of ctx. A nil before or after is
+// stored as NULL. The change has already been applied when audit is called, so
+// a failure is returned as ErrAuditFailed for the caller to report it.
+func (b *BL) audit(ctx context.Context, action string, entityType string, entityID int, before interface{}, after interface{}) error {
+	principal, _ := PrincipalFromContext(ctx)
+	request, _ := RequestInfoFromContext(ctx)
+	event := repo.AuditEvent{
+		ActorLogin: principal.Login,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		RequestID:  request.ID,
+		IP:         request.IP,
+	}
+	if principal.UserID != 0 {
+		event.ActorUserID = &principal.UserID
+	}
+
+	var err error
+	event.Before, err = auditState(before)
+	if err == nil {
+		event.After, err = auditState(after)
+	}
+	if err == nil {
+		err = b.Db.Audit.CreateAuditEvent(&event)
+	}
+	if err != nil {
+		b.logger.Error("audit event lost",
+			zap.String("action", action), zap.String("entity", entityType), zap.Int("id", entityID),
+			zap.String("actor", principal.Login), zap.String("request", request.ID), zap.Error(err))
+		return fmt.Errorf("%w: %v", ErrAuditFailed, err)
+	}
+	return nil
+}
+
+// auditCreated records the persons the repo created together with a credit.
+func (b *BL) auditCreated(ctx context.Context, persons []repo.Actor) error {
+	for _, person := range persons {
+		err := b.audit(ctx, AuditActionCreate, AuditEntityActor, person.ID, nil, models.ActorFromRepo(person))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func auditState(state interface{}) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+// GetAuditEvents returns a page of audit events matching the filter, newest first.
+func (b *BL) GetAuditEvents(filter repo.AuditFilter, page int, limit int) (models.AuditPage, error) {
+	b.logger.Info("get audit events")
+
+	events, total, err := b.Db.Audit.GetAuditEvents(filter, limit, (page-1)*limit)
+	if err != nil {
+		return models.AuditPage{}, err
+	}
+	result := models.AuditPage{Events: []models.AuditEventIo{}, Total: total, Page: page, Limit: limit}
+	for _, event := range events {
+		result.Events = append(result.Events, auditEventIo(event))
+	}
+	return result, nil
+}
+
+func auditEventIo(event repo.AuditEvent) models.AuditEventIo {
+	return models.AuditEventIo{
+		ID:         event.ID,
+		Actor:      event.ActorLogin,
+		Action:     event.Action,
+		EntityType: event.EntityType,
+		EntityID:   event.EntityID,
+		Before:     event.Before,
+		After:      event.After,
+		RequestID:  event.RequestID,
+		IP:         event.IP,
+		CreatedAt:  event.CreatedAt,
+	}
+}
@@ -2,19 +2,61 @@ package bl
 
 import (
 	"go.uber.org/zap"
+	"vk-inter-test-go/internal/config"
 	"vk-inter-test-go/internal/db"
+	"vk-inter-test-go/internal/mail"
+	"vk-inter-test-go/internal/oidc"
+	"vk-inter-test-go/internal/utils"
 )
 
 type BL struct {
-	Db     *db.DBRepo
-	logger *zap.Logger
+	Db      *db.DBRepo
+	options config.OptionsSrv
+	// roles caches the role name of a user, the role claim of a token may be
+	// stale until the token expires, so access decisions use the cache instead.
+	roles *ttlCache[int, string]
+	// permissions caches the permissions granted to a role.
+	permissions *ttlCache[string, []string]
+	// guests limits the requests of unauthenticated clients per address.
+	guests *rateLimiter
+	// oidc is the external identity provider, nil when it is not configured.
+	oidc      *oidc.Provider
+	passwords utils.PasswordPolicy
+	hasher    utils.PasswordHasher
+	mailer    mail.Mailer
+	logger    *zap.Logger
 }
 
-func NewBL(repo *db.DBRepo, logger *zap.Logger) *BL {
+func NewBL(repo *db.DBRepo, options config.OptionsSrv, mailer mail.Mailer, logger *zap.Logger) (*BL, error) {
 	logger = logger.Named("Bl")
 
-	return &BL{
-		Db:     repo,
-		logger: logger,
+	hasher, err := utils.NewPasswordHasher(options.PasswordHash,
+		utils.Argon2idHasher{Memory: options.Argon2Memory, Time: options.Argon2Time, Threads: options.Argon2Threads},
+		utils.BcryptHasher{Cost: options.BcryptCost})
+	if err != nil {
+		return nil, err
+	}
+	provider, err := newOidcProvider(options, repo.Role)
+	if err != nil {
+		return nil, err
 	}
+
+	return &BL{
+		Db:      repo,
+		options: options,
+		roles: newTTLCache(options.RoleCacheTTL, func(userID int) (string, error) {
+			role, err := repo.Role.GetRoleByUserId(userID)
+			if err != nil {
+				return "", err
+			}
+			return role.Name, nil
+		}),
+		permissions: newTTLCache(options.RoleCacheTTL, repo.Role.GetPermissionsByRoleName),
+		guests:      newRateLimiter(options.GuestRateLimit, options.GuestRateWindow),
+		oidc:        provider,
+		passwords:   utils.NewPasswordPolicy(options.PasswordMinLength, options.PasswordMinClasses, options.PasswordDenylist),
+		hasher:      hasher,
+		mailer:      mailer,
+		logger:      logger,
+	}, nil
 }
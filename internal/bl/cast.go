@@ -0,0 +1,130 @@
+package bl
+
+import (
+	"context"
+	"errors"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+)
+
+var (
+	ErrActorInCast    = errors.New("actor is already in the cast")
+	ErrActorNotInCast = errors.New("actor is not in the cast")
+	ErrInvalidActor   = errors.New("actor cannot be created")
+)
+
+// castIos maps the cast credits to cast members sorted by billing.
+func castIos(credits []repo.MovieActor, actorMap map[int][]repo.Actor) []models.CastMember {
+	cast := make([]models.CastMember, 0, len(credits))
+	for _, credit := range credits {
+		for _, actor := range actorMap[credit.ActorID] {
+			cast = append(cast, models.CastMember{
+				Actor:     models.ActorFromRepo(actor),
+				Character: credit.Character,
+				Billing:   credit.Billing,
+				Cameo:     credit.Cameo,
+			})
+		}
+	}
+	sortCast(cast)
+	return cast
+}
+
+func (b *BL) movieCast(movieID int) ([]models.CastMember, error) {
+	castMap, err := b.Db.MovieActor.GetCastByMovieIDs([]int{movieID})
+	if err != nil {
+		return nil, err
+	}
+	return b.castMembers(castMap[movieID])
+}
+
+func (b *BL) castMembers(credits []repo.MovieActor) ([]models.CastMember, error) {
+	var actorIDs []int
+	for _, credit := range credits {
+		actorIDs = append(actorIDs, credit.ActorID)
+	}
+	actorMap, err := b.Db.Actor.GetActorMapByIDs(actorIDs)
+	if err != nil {
+		return nil, err
+	}
+	return castIos(credits, actorMap), nil
+}
+
+// changeCast applies change to the cast of the movie, audits the persons it
+// created and the movie update, and returns the resulting cast. The change
+// and the cast before and after it are made in one transaction by the repo.
+func (b *BL) changeCast(ctx context.Context, movieID int, change func() (repo.CastChange, error)) ([]models.CastMember, error) {
+	movie, err := b.Db.Movie.GetMovieById(movieID)
+	if err != nil {
+		return nil, ErrMovieNotFound
+	}
+	res, err := change()
+	switch {
+	case errors.Is(err, repo.ErrMovieNotFound):
+		return nil, ErrMovieNotFound
+	case errors.Is(err, repo.ErrCreditExists):
+		return nil, ErrActorInCast
+	case errors.Is(err, repo.ErrCreditNotFound):
+		return nil, ErrActorNotInCast
+	case err != nil:
+		return nil, err
+	}
+
+	if err = b.auditCreated(ctx, res.Created); err != nil {
+		return nil, err
+	}
+	before, err := b.castMembers(res.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := b.castMembers(res.After)
+	if err != nil {
+		return nil, err
+	}
+	err = b.audit(ctx, AuditActionUpdate, AuditEntityMovie, movie.ID,
+		models.MovieIo{Movie: models.MovieFromRepo(movie), Actors: before},
+		models.MovieIo{Movie: models.MovieFromRepo(movie), Actors: after})
+	return after, err
+}
+
+// AddMovieActor adds the actor to the cast of the movie, the actor is created
+// when it is not known yet.
+func (b *BL) AddMovieActor(ctx context.Context, req models.MovieActorRequest) ([]models.CastMember, error) {
+	b.logger.Info("add movie actor")
+
+	return b.changeCast(ctx, req.MovieID, func() (repo.CastChange, error) {
+		credit, ok := b.personCredit(req.Actor, castCredit(req.CastMember))
+		if !ok {
+			return repo.CastChange{}, ErrInvalidActor
+		}
+		return b.Db.MovieActor.AddMovieActor(req.MovieID, credit)
+	})
+}
+
+// RemoveMovieActor removes the actor from the cast of the movie, the crew
+// credits of the person are kept.
+func (b *BL) RemoveMovieActor(ctx context.Context, movieID int, actorID int) ([]models.CastMember, error) {
+	b.logger.Info("remove movie actor")
+
+	return b.changeCast(ctx, movieID, func() (repo.CastChange, error) {
+		return b.Db.MovieActor.DeleteMovieActor(movieID, actorID)
+	})
+}
+
+// ReplaceMovieCast replaces the whole cast of the movie, actors that are not
+// known yet are created. Every actor can be listed once.
+func (b *BL) ReplaceMovieCast(ctx context.Context, req models.MovieCastRequest) ([]models.CastMember, error) {
+	b.logger.Info("replace movie cast")
+
+	return b.changeCast(ctx, req.MovieID, func() (repo.CastChange, error) {
+		cast := make([]repo.PersonCredit, 0, len(req.Actors))
+		for _, member := range req.Actors {
+			credit, ok := b.personCredit(member.Actor, castCredit(member))
+			if !ok {
+				return repo.CastChange{}, ErrInvalidActor
+			}
+			cast = append(cast, credit)
+		}
+		return b.Db.MovieActor.ReplaceMovieCast(req.MovieID, cast)
+	})
+}
@@ -0,0 +1,103 @@
+package bl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	netmail "net/mail"
+	"strings"
+	"time"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/mail"
+	"vk-inter-test-go/internal/utils"
+)
+
+var (
+	ErrInvalidEmail     = errors.New("invalid email")
+	ErrEmailTaken       = errors.New("email is already used by another user")
+	ErrInvalidUserToken = errors.New("invalid or expired token")
+)
+
+// SetEmail changes the email of the principal of ctx and mails a verification
+// token to it. The email is not used for password resets until it is verified,
+// and it is not checked for uniqueness before, so that nobody can reserve the
+// address of someone else.
+func (b *BL) SetEmail(ctx context.Context, email string) error {
+	b.logger.Info("set email")
+
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.UserID == 0 {
+		return ErrUserNotFound
+	}
+	address, err := netmail.ParseAddress(email)
+	if err != nil || address.Address != strings.TrimSpace(email) {
+		return ErrInvalidEmail
+	}
+	email = address.Address
+
+	rows, err := b.Db.User.SetUserEmail(principal.UserID, email)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	token, err := b.issueUserToken(principal.UserID, repo.TokenPurposeEmailVerify, email, b.options.EmailVerifyTTL)
+	if err != nil {
+		return err
+	}
+	return b.mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Подтверждение адреса почты",
+		Body: fmt.Sprintf("Для подтверждения адреса отправьте токен на POST /api/user/email/verify:\n\n%s\n\nТокен действует до %s.",
+			token, time.Now().Add(b.options.EmailVerifyTTL).Format(time.RFC1123)),
+	})
+}
+
+// VerifyEmail uses up a verification token. It fails when the email has been
+// changed after the token was sent or another user has verified it meanwhile.
+func (b *BL) VerifyEmail(token string) error {
+	b.logger.Info("verify email")
+
+	userToken, err := b.Db.UserToken.UseUserToken(utils.HashToken(token), repo.TokenPurposeEmailVerify)
+	if err != nil {
+		b.logger.Info("err", zap.Error(err))
+		return ErrInvalidUserToken
+	}
+	rows, err := b.Db.User.SetEmailVerified(userToken.UserID, userToken.Email)
+	if errors.Is(err, repo.ErrEmailTaken) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvalidUserToken
+	}
+	return nil
+}
+
+// issueUserToken stores the hash of a new single-use token, earlier tokens of the
+// same purpose stop working.
+func (b *BL) issueUserToken(userID int, purpose string, email string, ttl time.Duration) (string, error) {
+	if _, err := b.Db.UserToken.InvalidateUserTokens(userID, purpose); err != nil {
+		return "", err
+	}
+	token, err := utils.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = b.Db.UserToken.CreateUserToken(&repo.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(token),
+		Email:     email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
@@ -0,0 +1,109 @@
+package bl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+)
+
+const (
+	PermGenreManage = "genre:manage"
+
+	maxGenreLength = 50
+)
+
+var (
+	ErrInvalidGenre  = errors.New("genre name must be 1 to 50 printable characters")
+	ErrGenreNotFound = errors.New("unknown genre")
+	ErrGenreExists   = errors.New("genre exists")
+)
+
+// normalizeGenre makes genre names case-insensitive, they are stored in lower case.
+func normalizeGenre(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func validGenre(name string) bool {
+	length := utf8.RuneCountInString(name)
+	return length > 0 && length <= maxGenreLength && strings.IndexFunc(name, unicode.IsControl) < 0
+}
+
+func genreIos(genres []repo.Genre) []models.Genre {
+	result := make([]models.Genre, 0, len(genres))
+	for _, genre := range genres {
+		result = append(result, models.GenreFromRepo(genre))
+	}
+	return result
+}
+
+func (b *BL) GetGenres() ([]models.Genre, error) {
+	b.logger.Info("get genres")
+
+	genres, err := b.Db.Genre.GetGenres()
+	if err != nil {
+		return nil, err
+	}
+	return genreIos(genres), nil
+}
+
+func (b *BL) CreateGenre(ctx context.Context, req models.GenreCreateRequest) (models.Genre, error) {
+	b.logger.Info("create genre")
+
+	genre := repo.Genre{Name: normalizeGenre(req.Name)}
+	if !validGenre(genre.Name) {
+		return models.Genre{}, ErrInvalidGenre
+	}
+	err := b.Db.Genre.CreateGenre(&genre)
+	if errors.Is(err, repo.ErrGenreTaken) {
+		return models.Genre{}, ErrGenreExists
+	}
+	if err != nil {
+		return models.Genre{}, err
+	}
+	err = b.audit(ctx, AuditActionCreate, AuditEntityGenre, genre.ID, nil, models.GenreFromRepo(genre))
+	return models.GenreFromRepo(genre), err
+}
+
+// SetMovieGenres replaces the genres of the movie, every genre has to exist.
+func (b *BL) SetMovieGenres(ctx context.Context, req models.MovieGenresRequest) ([]models.Genre, error) {
+	b.logger.Info("set movie genres")
+
+	names := make([]string, 0, len(req.Genres))
+	for _, name := range req.Genres {
+		name = normalizeGenre(name)
+		if !validGenre(name) {
+			return nil, ErrInvalidGenre
+		}
+		names = append(names, name)
+	}
+	names = sortedUnique(names)
+
+	movie, err := b.Db.Movie.GetMovieById(req.MovieID)
+	if err != nil {
+		return nil, ErrMovieNotFound
+	}
+	before, err := b.Db.Genre.GetGenresByMovieIDs([]int{movie.ID})
+	if err != nil {
+		return nil, err
+	}
+	err = b.Db.Genre.SetMovieGenres(movie.ID, names)
+	if errors.Is(err, repo.ErrUnknownGenre) {
+		return nil, ErrGenreNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	after, err := b.Db.Genre.GetGenresByMovieIDs([]int{movie.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.audit(ctx, AuditActionUpdate, AuditEntityMovie, movie.ID,
+		models.MovieIo{Movie: models.MovieFromRepo(movie), Genres: genreIos(before[movie.ID])},
+		models.MovieIo{Movie: models.MovieFromRepo(movie), Genres: genreIos(after[movie.ID])})
+	return genreIos(after[movie.ID]), err
+}
@@ -0,0 +1,33 @@
+package bl
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const GuestLogin = "guest"
+
+var ErrGuestDisabled = errors.New("guest access is disabled")
+
+// GuestRateLimitedError is returned when a guest address has used up its requests.
+type GuestRateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *GuestRateLimitedError) Error() string {
+	return fmt.Sprintf("too many requests, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// AuthenticateGuest returns the principal of a request without credentials.
+// A guest has no user, role or permissions, it may only read the catalog, and
+// every client address is limited on its own.
+func (b *BL) AuthenticateGuest(clientIP string) (Principal, error) {
+	if !b.options.GuestAccess {
+		return Principal{}, ErrGuestDisabled
+	}
+	if retryAfter, ok := b.guests.Allow(clientIP, time.Now()); !ok {
+		return Principal{}, &GuestRateLimitedError{RetryAfter: retryAfter}
+	}
+	return Principal{Login: GuestLogin, Guest: true}, nil
+}
@@ -0,0 +1,185 @@
+package bl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+)
+
+const maxDisplayNameLength = 100
+
+// exportAuditBatch is the page size used to collect the audit events of an export.
+const exportAuditBatch = 500
+
+var ErrInvalidDisplayName = errors.New("display name must be at most 100 printable characters")
+
+// currentUser loads the user the request is authenticated as.
+func (b *BL) currentUser(ctx context.Context) (repo.User, error) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.UserID == 0 {
+		return repo.User{}, ErrUserNotFound
+	}
+	user, err := b.Db.User.GetUserById(principal.UserID)
+	if err != nil {
+		return repo.User{}, ErrUserNotFound
+	}
+	if user.Disabled {
+		return repo.User{}, ErrUserDisabled
+	}
+	return user, nil
+}
+
+func (b *BL) GetProfile(ctx context.Context) (models.ProfileIo, error) {
+	b.logger.Info("get profile")
+
+	user, err := b.currentUser(ctx)
+	if err != nil {
+		return models.ProfileIo{}, err
+	}
+	return b.profile(user)
+}
+
+// UpdateProfile changes the display data of the current user, an empty
+// display name clears it.
+func (b *BL) UpdateProfile(ctx context.Context, req models.ProfileUpdateRequest) (models.ProfileIo, error) {
+	b.logger.Info("update profile")
+
+	user, err := b.currentUser(ctx)
+	if err != nil {
+		return models.ProfileIo{}, err
+	}
+	if req.DisplayName != nil {
+		name := strings.TrimSpace(*req.DisplayName)
+		if utf8.RuneCountInString(name) > maxDisplayNameLength || strings.IndexFunc(name, unicode.IsControl) >= 0 {
+			return models.ProfileIo{}, ErrInvalidDisplayName
+		}
+		if _, err := b.Db.User.SetDisplayName(user.ID, name); err != nil {
+			return models.ProfileIo{}, err
+		}
+		user.DisplayName = name
+	}
+	return b.profile(user)
+}
+
+// ExportUserData collects everything stored about the current user.
+func (b *BL) ExportUserData(ctx context.Context) (models.UserExport, error) {
+	b.logger.Info("export user data")
+
+	user, err := b.currentUser(ctx)
+	if err != nil {
+		return models.UserExport{}, err
+	}
+	profile, err := b.profile(user)
+	if err != nil {
+		return models.UserExport{}, err
+	}
+	export := models.UserExport{
+		ExportedAt:  time.Now().UTC(),
+		Profile:     profile,
+		Sessions:    []models.SessionIo{},
+		ApiKeys:     []models.ApiKeyIo{},
+		Identities:  []models.IdentityIo{},
+		AuditEvents: []models.AuditEventIo{},
+	}
+
+	tokens, err := b.Db.Refresh.GetUserRefreshTokens(user.ID)
+	if err != nil {
+		return models.UserExport{}, err
+	}
+	for _, token := range tokens {
+		export.Sessions = append(export.Sessions, models.SessionIo{
+			CreatedAt: token.CreatedAt,
+			ExpiresAt: token.ExpiresAt,
+			RevokedAt: token.RevokedAt,
+		})
+	}
+
+	keys, err := b.Db.ApiKey.GetApiKeys()
+	if err != nil {
+		return models.UserExport{}, err
+	}
+	for _, key := range keys {
+		if key.CreatedBy != nil && *key.CreatedBy == user.ID {
+			export.ApiKeys = append(export.ApiKeys, apiKeyIo(key))
+		}
+	}
+
+	identities, err := b.Db.Oidc.GetUserIdentities(user.ID)
+	if err != nil {
+		return models.UserExport{}, err
+	}
+	for _, identity := range identities {
+		export.Identities = append(export.Identities, models.IdentityIo{
+			Issuer:    identity.Issuer,
+			Subject:   identity.Subject,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+
+	reviews, err := b.Db.Review.GetUserReviews(user.ID)
+	if err != nil {
+		return models.UserExport{}, err
+	}
+	export.Reviews = reviewIos(reviews)
+
+	filter := repo.AuditFilter{ActorUserID: user.ID}
+	for offset := 0; ; offset += exportAuditBatch {
+		events, total, err := b.Db.Audit.GetAuditEvents(filter, exportAuditBatch, offset)
+		if err != nil {
+			return models.UserExport{}, err
+		}
+		for _, event := range events {
+			export.AuditEvents = append(export.AuditEvents, auditEventIo(event))
+		}
+		if len(events) == 0 || offset+len(events) >= total {
+			break
+		}
+	}
+	return export, nil
+}
+
+// DeleteAccount deletes the current user and the data the user owns after the
+// password is confirmed. The last administrator cannot delete themselves.
+func (b *BL) DeleteAccount(ctx context.Context, req models.AccountDeleteRequest) error {
+	b.logger.Info("delete account")
+
+	user, err := b.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	if err := b.hasher.Verify(user.Pass, req.Pass); err != nil {
+		return ErrWrongPassword
+	}
+	rows, err := b.Db.User.DeleteAccount(user.ID, PermUserManage)
+	if err != nil {
+		return lastAdmin(err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	b.roles.Invalidate(user.ID)
+	return nil
+}
+
+func (b *BL) profile(user repo.User) (models.ProfileIo, error) {
+	mfa, err := b.Db.Mfa.GetMfa(user.ID)
+	if err != nil {
+		return models.ProfileIo{}, fmt.Errorf("mfa status: %w", err)
+	}
+	return models.ProfileIo{
+		ID:            user.ID,
+		Login:         user.Login,
+		DisplayName:   user.DisplayName,
+		Role:          user.Role,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		MfaEnabled:    mfa.Enabled,
+		CreatedAt:     user.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,262 @@
+package bl
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"slices"
+	"strings"
+	"time"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/utils"
+)
+
+const (
+	TokenPurposeMfa = "mfa"
+
+	recoveryCodeCount = 10
+	// mfaSkew is the number of time steps accepted around the current one.
+	mfaSkew = 1
+)
+
+var (
+	ErrInvalidMfaToken   = errors.New("invalid or expired mfa token")
+	ErrInvalidMfaCode    = errors.New("invalid code")
+	ErrMfaAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMfaNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrMfaMandatory      = errors.New("two-factor authentication is mandatory for the role")
+)
+
+// StartMfaEnrollment generates a new TOTP secret for the principal of ctx. The
+// secret is not used for logins until a code is confirmed with ConfirmMfaEnrollment.
+func (b *BL) StartMfaEnrollment(ctx context.Context) (models.MfaEnrollResponse, error) {
+	b.logger.Info("start mfa enrollment")
+
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.UserID == 0 {
+		return models.MfaEnrollResponse{}, ErrUserNotFound
+	}
+	mfa, err := b.Db.Mfa.GetMfa(principal.UserID)
+	if err != nil {
+		return models.MfaEnrollResponse{}, err
+	}
+	if mfa.Enabled {
+		return models.MfaEnrollResponse{}, ErrMfaAlreadyEnabled
+	}
+
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		return models.MfaEnrollResponse{}, err
+	}
+	if err := b.Db.Mfa.SetMfaSecret(principal.UserID, secret); err != nil {
+		return models.MfaEnrollResponse{}, err
+	}
+	return models.MfaEnrollResponse{
+		Secret: secret,
+		URI:    utils.TOTPURI(b.options.MfaIssuer, principal.Login, secret),
+	}, nil
+}
+
+// ConfirmMfaEnrollment enables the second factor once the user proves the
+// authenticator works. Existing sessions are revoked, the returned tokens
+// replace them together with the recovery codes.
+func (b *BL) ConfirmMfaEnrollment(ctx context.Context, code string) (models.MfaEnabledResponse, error) {
+	b.logger.Info("confirm mfa enrollment")
+
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.UserID == 0 {
+		return models.MfaEnabledResponse{}, ErrUserNotFound
+	}
+	mfa, err := b.Db.Mfa.GetMfa(principal.UserID)
+	if err != nil {
+		return models.MfaEnabledResponse{}, err
+	}
+	if mfa.Enabled {
+		return models.MfaEnabledResponse{}, ErrMfaAlreadyEnabled
+	}
+	if len(mfa.Secret) == 0 {
+		return models.MfaEnabledResponse{}, ErrMfaNotEnrolled
+	}
+	if err := b.checkTotp(mfa, code); err != nil {
+		return models.MfaEnabledResponse{}, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		recovery, err := utils.NewRecoveryCode()
+		if err != nil {
+			return models.MfaEnabledResponse{}, err
+		}
+		codes = append(codes, recovery)
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(recovery)))
+	}
+	if err := b.Db.Mfa.EnableMfa(principal.UserID, hashes); err != nil {
+		return models.MfaEnabledResponse{}, err
+	}
+
+	if _, err := b.Db.Refresh.RevokeUserRefreshTokens(principal.UserID); err != nil {
+		return models.MfaEnabledResponse{}, err
+	}
+	user, err := b.Db.User.GetUserById(principal.UserID)
+	if err != nil {
+		return models.MfaEnabledResponse{}, err
+	}
+	tokens, err := b.issueTokens(user, "")
+	if err != nil {
+		return models.MfaEnabledResponse{}, err
+	}
+	return models.MfaEnabledResponse{RecoveryCodes: codes, Tokens: tokens}, nil
+}
+
+// DisableMfa removes the second factor after checking a code or a recovery
+// code. It is refused while the role of the user requires a second factor.
+func (b *BL) DisableMfa(ctx context.Context, req models.MfaCodeRequest) error {
+	b.logger.Info("disable mfa")
+
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.UserID == 0 {
+		return ErrUserNotFound
+	}
+	role, err := b.roles.Get(principal.UserID)
+	if err != nil {
+		return err
+	}
+	mandatory, err := b.mfaMandatory(role)
+	if err != nil {
+		return err
+	}
+	if mandatory {
+		return ErrMfaMandatory
+	}
+	if err := b.verifySecondFactor(principal.UserID, req); err != nil {
+		return err
+	}
+	_, err = b.Db.Mfa.DeleteMfa(principal.UserID)
+	return err
+}
+
+// CompleteMfaLogin exchanges the mfa token from AuthUser and a code or a
+// recovery code for the token pair. Wrong codes count as failed logins.
+func (b *BL) CompleteMfaLogin(req models.MfaLoginRequest, clientIP string) (models.TokenResponse, error) {
+	b.logger.Info("complete mfa login")
+
+	claims, err := utils.ValidateToken(req.MfaToken)
+	if err != nil || claims.Purpose != TokenPurposeMfa {
+		return models.TokenResponse{}, ErrInvalidMfaToken
+	}
+	if err := b.checkLoginThrottle(claims.Subject, clientIP); err != nil {
+		return models.TokenResponse{}, err
+	}
+	user, err := b.Db.User.GetUserById(claims.UserID)
+	if err != nil {
+		b.logger.Info("err", zap.Error(err))
+		return models.TokenResponse{}, ErrInvalidMfaToken
+	}
+	if user.Disabled {
+		return models.TokenResponse{}, ErrUserDisabled
+	}
+
+	err = b.verifySecondFactor(user.ID, models.MfaCodeRequest{Code: req.Code, RecoveryCode: req.RecoveryCode})
+	if errors.Is(err, ErrInvalidMfaCode) {
+		b.recordLoginFailure(user.Login, clientIP)
+	}
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	b.resetLoginFailures(user.Login)
+	return b.issueTokens(user, "")
+}
+
+// mfaPending returns the short-lived token that only CompleteMfaLogin accepts.
+func (b *BL) mfaPending(user repo.User) (models.TokenResponse, error) {
+	token, err := utils.GenerateToken(b.options.MfaPendingTTL, utils.TokenClaims{
+		Subject: user.Login,
+		UserID:  user.ID,
+		Purpose: TokenPurposeMfa,
+	})
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	return models.TokenResponse{
+		MfaRequired: true,
+		MfaToken:    token,
+		ExpiresIn:   int64(b.options.MfaPendingTTL.Seconds()),
+	}, nil
+}
+
+// verifySecondFactor checks a TOTP code or, when given, a recovery code of an
+// enabled enrollment. Both can be used only once.
+func (b *BL) verifySecondFactor(userID int, req models.MfaCodeRequest) error {
+	mfa, err := b.Db.Mfa.GetMfa(userID)
+	if err != nil {
+		return err
+	}
+	if !mfa.Enabled {
+		return ErrMfaNotEnrolled
+	}
+	if len(req.RecoveryCode) > 0 {
+		hash := utils.HashToken(utils.NormalizeRecoveryCode(req.RecoveryCode))
+		rows, err := b.Db.Mfa.UseRecoveryCode(userID, hash)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrInvalidMfaCode
+		}
+		return nil
+	}
+	return b.checkTotp(mfa, req.Code)
+}
+
+// checkTotp accepts a code whose time step is later than the last accepted one,
+// so an observed code cannot be replayed.
+func (b *BL) checkTotp(mfa repo.Mfa, code string) error {
+	step, ok := utils.VerifyTOTP(mfa.Secret, code, time.Now(), mfaSkew)
+	if !ok {
+		return ErrInvalidMfaCode
+	}
+	rows, err := b.Db.Mfa.UseMfaStep(mfa.UserID, step)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvalidMfaCode
+	}
+	return nil
+}
+
+// writePermissions allow changes, every delete permission does as well.
+var writePermissions = []string{PermMovieWrite, PermActorWrite, PermRoleManage, PermUserManage, PermApiKeyManage, PermGenreManage}
+
+func isWritePermission(permission string) bool {
+	return slices.Contains(writePermissions, permission) || strings.HasSuffix(permission, ":delete")
+}
+
+// mfaMandatory reports whether the role requires a second factor. When enabled
+// by the options it is required for every role with a write permission.
+func (b *BL) mfaMandatory(role string) (bool, error) {
+	if !b.options.MfaRequired {
+		return false, nil
+	}
+	permissions, err := b.permissions.Get(role)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(permissions, isWritePermission), nil
+}
+
+// mustEnrollMfa reports whether the user has to enroll a second factor before
+// using the rest of the API.
+func (b *BL) mustEnrollMfa(userID int, role string) (bool, error) {
+	mandatory, err := b.mfaMandatory(role)
+	if err != nil || !mandatory {
+		return false, err
+	}
+	mfa, err := b.Db.Mfa.GetMfa(userID)
+	if err != nil {
+		return false, err
+	}
+	return !mfa.Enabled, nil
+}
@@ -1,54 +1,135 @@
 package bl
 
 import (
+	"context"
+	"errors"
 	"go.uber.org/zap"
-	"time"
+	"slices"
 	"vk-inter-test-go/internal/db/repo"
 	"vk-inter-test-go/internal/io/models"
-	"vk-inter-test-go/internal/utils"
 )
 
-func (b *BL) CreateMovie(movie models.MovieIo) (models.MovieIo, error) {
+var (
+	ErrMovieNotFound   = errors.New("movie not found")
+	ErrDuplicateCredit = errors.New("person is credited twice for the same job")
+)
+
+// CreateMovie creates the movie and credits its cast and crew, persons that
+// are not known yet are created. Nothing is created when a credit fails.
+// Genres are not taken from the request, they are assigned with SetMovieGenres.
+func (b *BL) CreateMovie(ctx context.Context, movie models.MovieIo) (models.MovieIo, error) {
 	b.logger.Info("create movie")
 
-	err := b.Db.Movie.CreateMovie(&movie.Movie)
+	dbMovie, err := movie.Movie.ToRepo()
 	if err != nil {
 		return models.MovieIo{}, err
 	}
-	actorIDs := make([]int, 0)
-	for i, actor := range movie.Actors {
-		dbActor, err := b.Db.Actor.GetActorByName(actor.Name)
-		if err != nil {
-			err := b.Db.Actor.CreateActor(&movie.Actors[i])
-			if err != nil {
-				b.logger.Info("err :", zap.Error(err))
-			}
-			actorIDs = append(actorIDs, movie.Actors[i].ID)
-			continue
+	credits := make([]repo.PersonCredit, 0, len(movie.Actors)+len(movie.Crew))
+	for _, member := range movie.Actors {
+		credit, ok := b.personCredit(member.Actor, castCredit(member))
+		if !ok {
+			return models.MovieIo{}, ErrInvalidActor
 		}
-		actorIDs = append(actorIDs, dbActor.ID)
-		movie.Actors[i].ID = dbActor.ID
+		credits = append(credits, credit)
+	}
+	for _, member := range movie.Crew {
+		credit, ok := b.personCredit(member.Actor, repo.MovieActor{CreditType: member.Job})
+		if !ok {
+			return models.MovieIo{}, ErrInvalidActor
+		}
+		credits = append(credits, credit)
 	}
 
-	err = b.Db.MovieActor.CreateMovieActorRelation(movie.Movie.ID, actorIDs)
+	resolved, created, err := b.Db.Movie.CreateMovie(&dbMovie, credits)
+	if errors.Is(err, repo.ErrCreditExists) {
+		return models.MovieIo{}, ErrDuplicateCredit
+	}
 	if err != nil {
 		return models.MovieIo{}, err
 	}
-	return movie, nil
+	movie.Movie.ID = dbMovie.ID
+	movie.Genres = []models.Genre{}
+	for i := range movie.Actors {
+		movie.Actors[i].ID = resolved[i].ActorID
+	}
+	sortCast(movie.Actors)
+	for i := range movie.Crew {
+		movie.Crew[i].ID = resolved[len(movie.Actors)+i].ActorID
+	}
+	if movie.Crew == nil {
+		movie.Crew = []models.CrewMember{}
+	}
 
+	if err = b.auditCreated(ctx, created); err != nil {
+		return movie, err
+	}
+	err = b.audit(ctx, AuditActionCreate, AuditEntityMovie, movie.Movie.ID, nil, movie)
+	return movie, err
 }
 
-func (b *BL) DeleteMovie(id int) (int64, error) {
+func castCredit(member models.CastMember) repo.MovieActor {
+	return repo.MovieActor{
+		ActorID:    member.ID,
+		CreditType: repo.CreditActor,
+		Character:  member.Character,
+		Billing:    member.Billing,
+		Cameo:      member.Cameo,
+	}
+}
+
+// sortCast orders the cast by billing, actors that are not billed come last
+// in their original order.
+func sortCast(cast []models.CastMember) {
+	slices.SortStableFunc(cast, func(a, b models.CastMember) int {
+		switch {
+		case a.Billing == b.Billing:
+			return 0
+		case a.Billing == 0:
+			return 1
+		case b.Billing == 0:
+			return -1
+		}
+		return a.Billing - b.Billing
+	})
+}
+
+// personCredit makes the credit of the person named by actor. A person that is
+// not known yet is created by the repo together with the credit, personCredit
+// reports false when actor cannot be created.
+func (b *BL) personCredit(actor models.Actor, credit repo.MovieActor) (repo.PersonCredit, bool) {
+	res := repo.PersonCredit{MovieActor: credit}
+	dbActor, err := b.Db.Actor.GetActorByName(actor.Name)
+	if err == nil {
+		res.ActorID = dbActor.ID
+		return res, true
+	}
+	res.ActorID = 0
+	res.Person, err = actor.ToRepo()
+	if err != nil {
+		b.logger.Info("err :", zap.Error(err))
+		return repo.PersonCredit{}, false
+	}
+	return res, true
+}
+
+func (b *BL) DeleteMovie(ctx context.Context, id int) (int64, error) {
 	b.logger.Info("delete movie")
 
+	dbMovie, err := b.Db.Movie.GetMovieById(id)
+	if err != nil {
+		return 0, err
+	}
 	rows, err := b.Db.Movie.DeleteMovieById(id)
 	if err != nil {
 		return 0, err
 	}
-	return rows, nil
+	if rows > 0 {
+		err = b.audit(ctx, AuditActionDelete, AuditEntityMovie, id, models.MovieFromRepo(dbMovie), nil)
+	}
+	return rows, err
 }
 
-func (b *BL) UpdateMovie(movie repo.Movie) (repo.Movie, error) {
+func (b *BL) UpdateMovie(ctx context.Context, movie repo.Movie) (repo.Movie, error) {
 	dbMovie, err := b.Db.Movie.GetMovieById(movie.ID)
 	if err != nil {
 		return repo.Movie{}, err
@@ -62,14 +143,8 @@ func (b *BL) UpdateMovie(movie repo.Movie) (repo.Movie, error) {
 		movie.Description = dbMovie.Description
 	}
 
-	if len(movie.ReleaseDateJson) == 0 {
-		movie.ReleaseDateJson = dbMovie.ReleaseDate.Format("2006-01-02")
+	if movie.ReleaseDate.IsZero() {
 		movie.ReleaseDate = dbMovie.ReleaseDate
-	} else {
-		movie.ReleaseDate, err = time.Parse("2006-01-02", movie.ReleaseDateJson)
-		if err != nil {
-			return repo.Movie{}, err
-		}
 	}
 
 	if movie.Rating == 0 {
@@ -86,13 +161,33 @@ func (b *BL) UpdateMovie(movie repo.Movie) (repo.Movie, error) {
 		return repo.Movie{}, err
 	}
 
-	return movie, nil
+	err = b.audit(ctx, AuditActionUpdate, AuditEntityMovie, movie.ID, models.MovieFromRepo(dbMovie), models.MovieFromRepo(movie))
+	return movie, err
 }
 
 func (b *BL) GetAllMoviesByTitle(title string, orderBy string) ([]models.MovieIo, error) {
 	b.logger.Info("get movies by title")
 
-	allMovies, err := b.Db.Movie.GetMoviesLikeTitle(title, orderBy)
+	return b.GetMovies(repo.MovieFilter{Title: title}, orderBy)
+}
+
+func (b *BL) GetAllMoviesByNameActor(name string, orderBy string) ([]models.MovieIo, error) {
+	b.logger.Info("get movies by name actor")
+
+	return b.GetMovies(repo.MovieFilter{Actor: name}, orderBy)
+}
+
+// GetMovies returns the movies matching the filter together with their cast,
+// crew, genres and user score. An actor name overrides the title, the genre
+// and the director narrow either search.
+func (b *BL) GetMovies(filter repo.MovieFilter, orderBy string) ([]models.MovieIo, error) {
+	b.logger.Info("get movies")
+
+	if len(filter.Actor) != 0 {
+		filter.Title = ""
+	}
+	filter.Genre = normalizeGenre(filter.Genre)
+	allMovies, err := b.Db.Movie.GetMovies(filter, orderBy)
 	if err != nil {
 		return nil, err
 	}
@@ -101,69 +196,55 @@ func (b *BL) GetAllMoviesByTitle(title string, orderBy string) ([]models.MovieIo
 	var movieIDs []int
 
 	for _, movie := range allMovies {
-		movies = append(movies, models.MovieIo{Movie: movie})
+		movies = append(movies, models.MovieIo{Movie: models.MovieFromRepo(movie)})
 		movieIDs = append(movieIDs, movie.ID)
 	}
 
-	movieIDsWithActorIDs, err := b.Db.MovieActor.GetRelationByMovieIDs(movieIDs)
+	castMap, err := b.Db.MovieActor.GetCastByMovieIDs(movieIDs)
 	if err != nil {
 		return nil, err
 	}
 
-	actorIDs := utils.UniqueValues(movieIDsWithActorIDs)
-
-	actorMap, err := b.Db.Actor.GetActorMapByIDs(actorIDs)
+	crewMap, err := b.Db.MovieActor.GetCrewByMovieIDs(movieIDs)
 	if err != nil {
 		return nil, err
 	}
 
-	for i, _ := range movies {
-		for _, actorID := range movieIDsWithActorIDs[movies[i].Movie.ID] {
-			movies[i].Actors = append(movies[i].Actors, actorMap[actorID]...)
+	var actorIDs []int
+	for _, credits := range [](map[int][]repo.MovieActor){castMap, crewMap} {
+		for _, movieCredits := range credits {
+			for _, credit := range movieCredits {
+				actorIDs = append(actorIDs, credit.ActorID)
+			}
 		}
 	}
 
-	return movies, nil
-}
-
-func (b *BL) GetAllMoviesByNameActor(name string, orderBy string) ([]models.MovieIo, error) {
-	b.logger.Info("get movies by name actor")
-
-	allActor, err := b.Db.Actor.GetAllActorsLikeName(name, "")
+	actorMap, err := b.Db.Actor.GetActorMapByIDs(actorIDs)
 	if err != nil {
 		return nil, err
 	}
 
-	var movies []models.MovieIo
-	var actorsIDs []int
-
-	for _, actor := range allActor {
-		actorsIDs = append(actorsIDs, actor.ID)
-	}
-
-	actorIDsWithMovieIDs, err := b.Db.MovieActor.GetRelationByActorIDs(actorsIDs)
+	genreMap, err := b.Db.Genre.GetGenresByMovieIDs(movieIDs)
 	if err != nil {
 		return nil, err
 	}
 
-	movieIDs := utils.UniqueValues(actorIDsWithMovieIDs)
-	movieIDsWithActorIDs, err := b.Db.MovieActor.GetRelationByMovieIDs(movieIDs)
+	scoreMap, err := b.Db.Review.GetScoresByMovieIDs(movieIDs)
 	if err != nil {
 		return nil, err
 	}
 
-	movieMap, err := b.Db.Movie.GetMovieMapByIDs(movieIDs, orderBy)
-	actorMap, err := b.Db.Actor.GetActorMapByIDs(actorsIDs)
-	if err != nil {
-		return nil, err
-	}
-	var i int
-	for k, v := range movieMap {
-		movies = append(movies, models.MovieIo{Movie: v})
-		for _, actorID := range movieIDsWithActorIDs[k] {
-			movies[i].Actors = append(movies[i].Actors, actorMap[actorID]...)
+	for i, _ := range movies {
+		movies[i].Actors = castIos(castMap[movies[i].Movie.ID], actorMap)
+		movies[i].Crew = []models.CrewMember{}
+		for _, credit := range crewMap[movies[i].Movie.ID] {
+			for _, person := range actorMap[credit.ActorID] {
+				movies[i].Crew = append(movies[i].Crew, models.CrewMember{Actor: models.ActorFromRepo(person), Job: credit.CreditType})
+			}
 		}
-		i++
+		movies[i].Genres = genreIos(genreMap[movies[i].Movie.ID])
+		movies[i].UserScore = scoreMap[movies[i].Movie.ID].Average
+		movies[i].Votes = scoreMap[movies[i].Movie.ID].Votes
 	}
 
 	return movies, nil
@@ -0,0 +1,235 @@
+package bl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"slices"
+	"strings"
+	"time"
+	"vk-inter-test-go/internal/config"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/oidc"
+	"vk-inter-test-go/internal/utils"
+)
+
+var (
+	ErrOidcDisabled     = errors.New("oidc login is not configured")
+	ErrInvalidOidcState = errors.New("invalid or expired oidc login")
+	ErrOidcLogin        = errors.New("oidc login failed")
+	ErrOidcLoginTaken   = errors.New("login of the external account is taken by a local user")
+)
+
+// newOidcProvider returns nil when external login is not configured. The
+// roles of the role mapping have to exist.
+func newOidcProvider(options config.OptionsSrv, roles repo.RoleRepository) (*oidc.Provider, error) {
+	if len(options.OidcIssuer) == 0 {
+		return nil, nil
+	}
+	targets := []string{options.OidcDefaultRole}
+	for _, mapping := range options.OidcRoleMap {
+		i := strings.LastIndex(mapping, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("oidc role mapping %q is not group:role", mapping)
+		}
+		targets = append(targets, mapping[i+1:])
+	}
+	for _, role := range targets {
+		if _, err := roles.GetRoleByName(role); err != nil {
+			return nil, fmt.Errorf("oidc role %q does not exist: %w", role, err)
+		}
+	}
+	return oidc.NewProvider(oidc.Config{
+		Issuer:       options.OidcIssuer,
+		ClientID:     options.OidcClientID,
+		ClientSecret: options.OidcClientSecret,
+		RedirectURL:  options.OidcRedirectURL,
+		Scopes:       options.OidcScopes,
+	}, nil), nil
+}
+
+// StartOidcLogin returns the address of the provider login page and the state
+// of the login. The state, nonce and PKCE verifier of the login are kept until
+// the provider redirects back to CompleteOidcLogin, the caller has to bind the
+// state to the browser that started the login.
+func (b *BL) StartOidcLogin(ctx context.Context) (string, string, error) {
+	b.logger.Info("start oidc login")
+
+	if b.oidc == nil {
+		return "", "", ErrOidcDisabled
+	}
+	state, err := utils.NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.NewPKCEVerifier()
+	if err != nil {
+		return "", "", err
+	}
+	err = b.Db.Oidc.CreateLoginState(&repo.OidcLoginState{
+		StateHash:    utils.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(b.options.OidcStateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	uri, err := b.oidc.AuthCodeURL(ctx, state, nonce, oidc.PKCEChallenge(verifier))
+	if err != nil {
+		b.logger.Error("oidc provider", zap.Error(err))
+		return "", "", ErrOidcLogin
+	}
+	return uri, state, nil
+}
+
+// CompleteOidcLogin redeems the code the provider redirected with and logs in
+// the local user linked to the external account. On the first login the user
+// is created, or linked by a verified email when OidcLinkEmail is set, the
+// role follows the groups of the account on every login. Users with a second factor still have to
+// complete the login with CompleteMfaLogin.
+func (b *BL) CompleteOidcLogin(ctx context.Context, code string, state string) (models.TokenResponse, error) {
+	b.logger.Info("complete oidc login")
+
+	if b.oidc == nil {
+		return models.TokenResponse{}, ErrOidcDisabled
+	}
+	if len(state) == 0 {
+		return models.TokenResponse{}, ErrInvalidOidcState
+	}
+	login, err := b.Db.Oidc.UseLoginState(utils.HashToken(state))
+	if err != nil {
+		b.logger.Info("err", zap.Error(err))
+		return models.TokenResponse{}, ErrInvalidOidcState
+	}
+	claims, err := b.oidc.Exchange(ctx, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		b.logger.Info("oidc exchange", zap.Error(err))
+		return models.TokenResponse{}, ErrOidcLogin
+	}
+
+	user, err := b.oidcUser(claims)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	if user.Disabled {
+		return models.TokenResponse{}, ErrUserDisabled
+	}
+	user, err = b.syncOidcRole(user, b.oidcRole(claims))
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	mfa, err := b.Db.Mfa.GetMfa(user.ID)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	if mfa.Enabled {
+		return b.mfaPending(user)
+	}
+	return b.issueTokens(user, "")
+}
+
+// oidcUser returns the user linked to the external account, linking or
+// creating one on the first login. An existing user is linked only when
+// OidcLinkEmail is set and both sides have verified the same email, otherwise
+// a login collision is an error.
+func (b *BL) oidcUser(claims oidc.Claims) (repo.User, error) {
+	issuer, subject := b.options.OidcIssuer, claims.String("sub")
+	userID, err := b.Db.Oidc.GetIdentityUserId(issuer, subject)
+	if err != nil {
+		return repo.User{}, err
+	}
+	if userID != 0 {
+		return b.Db.User.GetUserById(userID)
+	}
+
+	email := claims.String("email")
+	verified := len(email) > 0 && claims.Bool("email_verified")
+	if verified && b.options.OidcLinkEmail {
+		user, err := b.Db.User.GetUserByEmail(email)
+		if err == nil && user.EmailVerified {
+			b.logger.Info("link oidc identity", zap.String("login", user.Login))
+			return user, b.Db.Oidc.CreateIdentity(issuer, subject, user.ID)
+		}
+	}
+
+	login := claims.String(b.options.OidcLoginClaim)
+	if len(login) == 0 {
+		b.logger.Info("oidc claims have no login", zap.String("claim", b.options.OidcLoginClaim))
+		return repo.User{}, ErrOidcLogin
+	}
+	role, err := b.Db.Role.GetRoleByName(b.oidcRole(claims))
+	if err != nil {
+		return repo.User{}, err
+	}
+	// The account has no usable password, the user may set one with a
+	// password reset once the email is verified.
+	password, err := utils.NewOpaqueToken()
+	if err != nil {
+		return repo.User{}, err
+	}
+	user := repo.User{Login: login, RoleID: role.ID}
+	user.Pass, err = b.hasher.Hash(password)
+	if err != nil {
+		return repo.User{}, err
+	}
+	err = b.Db.User.CreateUser(&user)
+	if errors.Is(err, repo.ErrLoginTaken) {
+		return repo.User{}, ErrOidcLoginTaken
+	}
+	if err != nil {
+		return repo.User{}, err
+	}
+	b.logger.Info("create oidc user", zap.String("login", user.Login))
+	if err := b.Db.Oidc.CreateIdentity(issuer, subject, user.ID); err != nil {
+		return repo.User{}, err
+	}
+	if verified {
+		if _, err := b.Db.User.SetUserEmail(user.ID, email); err != nil {
+			b.logger.Info("oidc email not set", zap.Error(err))
+		} else if _, err := b.Db.User.SetEmailVerified(user.ID, email); errors.Is(err, repo.ErrEmailTaken) {
+			b.logger.Info("oidc email is verified by another user", zap.Int("user", user.ID))
+		} else if err != nil {
+			return repo.User{}, err
+		}
+	}
+	return b.Db.User.GetUserById(user.ID)
+}
+
+// oidcRole returns the role of the first mapping whose group the account is
+// a member of, or the default role.
+func (b *BL) oidcRole(claims oidc.Claims) string {
+	groups := claims.Strings(b.options.OidcRoleClaim)
+	for _, mapping := range b.options.OidcRoleMap {
+		i := strings.LastIndex(mapping, ":")
+		if slices.Contains(groups, mapping[:i]) {
+			return mapping[i+1:]
+		}
+	}
+	return b.options.OidcDefaultRole
+}
+
+// syncOidcRole gives the user the role mapped from the provider. The last
+// administrator keeps the role so that a provider change cannot lock
+// everyone out of administration.
+func (b *BL) syncOidcRole(user repo.User, role string) (repo.User, error) {
+	if user.Role == role {
+		return user, nil
+	}
+	err := b.setUserRole(user.ID, role, PermUserManage)
+	if errors.Is(err, ErrLastAdmin) {
+		b.logger.Warn("oidc role not applied to the last admin", zap.String("login", user.Login))
+		return user, nil
+	}
+	if err != nil {
+		return repo.User{}, err
+	}
+	user.Role = role
+	return user, nil
+}
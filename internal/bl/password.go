@@ -0,0 +1,78 @@
+package bl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+)
+
+var (
+	ErrWeakPassword  = errors.New("password does not meet the policy")
+	ErrWrongPassword = errors.New("wrong pass")
+)
+
+func (b *BL) checkPassword(password string, login string) error {
+	if err := b.passwords.Check(password, login); err != nil {
+		return fmt.Errorf("%w: %s", ErrWeakPassword, err.Error())
+	}
+	return nil
+}
+
+// ChangePassword replaces the password of the principal of ctx. All other
+// sessions of the user are revoked and a fresh token pair is returned.
+func (b *BL) ChangePassword(ctx context.Context, req models.PasswordChangeRequest) (models.TokenResponse, error) {
+	b.logger.Info("change password")
+
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return models.TokenResponse{}, ErrUserNotFound
+	}
+	user, err := b.Db.User.GetUserById(principal.UserID)
+	if err != nil {
+		return models.TokenResponse{}, ErrUserNotFound
+	}
+	if err := b.hasher.Verify(user.Pass, req.OldPass); err != nil {
+		return models.TokenResponse{}, ErrWrongPassword
+	}
+	if req.NewPass == req.OldPass {
+		return models.TokenResponse{}, fmt.Errorf("%w: new password must differ from the old one", ErrWeakPassword)
+	}
+	if err := b.checkPassword(req.NewPass, user.Login); err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	hash, err := b.hasher.Hash(req.NewPass)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	if _, err := b.Db.User.UpdatePassword(user.ID, hash); err != nil {
+		return models.TokenResponse{}, err
+	}
+	if _, err := b.Db.Refresh.RevokeUserRefreshTokens(user.ID); err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	user.MustChangePassword = false
+	return b.issueTokens(user, "")
+}
+
+// rehashPassword replaces a hash made with another algorithm or other parameters
+// than configured. A failure only leaves the old hash in place.
+func (b *BL) rehashPassword(user repo.User, password string) {
+	if !b.hasher.NeedsRehash(user.Pass) {
+		return
+	}
+	hash, err := b.hasher.Hash(password)
+	if err != nil {
+		b.logger.Error("rehash password", zap.Error(err))
+		return
+	}
+	if _, err := b.Db.User.SetPasswordHash(user.ID, hash); err != nil {
+		b.logger.Error("rehash password", zap.Error(err))
+		return
+	}
+	b.logger.Info("password rehashed", zap.Int("user", user.ID))
+}
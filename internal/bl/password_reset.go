@@ -0,0 +1,77 @@
+package bl
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/mail"
+	"vk-inter-test-go/internal/utils"
+)
+
+// RequestPasswordReset mails a reset token to the user with the verified email.
+// Nothing tells the caller whether such a user exists, so unknown and unverified
+// emails are only logged.
+func (b *BL) RequestPasswordReset(email string) error {
+	b.logger.Info("request password reset")
+
+	user, err := b.Db.User.GetUserByEmail(email)
+	if err != nil {
+		b.logger.Info("reset for unknown email", zap.Error(err))
+		return nil
+	}
+	if !user.EmailVerified || user.Disabled {
+		b.logger.Info("reset refused", zap.Int("user", user.ID))
+		return nil
+	}
+
+	token, err := b.issueUserToken(user.ID, repo.TokenPurposePasswordReset, "", b.options.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	return b.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Логин: %s\n\nДля установки нового пароля отправьте токен на POST /api/password/reset/confirm:\n\n%s\n\n"+
+			"Токен действует до %s. Если вы не запрашивали сброс, проигнорируйте это письмо.",
+			user.Login, token, time.Now().Add(b.options.PasswordResetTTL).Format(time.RFC1123)),
+	})
+}
+
+// ConfirmPasswordReset sets a new password with a reset token. The token is used
+// up only once the new password passes the policy. All sessions are revoked and
+// the failed login attempts of the user are forgotten.
+func (b *BL) ConfirmPasswordReset(req models.PasswordResetConfirmRequest) error {
+	b.logger.Info("confirm password reset")
+
+	hash := utils.HashToken(req.Token)
+	userToken, err := b.Db.UserToken.GetValidUserToken(hash, repo.TokenPurposePasswordReset)
+	if err != nil {
+		b.logger.Info("err", zap.Error(err))
+		return ErrInvalidUserToken
+	}
+	user, err := b.Db.User.GetUserById(userToken.UserID)
+	if err != nil {
+		return ErrInvalidUserToken
+	}
+	if err := b.checkPassword(req.NewPass, user.Login); err != nil {
+		return err
+	}
+	if _, err := b.Db.UserToken.UseUserToken(hash, repo.TokenPurposePasswordReset); err != nil {
+		return ErrInvalidUserToken
+	}
+
+	pass, err := b.hasher.Hash(req.NewPass)
+	if err != nil {
+		return err
+	}
+	if _, err := b.Db.User.UpdatePassword(user.ID, pass); err != nil {
+		return err
+	}
+	if _, err := b.Db.Refresh.RevokeUserRefreshTokens(user.ID); err != nil {
+		return err
+	}
+	b.resetLoginFailures(user.Login)
+	return nil
+}
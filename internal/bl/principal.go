@@ -0,0 +1,51 @@
+package bl
+
+import "context"
+
+// Principal is the authenticated caller of a request.
+// Role stays empty until it has been resolved.
+type Principal struct {
+	UserID int
+	Login  string
+	Role   string
+	// ApiKeyID and Permissions are set when the caller is an API key,
+	// such a principal has no user and no role.
+	ApiKeyID    int
+	Permissions []string
+	// MustChangePassword is set until the user replaces an initial password,
+	// such a principal may only change the password.
+	MustChangePassword bool
+	// MustEnrollMfa is set until a user whose role requires a second factor
+	// enrolls one, such a principal may only enroll.
+	MustEnrollMfa bool
+	// Guest is set for requests without credentials, see AuthenticateGuest.
+	Guest bool
+}
+
+const (
+	RestrictionPasswordChange = "password change required"
+	RestrictionMfaEnrollment  = "mfa enrollment required"
+)
+
+// Restriction names what the principal has to do before it may use the rest of
+// the API, it is empty when nothing.
+func (p Principal) Restriction() string {
+	switch {
+	case p.MustChangePassword:
+		return RestrictionPasswordChange
+	case p.MustEnrollMfa:
+		return RestrictionMfaEnrollment
+	}
+	return ""
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
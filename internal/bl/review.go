@@ -0,0 +1,125 @@
+package bl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+)
+
+const (
+	minReviewScore  = 1
+	maxReviewScore  = 10
+	maxReviewLength = 2000
+)
+
+var (
+	ErrInvalidReview  = errors.New("score must be 1 to 10 and the review at most 2000 characters")
+	ErrReviewExists   = errors.New("movie is already rated")
+	ErrReviewNotFound = errors.New("review not found")
+)
+
+func validReview(score int, text string) bool {
+	return score >= minReviewScore && score <= maxReviewScore && utf8.RuneCountInString(text) <= maxReviewLength
+}
+
+func reviewIos(reviews []repo.Review) []models.ReviewIo {
+	result := make([]models.ReviewIo, 0, len(reviews))
+	for _, review := range reviews {
+		result = append(result, models.ReviewFromRepo(review))
+	}
+	return result
+}
+
+// CreateReview rates the movie as the current user, every user can rate a
+// movie once and edits the review afterwards.
+func (b *BL) CreateReview(ctx context.Context, req models.ReviewCreateRequest) (models.ReviewIo, error) {
+	b.logger.Info("create review")
+
+	user, err := b.currentUser(ctx)
+	if err != nil {
+		return models.ReviewIo{}, err
+	}
+	review := repo.Review{MovieID: req.MovieID, UserID: user.ID, Score: req.Score, Text: strings.TrimSpace(req.Review)}
+	if !validReview(review.Score, review.Text) {
+		return models.ReviewIo{}, ErrInvalidReview
+	}
+	if _, err := b.Db.Movie.GetMovieById(review.MovieID); err != nil {
+		return models.ReviewIo{}, ErrMovieNotFound
+	}
+	err = b.Db.Review.CreateReview(&review)
+	if errors.Is(err, repo.ErrReviewTaken) {
+		return models.ReviewIo{}, ErrReviewExists
+	}
+	if err != nil {
+		return models.ReviewIo{}, err
+	}
+	return models.ReviewFromRepo(review), nil
+}
+
+// GetMyReviews returns the reviews of the current user, the latest first.
+func (b *BL) GetMyReviews(ctx context.Context) ([]models.ReviewIo, error) {
+	b.logger.Info("get my reviews")
+
+	user, err := b.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := b.Db.Review.GetUserReviews(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return reviewIos(reviews), nil
+}
+
+// UpdateReview changes a review of the current user, reviews of other users
+// are reported as not found.
+func (b *BL) UpdateReview(ctx context.Context, req models.ReviewUpdateRequest) (models.ReviewIo, error) {
+	b.logger.Info("update review")
+
+	user, err := b.currentUser(ctx)
+	if err != nil {
+		return models.ReviewIo{}, err
+	}
+	review, err := b.Db.Review.GetReviewById(req.ID)
+	if err != nil || review.UserID != user.ID {
+		return models.ReviewIo{}, ErrReviewNotFound
+	}
+	if req.Score != 0 {
+		review.Score = req.Score
+	}
+	if req.Review != nil {
+		review.Text = strings.TrimSpace(*req.Review)
+	}
+	if !validReview(review.Score, review.Text) {
+		return models.ReviewIo{}, ErrInvalidReview
+	}
+	rows, err := b.Db.Review.UpdateReview(&review)
+	if err != nil {
+		return models.ReviewIo{}, err
+	}
+	if rows == 0 {
+		return models.ReviewIo{}, ErrReviewNotFound
+	}
+	return models.ReviewFromRepo(review), nil
+}
+
+// DeleteReview deletes a review of the current user.
+func (b *BL) DeleteReview(ctx context.Context, id int) error {
+	b.logger.Info("delete review")
+
+	user, err := b.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	rows, err := b.Db.Review.DeleteReview(id, user.ID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrReviewNotFound
+	}
+	return nil
+}
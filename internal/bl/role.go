@@ -0,0 +1,125 @@
+package bl
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"sort"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/utils"
+)
+
+const (
+	PermMovieWrite  = "movie:write"
+	PermMovieDelete = "movie:delete"
+	PermActorWrite  = "actor:write"
+	PermActorDelete = "actor:delete"
+	PermUserManage  = "user:manage"
+	PermRoleManage  = "role:manage"
+
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+var ErrBuiltinRole = errors.New("builtin role cannot be changed")
+
+// CheckPermission reports whether the principal of ctx currently has the
+// permission. For users both the role and its permissions come from the caches,
+// API keys carry their permissions and guests have none.
+func (b *BL) CheckPermission(ctx context.Context, permission string) (Principal, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Guest {
+		return Principal{}, false
+	}
+	if principal.ApiKeyID == 0 {
+		role, err := b.roles.Get(principal.UserID)
+		if err != nil {
+			b.logger.Info("err", zap.Error(err))
+			return principal, false
+		}
+		principal.Role = role
+	}
+
+	granted, err := b.hasPermission(principal, permission)
+	if err != nil {
+		b.logger.Info("err", zap.Error(err))
+		return principal, false
+	}
+	return principal, granted
+}
+
+// hasPermission checks a principal whose role has already been resolved.
+func (b *BL) hasPermission(principal Principal, permission string) (bool, error) {
+	if principal.ApiKeyID != 0 {
+		for _, p := range principal.Permissions {
+			if p == permission {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return b.roleHasPermission(principal.Role, permission)
+}
+
+func (b *BL) GetRoles() ([]repo.Role, error) {
+	b.logger.Info("get roles")
+	return b.Db.Role.GetRoles()
+}
+
+func (b *BL) GetPermissions() ([]string, error) {
+	b.logger.Info("get permissions")
+	return b.Db.Role.GetPermissions()
+}
+
+func (b *BL) CreateRole(role repo.Role) (repo.Role, error) {
+	b.logger.Info("create role")
+
+	role.Permissions = sortedUnique(role.Permissions)
+	err := b.Db.Role.CreateRole(&role)
+	if err != nil {
+		return repo.Role{}, err
+	}
+	b.permissions.Invalidate(role.Name)
+	return role, nil
+}
+
+// UpdateRolePermissions replaces the permissions of the role.
+// The admin role always keeps every permission.
+func (b *BL) UpdateRolePermissions(role repo.Role) (repo.Role, error) {
+	b.logger.Info("update role permissions")
+
+	if role.Name == RoleAdmin {
+		return repo.Role{}, ErrBuiltinRole
+	}
+	dbRole, err := b.Db.Role.GetRoleByName(role.Name)
+	if err != nil {
+		return repo.Role{}, err
+	}
+	dbRole.Permissions = sortedUnique(role.Permissions)
+	err = b.Db.Role.SetRolePermissions(dbRole.ID, dbRole.Permissions)
+	if err != nil {
+		return repo.Role{}, err
+	}
+	b.permissions.Invalidate(dbRole.Name)
+	return dbRole, nil
+}
+
+func (b *BL) DeleteRole(name string) (int64, error) {
+	b.logger.Info("delete role")
+
+	if name == RoleAdmin || name == RoleUser {
+		return 0, ErrBuiltinRole
+	}
+	rows, err := b.Db.Role.DeleteRoleByName(name)
+	if err != nil {
+		return 0, err
+	}
+	b.permissions.Invalidate(name)
+	return rows, nil
+}
+
+func sortedUnique(values []string) []string {
+	unique := utils.UniqueStrings(values)
+	sort.Strings(unique)
+	return unique
+}
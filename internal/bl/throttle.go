@@ -0,0 +1,141 @@
+package bl
+
+import (
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+	"vk-inter-test-go/internal/config"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+)
+
+var ErrLockoutNotFound = errors.New("lockout not found")
+
+// LoginThrottledError is returned while the login or the client address is
+// blocked after failed attempts.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// checkLoginThrottle refuses the attempt before the password is compared when the
+// login or the client address is still blocked.
+func (b *BL) checkLoginThrottle(login string, clientIP string) error {
+	var retryAfter time.Duration
+	for _, key := range loginThrottleKeys(login, clientIP) {
+		attempt, err := b.Db.Login.GetLoginAttempt(key[0], key[1])
+		if err != nil {
+			return err
+		}
+		if attempt.BlockedUntil == nil {
+			continue
+		}
+		if wait := time.Until(*attempt.BlockedUntil); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt and blocks the login or the client
+// address for an exponentially growing delay, up to a lockout.
+func (b *BL) recordLoginFailure(login string, clientIP string) {
+	for _, key := range loginThrottleKeys(login, clientIP) {
+		attempt, err := b.Db.Login.RecordLoginFailure(key[0], key[1], b.options.LoginFailureWindow)
+		if err != nil {
+			b.logger.Error("record login failure", zap.Error(err))
+			continue
+		}
+		maxFailures := b.options.LoginMaxFailures
+		if key[0] == repo.LoginScopeIP {
+			maxFailures = b.options.LoginIPMaxFailures
+		}
+		delay := loginDelay(attempt.Failures, maxFailures, b.options)
+		if delay <= 0 {
+			continue
+		}
+		b.logger.Warn("login blocked", zap.String("scope", key[0]), zap.String("subject", key[1]),
+			zap.Int("failures", attempt.Failures), zap.Duration("delay", delay))
+		if err := b.Db.Login.SetLoginBlockedUntil(key[0], key[1], time.Now().Add(delay)); err != nil {
+			b.logger.Error("block login", zap.Error(err))
+		}
+	}
+}
+
+// resetLoginFailures forgets the failures of a login after a successful attempt.
+// The counter of the client address is kept, so one valid account does not
+// lift the limit for guessing others.
+func (b *BL) resetLoginFailures(login string) {
+	if _, err := b.Db.Login.DeleteLoginAttempt(repo.LoginScopeLogin, login); err != nil {
+		b.logger.Error("reset login failures", zap.Error(err))
+	}
+}
+
+func loginThrottleKeys(login string, clientIP string) [][2]string {
+	keys := [][2]string{{repo.LoginScopeLogin, login}}
+	if len(clientIP) > 0 {
+		keys = append(keys, [2]string{repo.LoginScopeIP, clientIP})
+	}
+	return keys
+}
+
+// loginDelay returns how long to block after the given number of failures:
+// nothing for the first few, then a doubling delay, and the lockout once the
+// threshold is reached. The delay never exceeds the lockout.
+func loginDelay(failures int, maxFailures int, options config.OptionsSrv) time.Duration {
+	if maxFailures > 0 && failures >= maxFailures {
+		return options.LoginLockout
+	}
+	if options.LoginBackoffBase <= 0 || failures < options.LoginBackoffAfter {
+		return 0
+	}
+	delay := options.LoginBackoffBase
+	for i := options.LoginBackoffAfter; i < failures && delay < options.LoginLockout; i++ {
+		delay *= 2
+	}
+	if delay > options.LoginLockout {
+		delay = options.LoginLockout
+	}
+	return delay
+}
+
+// GetLockouts lists the logins and client addresses that are currently blocked.
+func (b *BL) GetLockouts() ([]models.LockoutIo, error) {
+	b.logger.Info("get lockouts")
+
+	attempts, err := b.Db.Login.GetBlockedLoginAttempts()
+	if err != nil {
+		return nil, err
+	}
+	lockouts := make([]models.LockoutIo, 0, len(attempts))
+	for _, attempt := range attempts {
+		lockouts = append(lockouts, models.LockoutIo{
+			Scope:        attempt.Scope,
+			Subject:      attempt.Subject,
+			Failures:     attempt.Failures,
+			BlockedUntil: *attempt.BlockedUntil,
+		})
+	}
+	return lockouts, nil
+}
+
+// Unlock lifts the block of a login or a client address and resets its failures.
+func (b *BL) Unlock(scope string, subject string) error {
+	b.logger.Info("unlock", zap.String("scope", scope), zap.String("subject", subject))
+
+	rows, err := b.Db.Login.DeleteLoginAttempt(scope, subject)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrLockoutNotFound
+	}
+	return nil
+}
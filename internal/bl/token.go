@@ -0,0 +1,121 @@
+package bl
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"time"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/utils"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+)
+
+// issueTokens returns a new access token together with a refresh token.
+// An empty family starts a new session, otherwise the refresh token continues the given one.
+func (b *BL) issueTokens(user repo.User, family string) (models.TokenResponse, error) {
+	role, err := b.roles.Get(user.ID)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	mustEnrollMfa, err := b.mustEnrollMfa(user.ID, role)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	access, err := utils.GenerateToken(b.options.AccessTTL, utils.TokenClaims{
+		Subject:            user.Login,
+		UserID:             user.ID,
+		Role:               role,
+		MustChangePassword: user.MustChangePassword,
+		MustEnrollMfa:      mustEnrollMfa,
+	})
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	if len(family) == 0 {
+		family, err = utils.NewOpaqueToken()
+		if err != nil {
+			return models.TokenResponse{}, err
+		}
+	}
+	refresh, err := utils.NewOpaqueToken()
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	err = b.Db.Refresh.CreateRefreshToken(&repo.RefreshToken{
+		UserID:    user.ID,
+		Family:    family,
+		TokenHash: utils.HashToken(refresh),
+		ExpiresAt: time.Now().UTC().Add(b.options.RefreshTTL),
+	})
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	return models.TokenResponse{
+		Bearer:    access,
+		Refresh:   refresh,
+		ExpiresIn: int64(b.options.AccessTTL.Seconds()),
+	}, nil
+}
+
+// RefreshToken exchanges a refresh token for a new token pair. Every refresh token
+// can be used once: presenting an already rotated token revokes the whole session.
+func (b *BL) RefreshToken(refresh string) (models.TokenResponse, error) {
+	b.logger.Info("refresh token")
+
+	token, err := b.Db.Refresh.GetRefreshTokenByHash(utils.HashToken(refresh))
+	if err != nil {
+		b.logger.Info("err", zap.Error(err))
+		return models.TokenResponse{}, ErrInvalidRefreshToken
+	}
+	if token.RevokedAt != nil {
+		return models.TokenResponse{}, b.revokeReused(token)
+	}
+	if time.Now().UTC().After(token.ExpiresAt) {
+		return models.TokenResponse{}, ErrInvalidRefreshToken
+	}
+
+	rows, err := b.Db.Refresh.RevokeRefreshToken(token.ID)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	if rows == 0 {
+		return models.TokenResponse{}, b.revokeReused(token)
+	}
+
+	user, err := b.Db.User.GetUserById(token.UserID)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	if user.Disabled {
+		return models.TokenResponse{}, ErrInvalidRefreshToken
+	}
+	return b.issueTokens(user, token.Family)
+}
+
+// Logout revokes the session the refresh token belongs to.
+func (b *BL) Logout(refresh string) error {
+	b.logger.Info("logout")
+
+	token, err := b.Db.Refresh.GetRefreshTokenByHash(utils.HashToken(refresh))
+	if err != nil {
+		b.logger.Info("err", zap.Error(err))
+		return ErrInvalidRefreshToken
+	}
+	_, err = b.Db.Refresh.RevokeRefreshTokenFamily(token.Family)
+	return err
+}
+
+func (b *BL) revokeReused(token repo.RefreshToken) error {
+	b.logger.Warn("refresh token reuse", zap.Int("user", token.UserID), zap.String("family", token.Family))
+	_, err := b.Db.Refresh.RevokeRefreshTokenFamily(token.Family)
+	if err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
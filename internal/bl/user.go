@@ -1,61 +1,136 @@
 package bl
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"go.uber.org/zap"
-	"time"
 	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
 	utilsJwt "vk-inter-test-go/internal/utils"
 )
 
-func (b *BL) CreateUser(user repo.User) (string, error) {
-	user.Pass, _ = utilsJwt.HashPassword(user.Pass)
-	err := b.Db.User.CreateUser(user)
+var ErrUserExists = errors.New("user already exists")
+
+func (b *BL) CreateUser(user repo.User) (models.TokenResponse, error) {
+	if err := b.checkPassword(user.Pass, user.Login); err != nil {
+		return models.TokenResponse{}, err
+	}
+	var err error
+	user.Pass, err = b.hasher.Hash(user.Pass)
 	if err != nil {
-		return "", err
+		return models.TokenResponse{}, err
+	}
+	err = b.Db.User.CreateUser(&user)
+	if errors.Is(err, repo.ErrLoginTaken) {
+		return models.TokenResponse{}, ErrUserExists
 	}
-	token, err := utilsJwt.GenerateToken(time.Hour, user.Login)
 	if err != nil {
-		return "", err
+		return models.TokenResponse{}, err
 	}
-	return token, nil
+	return b.issueTokens(user, "")
 }
 
-func (b *BL) AuthUser(user repo.User) (string, error) {
+// AuthUser checks the login and password. An unknown login and a wrong password
+// both give ErrWrongPassword. Failed attempts are counted per login and per client
+// address, see checkLoginThrottle. A hash made with outdated settings is replaced
+// with a fresh one while the password is at hand. Users with a second factor get
+// an mfa token instead of the access token, see CompleteMfaLogin.
+func (b *BL) AuthUser(user repo.User, clientIP string) (models.TokenResponse, error) {
+	if err := b.checkLoginThrottle(user.Login, clientIP); err != nil {
+		return models.TokenResponse{}, err
+	}
 	dbUser, err := b.Db.User.GetUserByLogin(user.Login)
 	if err != nil {
-		return "", err
+		b.logger.Info("err", zap.Error(err))
+		b.recordLoginFailure(user.Login, clientIP)
+		return models.TokenResponse{}, ErrWrongPassword
 	}
-	err = utilsJwt.VerifyPassword(dbUser.Pass, user.Pass)
+	err = b.hasher.Verify(dbUser.Pass, user.Pass)
 	if err != nil {
-		return "", err
+		b.recordLoginFailure(user.Login, clientIP)
+		return models.TokenResponse{}, ErrWrongPassword
+	}
+	if dbUser.Disabled {
+		return models.TokenResponse{}, ErrUserDisabled
 	}
-	token, err := utilsJwt.GenerateToken(time.Hour*5, user.Login)
+	b.resetLoginFailures(user.Login)
+	b.rehashPassword(dbUser, user.Pass)
+	mfa, err := b.Db.Mfa.GetMfa(dbUser.ID)
 	if err != nil {
-		return "", err
+		return models.TokenResponse{}, err
 	}
-	return token, nil
+	if mfa.Enabled {
+		return b.mfaPending(dbUser)
+	}
+	return b.issueTokens(dbUser, "")
 }
 
-func (b *BL) CheckJwt(token string) bool {
-	validateToken, err := utilsJwt.ValidateToken(token)
-
+// Authenticate verifies an access token and returns the principal it was issued to.
+func (b *BL) Authenticate(token string) (Principal, error) {
+	claims, err := utilsJwt.ValidateToken(token)
+	if err != nil {
+		return Principal{}, err
+	}
+	if len(claims.Purpose) > 0 {
+		return Principal{}, fmt.Errorf("not an access token: %s", claims.Purpose)
+	}
+	// the role comes from the cached resolver, which has no role for a disabled
+	// user, so disabling an account revokes its access tokens
+	role, err := b.roles.Get(claims.UserID)
 	if err != nil {
-		return false
+		b.logger.Info("err", zap.Error(err))
+		return Principal{}, ErrUserDisabled
 	}
-	b.logger.Info("check jwt ok", zap.String("user: ", validateToken.(string)))
-	return true
+	b.logger.Info("check jwt ok", zap.String("user: ", claims.Subject))
+	return Principal{
+		UserID:             claims.UserID,
+		Login:              claims.Subject,
+		Role:               role,
+		MustChangePassword: claims.MustChangePassword,
+		MustEnrollMfa:      claims.MustEnrollMfa,
+	}, nil
 }
 
-func (b *BL) CheckRole(name string, role string) bool {
-	user, err := b.Db.User.GetUserByLogin(name)
+// CheckRole reports whether the principal of ctx currently has the role. The role
+// is taken from the cached resolver rather than the token claim, so a role change
+// applies before the token expires. The resolved principal is returned so the
+// caller can keep it in the request context.
+func (b *BL) CheckRole(ctx context.Context, role string) (Principal, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.ApiKeyID != 0 || principal.Guest {
+		return Principal{}, false
+	}
+	current, err := b.roles.Get(principal.UserID)
 	if err != nil {
 		b.logger.Info("err", zap.Error(err))
-		return false
+		return principal, false
 	}
-	roleDb, err := b.Db.Role.GetRoleById(user.RoleID)
+	principal.Role = current
+	return principal, principal.Role == role
+}
+
+// SetUserRole assigns the role to the user and drops the cached role.
+func (b *BL) SetUserRole(userID int, role string) error {
+	b.logger.Info("set user role")
+
+	return b.setUserRole(userID, role, "")
+}
+
+// setUserRole changes the role, it fails with ErrLastAdmin when keep is
+// PermUserManage and the user is the last enabled user holding it.
+func (b *BL) setUserRole(userID int, role string, keep string) error {
+	roleDb, err := b.Db.Role.GetRoleByName(role)
 	if err != nil {
-		b.logger.Info("err", zap.Error(err))
-		return false
+		return err
+	}
+	rows, err := b.Db.User.SetUserRole(userID, roleDb.ID, keep)
+	if err != nil {
+		return lastAdmin(err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user with id %d not found", userID)
 	}
-	return roleDb.Name == role
+	b.roles.Invalidate(userID)
+	return nil
 }
@@ -0,0 +1,157 @@
+package bl
+
+import (
+	"errors"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserDisabled = errors.New("user is disabled")
+	ErrLastAdmin    = errors.New("cannot remove the last admin")
+)
+
+func userIo(user repo.User) models.UserIo {
+	return models.UserIo{
+		ID:        user.ID,
+		Login:     user.Login,
+		Role:      user.Role,
+		Disabled:  user.Disabled,
+		CreatedAt: user.CreatedAt,
+
+		MustChangePassword: user.MustChangePassword,
+		Email:              user.Email,
+		EmailVerified:      user.EmailVerified,
+		DisplayName:        user.DisplayName,
+	}
+}
+
+func (b *BL) GetUsers(search string, page int, limit int) (models.UserPage, error) {
+	b.logger.Info("get users")
+
+	users, total, err := b.Db.User.GetUsers(search, limit, (page-1)*limit)
+	if err != nil {
+		return models.UserPage{}, err
+	}
+	result := models.UserPage{Users: []models.UserIo{}, Total: total, Page: page, Limit: limit}
+	for _, user := range users {
+		result.Users = append(result.Users, userIo(user))
+	}
+	return result, nil
+}
+
+func (b *BL) GetUser(id int) (models.UserIo, error) {
+	b.logger.Info("get user")
+
+	user, err := b.Db.User.GetUserById(id)
+	if err != nil {
+		return models.UserIo{}, ErrUserNotFound
+	}
+	return userIo(user), nil
+}
+
+// CreateUserByAdmin creates a user with the given role, the default role is used when it is empty.
+func (b *BL) CreateUserByAdmin(req models.UserCreateRequest) (models.UserIo, error) {
+	b.logger.Info("create user by admin")
+
+	if err := b.checkPassword(req.Pass, req.Login); err != nil {
+		return models.UserIo{}, err
+	}
+	user := repo.User{Login: req.Login}
+	if len(req.Role) > 0 {
+		role, err := b.Db.Role.GetRoleByName(req.Role)
+		if err != nil {
+			return models.UserIo{}, err
+		}
+		user.RoleID = role.ID
+	}
+	var err error
+	user.Pass, err = b.hasher.Hash(req.Pass)
+	if err != nil {
+		return models.UserIo{}, err
+	}
+	err = b.Db.User.CreateUser(&user)
+	if errors.Is(err, repo.ErrLoginTaken) {
+		return models.UserIo{}, ErrUserExists
+	}
+	if err != nil {
+		return models.UserIo{}, err
+	}
+	return b.GetUser(user.ID)
+}
+
+// UpdateUserByAdmin changes the role of a user, enables or disables the account
+// and sets whether the user has to change the password on the next login.
+// Disabling a user revokes all of their sessions.
+func (b *BL) UpdateUserByAdmin(req models.UserUpdateRequest) (models.UserIo, error) {
+	b.logger.Info("update user by admin")
+
+	user, err := b.Db.User.GetUserById(req.ID)
+	if err != nil {
+		return models.UserIo{}, ErrUserNotFound
+	}
+
+	if req.Role != nil && *req.Role != user.Role {
+		if err := b.setUserRole(user.ID, *req.Role, PermUserManage); err != nil {
+			return models.UserIo{}, err
+		}
+	}
+
+	if req.Disabled != nil && *req.Disabled != user.Disabled {
+		if _, err := b.Db.User.SetUserDisabled(user.ID, *req.Disabled, PermUserManage); err != nil {
+			return models.UserIo{}, lastAdmin(err)
+		}
+		if *req.Disabled {
+			if _, err := b.Db.Refresh.RevokeUserRefreshTokens(user.ID); err != nil {
+				return models.UserIo{}, err
+			}
+		}
+		b.roles.Invalidate(user.ID)
+	}
+
+	if req.MustChangePassword != nil && *req.MustChangePassword != user.MustChangePassword {
+		if _, err := b.Db.User.SetMustChangePassword(user.ID, *req.MustChangePassword); err != nil {
+			return models.UserIo{}, err
+		}
+	}
+
+	return b.GetUser(user.ID)
+}
+
+func (b *BL) DeleteUser(id int) error {
+	b.logger.Info("delete user")
+
+	rows, err := b.Db.User.DeleteUserById(id, PermUserManage)
+	if err != nil {
+		return lastAdmin(err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	b.roles.Invalidate(id)
+	return nil
+}
+
+// lastAdmin maps the refusal of the repo to remove the last enabled user allowed
+// to manage users, which would lock everyone out of administration. The repo
+// checks it in the transaction of the change.
+func lastAdmin(err error) error {
+	if errors.Is(err, repo.ErrLastHolder) {
+		return ErrLastAdmin
+	}
+	return err
+}
+
+func (b *BL) roleHasPermission(role string, permission string) (bool, error) {
+	permissions, err := b.permissions.Get(role)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range permissions {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
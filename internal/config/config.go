@@ -17,6 +17,71 @@ type OptionsSrv struct {
   PgUser string `long:"pguser" description:"the db user" default:"user_postgres" env:"POSTGRES_USER"`
   PgPass string `long:"pgpass" description:"the db pass" default:"pass" env:"POSTGRES_PASSWORD"`
   DbName string `long:"dbname" description:"the db name" default:"test" env:"POSTGRES_DB"`
+
+  JwtAlg       string   `long:"jwt-alg" description:"алгоритм подписи jwt" choice:"HS256" choice:"RS256" choice:"EdDSA" default:"HS256" env:"JWT_ALG"`
+  JwtKeys      []string `long:"jwt-key" description:"ключ подписи jwt в формате kid:secret (можно указать несколько)" env:"JWT_KEYS" env-delim:","`
+  JwtKeyFile   string   `long:"jwt-key-file" description:"файл с ключами подписи jwt, по одному kid:secret на строку" env:"JWT_KEY_FILE"`
+  JwtPemKeys   []string `long:"jwt-pem-key" description:"PEM ключ для RS256/EdDSA в формате kid:path (закрытый ключ подписывает, открытый только проверяет)" env:"JWT_PEM_KEYS" env-delim:","`
+  JwtActiveKid string   `long:"jwt-active-kid" description:"kid ключа для подписи новых токенов (по умолчанию первый ключ)" env:"JWT_ACTIVE_KID"`
+
+  AccessTTL  time.Duration `long:"access-ttl" description:"время жизни access токена" default:"15m" env:"ACCESS_TTL"`
+  RefreshTTL time.Duration `long:"refresh-ttl" description:"время жизни refresh токена" default:"720h" env:"REFRESH_TTL"`
+
+  RoleCacheTTL time.Duration `long:"role-cache-ttl" description:"время кеширования роли пользователя" default:"1m" env:"ROLE_CACHE_TTL"`
+
+  PasswordMinLength  int      `long:"password-min-length" description:"минимальная длина пароля" default:"8" env:"PASSWORD_MIN_LENGTH"`
+  PasswordMinClasses int      `long:"password-min-classes" description:"минимальное число классов символов в пароле (строчные, заглавные, цифры, прочие)" default:"2" env:"PASSWORD_MIN_CLASSES"`
+  PasswordDenylist   []string `long:"password-deny" description:"запрещенный пароль в дополнение к встроенному списку распространенных паролей" env:"PASSWORD_DENYLIST" env-delim:","`
+
+  PasswordHash  string `long:"password-hash" description:"алгоритм хеширования новых паролей" choice:"argon2id" choice:"bcrypt" default:"argon2id" env:"PASSWORD_HASH"`
+  Argon2Memory  uint32 `long:"argon2-memory" description:"память argon2id в КиБ" default:"19456" env:"ARGON2_MEMORY"`
+  Argon2Time    uint32 `long:"argon2-time" description:"число проходов argon2id" default:"2" env:"ARGON2_TIME"`
+  Argon2Threads uint8  `long:"argon2-threads" description:"число потоков argon2id" default:"1" env:"ARGON2_THREADS"`
+  BcryptCost    int    `long:"bcrypt-cost" description:"стоимость bcrypt" default:"10" env:"BCRYPT_COST"`
+
+  LoginBackoffAfter  int           `long:"login-backoff-after" description:"число неудачных входов без задержки" default:"3" env:"LOGIN_BACKOFF_AFTER"`
+  LoginBackoffBase   time.Duration `long:"login-backoff-base" description:"начальная задержка после неудачного входа, удваивается с каждой ошибкой (0 - без задержки)" default:"1s" env:"LOGIN_BACKOFF_BASE"`
+  LoginMaxFailures   int           `long:"login-max-failures" description:"число неудачных входов в логин до блокировки (0 - без блокировки)" default:"10" env:"LOGIN_MAX_FAILURES"`
+  LoginIPMaxFailures int           `long:"login-ip-max-failures" description:"число неудачных входов с одного адреса до блокировки (0 - без блокировки)" default:"50" env:"LOGIN_IP_MAX_FAILURES"`
+  LoginLockout       time.Duration `long:"login-lockout" description:"время блокировки" default:"15m" env:"LOGIN_LOCKOUT"`
+  LoginFailureWindow time.Duration `long:"login-failure-window" description:"через сколько после последней ошибки счетчик неудачных входов сбрасывается" default:"1h" env:"LOGIN_FAILURE_WINDOW"`
+
+  MailSender    string `long:"mail-sender" description:"способ отправки писем: smtp или outbox (запись в файлы)" choice:"smtp" choice:"outbox" default:"outbox" env:"MAIL_SENDER"`
+  MailFrom      string `long:"mail-from" description:"адрес отправителя писем" default:"noreply@localhost" env:"MAIL_FROM"`
+  MailOutboxDir string `long:"mail-outbox-dir" description:"каталог для писем в режиме outbox" default:"outbox" env:"MAIL_OUTBOX_DIR"`
+  SmtpHost      string `long:"smtp-host" description:"хост smtp сервера" default:"localhost" env:"SMTP_HOST"`
+  SmtpPort      string `long:"smtp-port" description:"порт smtp сервера" default:"587" env:"SMTP_PORT"`
+  SmtpUser      string `long:"smtp-user" description:"пользователь smtp" env:"SMTP_USER"`
+  SmtpPass      string `long:"smtp-pass" description:"пароль smtp" env:"SMTP_PASS"`
+
+  EmailVerifyTTL   time.Duration `long:"email-verify-ttl" description:"время жизни токена подтверждения почты" default:"24h" env:"EMAIL_VERIFY_TTL"`
+  PasswordResetTTL time.Duration `long:"password-reset-ttl" description:"время жизни токена сброса пароля" default:"1h" env:"PASSWORD_RESET_TTL"`
+
+  MfaRequired   bool          `long:"mfa-required" description:"обязательная двухфакторная аутентификация для ролей с разрешениями на изменение" env:"MFA_REQUIRED"`
+  MfaIssuer     string        `long:"mfa-issuer" description:"название сервиса в приложении-аутентификаторе" default:"Filmoteka" env:"MFA_ISSUER"`
+  MfaPendingTTL time.Duration `long:"mfa-pending-ttl" description:"время на ввод второго фактора после пароля" default:"5m" env:"MFA_PENDING_TTL"`
+
+  SessionCookies bool     `long:"session-cookies" description:"по запросу с ?session=cookie выдавать токены в HttpOnly cookie с защитой от CSRF" env:"SESSION_COOKIES"`
+  CookieInsecure bool     `long:"cookie-insecure" description:"не ставить cookie флаг Secure (только для разработки без https)" env:"COOKIE_INSECURE"`
+  CookieSameSite string   `long:"cookie-samesite" description:"атрибут SameSite cookie" choice:"strict" choice:"lax" choice:"none" default:"strict" env:"COOKIE_SAMESITE"`
+  CookieDomain   string   `long:"cookie-domain" description:"домен cookie (по умолчанию только текущий хост)" env:"COOKIE_DOMAIN"`
+  CorsOrigins    []string `long:"cors-origin" description:"источник, которому разрешены запросы с cookie (можно указать несколько); без списка разрешен любой источник без cookie" env:"CORS_ORIGINS" env-delim:","`
+
+  GuestAccess     bool          `long:"guest-access" description:"разрешить запросы без токена к спискам фильмов и актеров (только чтение)" env:"GUEST_ACCESS"`
+  GuestRateLimit  int           `long:"guest-rate-limit" description:"число запросов без токена с одного адреса за --guest-rate-window (0 - без ограничения)" default:"60" env:"GUEST_RATE_LIMIT"`
+  GuestRateWindow time.Duration `long:"guest-rate-window" description:"окно ограничения запросов без токена" default:"1m" env:"GUEST_RATE_WINDOW"`
+
+  OidcIssuer       string        `long:"oidc-issuer" description:"адрес OpenID Connect провайдера для входа через внешнюю учетную запись (пусто - вход выключен)" env:"OIDC_ISSUER"`
+  OidcClientID     string        `long:"oidc-client-id" description:"идентификатор клиента у провайдера" env:"OIDC_CLIENT_ID"`
+  OidcClientSecret string        `long:"oidc-client-secret" description:"секрет клиента (для публичного клиента не нужен)" env:"OIDC_CLIENT_SECRET"`
+  OidcRedirectURL  string        `long:"oidc-redirect-url" description:"адрес /api/login/oidc/callback, зарегистрированный у провайдера" env:"OIDC_REDIRECT_URL"`
+  OidcScopes       []string      `long:"oidc-scope" description:"запрашиваемые scope" default:"openid" default:"profile" default:"email" env:"OIDC_SCOPES" env-delim:","`
+  OidcLoginClaim   string        `long:"oidc-login-claim" description:"claim с логином нового пользователя" default:"preferred_username" env:"OIDC_LOGIN_CLAIM"`
+  OidcRoleClaim    string        `long:"oidc-role-claim" description:"claim со списком групп для выбора роли" default:"groups" env:"OIDC_ROLE_CLAIM"`
+  OidcRoleMap      []string      `long:"oidc-role" description:"соответствие группы провайдера роли в виде группа:роль (можно указать несколько, первое совпадение побеждает)" env:"OIDC_ROLES" env-delim:","`
+  OidcDefaultRole  string        `long:"oidc-default-role" description:"роль пользователя, группы которого не указаны в --oidc-role" default:"user" env:"OIDC_DEFAULT_ROLE"`
+  OidcStateTTL     time.Duration `long:"oidc-state-ttl" description:"время на вход у провайдера" default:"10m" env:"OIDC_STATE_TTL"`
+  OidcLinkEmail    bool          `long:"oidc-link-email" description:"при первом входе связывать учетную запись провайдера с пользователем с той же подтвержденной почтой" env:"OIDC_LINK_EMAIL"`
 }
 
 type ConfSrv struct {
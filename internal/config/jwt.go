@@ -0,0 +1,122 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const minJwtKeyLen = 32
+
+// LoadJwtKeys собирает ключи подписи jwt для алгоритма JwtAlg.
+// Для HS256 это секреты из флагов (переменной окружения) и файла ключей,
+// для RS256/EdDSA - содержимое PEM файлов.
+// Возвращает kid активного ключа и все ключи по kid: остальные ключи считаются
+// выведенными из оборота и используются только для проверки ранее выданных токенов.
+func (o OptionsSrv) LoadJwtKeys() (string, map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	var order []string
+
+	add := func(kid string, key []byte) error {
+		if len(kid) == 0 {
+			return fmt.Errorf("jwt key must have a kid")
+		}
+		if _, exist := keys[kid]; exist {
+			return fmt.Errorf("duplicate jwt key id %q", kid)
+		}
+		keys[kid] = key
+		order = append(order, kid)
+		return nil
+	}
+
+	var err error
+	if len(o.JwtAlg) == 0 || o.JwtAlg == "HS256" {
+		err = o.loadJwtSecrets(add)
+	} else {
+		err = o.loadJwtPemKeys(add)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(order) == 0 {
+		return "", nil, errors.New("no jwt keys configured: set --jwt-key, JWT_KEYS, JWT_KEY_FILE or JWT_PEM_KEYS")
+	}
+
+	active := o.JwtActiveKid
+	if len(active) == 0 {
+		active = order[0]
+	}
+	if _, ok := keys[active]; !ok {
+		return "", nil, fmt.Errorf("active jwt key %q not found", active)
+	}
+	return active, keys, nil
+}
+
+func (o OptionsSrv) loadJwtSecrets(add func(kid string, key []byte) error) error {
+	addSecret := func(entry string) error {
+		kid, secret, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return fmt.Errorf("jwt key must be in form kid:secret")
+		}
+		if len(secret) < minJwtKeyLen {
+			return fmt.Errorf("jwt key %q is shorter than %d bytes", kid, minJwtKeyLen)
+		}
+		return add(kid, []byte(secret))
+	}
+
+	for _, entry := range o.JwtKeys {
+		if len(strings.TrimSpace(entry)) == 0 {
+			continue
+		}
+		if err := addSecret(entry); err != nil {
+			return err
+		}
+	}
+
+	if len(o.JwtKeyFile) == 0 {
+		return nil
+	}
+	file, err := os.Open(o.JwtKeyFile)
+	if err != nil {
+		return fmt.Errorf("open jwt key file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := addSecret(line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read jwt key file: %w", err)
+	}
+	return nil
+}
+
+func (o OptionsSrv) loadJwtPemKeys(add func(kid string, key []byte) error) error {
+	for _, entry := range o.JwtPemKeys {
+		if len(strings.TrimSpace(entry)) == 0 {
+			continue
+		}
+		kid, path, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return fmt.Errorf("jwt pem key must be in form kid:path")
+		}
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read jwt pem key %q: %w", kid, err)
+		}
+		if err := add(kid, pem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE refresh_tokens
+(
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    family VARCHAR(64) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family);
+
+-- +goose Down
+DROP TABLE refresh_tokens;
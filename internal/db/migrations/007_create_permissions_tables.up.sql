@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE permissions
+(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE role_permissions
+(
+    role_id INT,
+    permission_id INT,
+    PRIMARY KEY (role_id, permission_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+INSERT INTO permissions (name)
+VALUES ('movie:write'),
+       ('movie:delete'),
+       ('actor:write'),
+       ('actor:delete'),
+       ('user:manage'),
+       ('role:manage');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p WHERE r.name = 'admin';
+
+-- +goose Down
+DROP TABLE role_permissions;
+DROP TABLE permissions;
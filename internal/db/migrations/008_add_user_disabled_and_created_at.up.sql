@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT now();
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN disabled,
+    DROP COLUMN created_at;
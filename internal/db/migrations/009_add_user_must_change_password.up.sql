@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE users SET must_change_password = true WHERE login = 'admin';
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN must_change_password;
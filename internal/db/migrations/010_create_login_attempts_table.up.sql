@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE login_attempts (
+    scope VARCHAR(16) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT now(),
+    blocked_until TIMESTAMP,
+    PRIMARY KEY (scope, subject)
+);
+
+CREATE INDEX login_attempts_blocked_until_idx ON login_attempts (blocked_until);
+
+-- +goose Down
+DROP TABLE login_attempts;
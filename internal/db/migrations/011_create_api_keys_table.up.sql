@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE api_keys
+(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE TABLE api_key_permissions
+(
+    api_key_id INT,
+    permission_id INT,
+    PRIMARY KEY (api_key_id, permission_id),
+    FOREIGN KEY (api_key_id) REFERENCES api_keys(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+INSERT INTO permissions (name)
+VALUES ('apikey:manage');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p WHERE r.name = 'admin' AND p.name = 'apikey:manage';
+
+-- +goose Down
+DROP TABLE api_key_permissions;
+DROP TABLE api_keys;
+DELETE FROM permissions WHERE name = 'apikey:manage';
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN email VARCHAR(255),
+    ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT false;
+
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email)) WHERE email_verified;
+
+CREATE TABLE user_tokens
+(
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(32) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    email VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX user_tokens_user_id_idx ON user_tokens (user_id, purpose);
+
+-- +goose Down
+DROP TABLE user_tokens;
+DROP INDEX users_email_idx;
+ALTER TABLE users
+    DROP COLUMN email,
+    DROP COLUMN email_verified;
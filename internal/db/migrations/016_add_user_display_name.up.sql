@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN display_name VARCHAR(100);
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN display_name;
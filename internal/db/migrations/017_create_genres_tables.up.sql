@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE genres
+(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE CHECK (char_length(name) >= 1)
+);
+
+CREATE TABLE movies_genres
+(
+    movie_id INT,
+    genre_id INT,
+    PRIMARY KEY (movie_id, genre_id),
+    FOREIGN KEY (movie_id) REFERENCES movies(id) ON DELETE CASCADE,
+    FOREIGN KEY (genre_id) REFERENCES genres(id) ON DELETE CASCADE
+);
+
+CREATE INDEX movies_genres_genre_idx ON movies_genres (genre_id);
+
+INSERT INTO permissions (name)
+VALUES ('genre:manage');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p WHERE r.name = 'admin' AND p.name = 'genre:manage';
+
+-- +goose Down
+DROP TABLE movies_genres;
+DROP TABLE genres;
+DELETE FROM permissions WHERE name = 'genre:manage';
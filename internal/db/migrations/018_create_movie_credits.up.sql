@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE actors RENAME TO persons;
+
+ALTER TABLE movies_actors RENAME TO movie_credits;
+ALTER TABLE movie_credits RENAME COLUMN actor_id TO person_id;
+ALTER TABLE movie_credits
+    ADD COLUMN credit_type VARCHAR(16) NOT NULL DEFAULT 'actor'
+        CHECK (credit_type IN ('actor', 'director', 'writer', 'producer', 'composer'));
+ALTER TABLE movie_credits DROP CONSTRAINT movies_actors_pkey;
+ALTER TABLE movie_credits ADD PRIMARY KEY (movie_id, person_id, credit_type);
+
+CREATE INDEX movie_credits_person_idx ON movie_credits (person_id, credit_type);
+
+-- +goose Down
+DELETE FROM movie_credits WHERE credit_type <> 'actor';
+DROP INDEX movie_credits_person_idx;
+ALTER TABLE movie_credits DROP CONSTRAINT movie_credits_pkey;
+ALTER TABLE movie_credits DROP COLUMN credit_type;
+ALTER TABLE movie_credits RENAME COLUMN person_id TO actor_id;
+ALTER TABLE movie_credits RENAME TO movies_actors;
+ALTER TABLE movies_actors ADD PRIMARY KEY (movie_id, actor_id);
+
+ALTER TABLE persons RENAME TO actors;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE movie_credits
+    ADD COLUMN character_name VARCHAR(100),
+    ADD COLUMN billing_order INT CHECK (billing_order > 0),
+    ADD COLUMN cameo BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE movie_credits
+    DROP COLUMN character_name,
+    DROP COLUMN billing_order,
+    DROP COLUMN cameo;
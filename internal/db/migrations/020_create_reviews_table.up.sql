@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE reviews
+(
+    id SERIAL PRIMARY KEY,
+    movie_id INT NOT NULL,
+    user_id INT NOT NULL,
+    score INT NOT NULL CHECK (score BETWEEN 1 AND 10),
+    review VARCHAR(2000) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (movie_id, user_id),
+    FOREIGN KEY (movie_id) REFERENCES movies(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX reviews_user_idx ON reviews (user_id);
+
+-- +goose Down
+DROP TABLE reviews;
@@ -14,6 +14,15 @@ type DBRepo struct {
 	Actor      repo.ActorRepository
 	Movie      repo.MovieRepository
 	MovieActor repo.MovieActorRepository
+	Genre      repo.GenreRepository
+	Review     repo.ReviewRepository
+	Refresh    repo.RefreshTokenRepository
+	Login      repo.LoginAttemptRepository
+	ApiKey     repo.ApiKeyRepository
+	UserToken  repo.UserTokenRepository
+	Mfa        repo.MfaRepository
+	Audit      repo.AuditRepository
+	Oidc       repo.OidcRepository
 }
 
 func NewDBRepo(conf *config.ConfSrv) *DBRepo {
@@ -26,6 +35,15 @@ func NewDBRepo(conf *config.ConfSrv) *DBRepo {
 		Role:       repo.NewRoleRepository(db, conf.Logger.Named("RepoRole")),
 		Movie:      repo.NewMovieRepository(db, conf.Logger.Named("RepoMovie")),
 		MovieActor: repo.NewMovieActorRepository(db, conf.Logger.Named("RepoMovieActor")),
+		Genre:      repo.NewGenreRepository(db, conf.Logger.Named("RepoGenre")),
+		Review:     repo.NewReviewRepository(db, conf.Logger.Named("RepoReview")),
+		Refresh:    repo.NewRefreshTokenRepository(db, conf.Logger.Named("RepoRefreshToken")),
+		Login:      repo.NewLoginAttemptRepository(db, conf.Logger.Named("RepoLoginAttempt")),
+		ApiKey:     repo.NewApiKeyRepository(db, conf.Logger.Named("RepoApiKey")),
+		UserToken:  repo.NewUserTokenRepository(db, conf.Logger.Named("RepoUserToken")),
+		Mfa:        repo.NewMfaRepository(db, conf.Logger.Named("RepoMfa")),
+		Audit:      repo.NewAuditRepository(db, conf.Logger.Named("RepoAudit")),
+		Oidc:       repo.NewOidcRepository(db, conf.Logger.Named("RepoOidc")),
 	}
 }
 
@@ -17,12 +17,12 @@ func NewActorRepository(db *pgxpool.Pool, logger *zap.Logger) *ActorRepositoryIm
 	return &ActorRepositoryImpl{db: db, logger: logger}
 }
 
+// Actor is a person that can be credited on movies, as cast or as crew.
 type Actor struct {
-	ID            int       `db:"id" json:"ID"`
-	Name          string    `db:"name" json:"name,omitempty"`
-	Gender        string    `db:"gender" json:"gender,omitempty"`
-	BirthDateJson string    `db:"-" json:"birthDate,omitempty"`
-	BirthDate     time.Time `db:"birth-date" json:"-"`
+	ID        int       `db:"id"`
+	Name      string    `db:"name"`
+	Gender    string    `db:"gender"`
+	BirthDate time.Time `db:"birth_date"`
 }
 
 type ActorRepository interface {
@@ -36,7 +36,7 @@ type ActorRepository interface {
 }
 
 func (a ActorRepositoryImpl) CreateActor(actor *Actor) error {
-	sql := "INSERT INTO actors (name, gender, birth_date) VALUES ($1, $2, $3) RETURNING id"
+	sql := "INSERT INTO persons (name, gender, birth_date) VALUES ($1, $2, $3) RETURNING id"
 	err := a.db.QueryRow(context.Background(), sql, actor.Name, actor.Gender, actor.BirthDate).Scan(&actor.ID)
 	if err != nil {
 		return err
@@ -44,8 +44,16 @@ func (a ActorRepositoryImpl) CreateActor(actor *Actor) error {
 	return nil
 }
 
+// isActor matches the persons the actor methods work on: persons in a cast and
+// persons without any credit, who can only have been created as actors.
+// Persons credited only as crew are not actors.
+const isActor = `(EXISTS (SELECT 1 FROM movie_credits c WHERE c.person_id = persons.id AND c.credit_type = 'actor')
+	OR NOT EXISTS (SELECT 1 FROM movie_credits c WHERE c.person_id = persons.id))`
+
+// DeleteActorByName deletes the actor with all the credits, the crew credits
+// of the actor are removed as well. Persons credited only as crew are kept.
 func (a ActorRepositoryImpl) DeleteActorByName(name string) (int64, error) {
-	sql := "DELETE FROM actors WHERE name = $1"
+	sql := "DELETE FROM persons WHERE name = $1 AND " + isActor
 	res, err := a.db.Exec(context.Background(), sql, name)
 
 	if err != nil {
@@ -55,7 +63,7 @@ func (a ActorRepositoryImpl) DeleteActorByName(name string) (int64, error) {
 }
 
 func (a ActorRepositoryImpl) UpdateActor(actor Actor) (int64, error) {
-	sql := "UPDATE actors SET name = $2, gender = $3, birth_date = $4 WHERE id = $1"
+	sql := "UPDATE persons SET name = $2, gender = $3, birth_date = $4 WHERE id = $1"
 	res, err := a.db.Exec(context.Background(), sql, actor.ID, actor.Name, actor.Gender, actor.BirthDate)
 	if err != nil {
 		return 0, err
@@ -66,30 +74,30 @@ func (a ActorRepositoryImpl) UpdateActor(actor Actor) (int64, error) {
 func (a ActorRepositoryImpl) GetActorById(id int) (Actor, error) {
 	var actor Actor
 
-	sql := "SELECT id, name, gender, birth_date FROM actors WHERE id = $1"
+	sql := "SELECT id, name, gender, birth_date FROM persons WHERE id = $1"
 	err := a.db.QueryRow(context.Background(), sql, id).Scan(&actor.ID, &actor.Name, &actor.Gender, &actor.BirthDate)
 	if err != nil {
 		return Actor{}, err
 	}
-	actor.BirthDateJson = actor.BirthDate.Format("2006-01-02")
 	return actor, nil
 }
 
 func (a ActorRepositoryImpl) GetActorByName(name string) (Actor, error) {
 	var actor Actor
 
-	sql := "SELECT id, name, gender, birth_date FROM actors WHERE name = $1"
+	sql := "SELECT id, name, gender, birth_date FROM persons WHERE name = $1"
 	err := a.db.QueryRow(context.Background(), sql, name).Scan(&actor.ID, &actor.Name, &actor.Gender, &actor.BirthDate)
 	if err != nil {
 		return Actor{}, err
 	}
-	actor.BirthDateJson = actor.BirthDate.Format("2006-01-02")
 	return actor, nil
 }
 
+// GetAllActorsLikeName returns the actors whose name contains name, persons
+// credited only as crew are not listed.
 func (a ActorRepositoryImpl) GetAllActorsLikeName(name string, orderBy string) ([]Actor, error) {
 	var actors []Actor
-	sql := "SELECT id, name, gender, birth_date FROM actors WHERE name LIKE '%' || $1 || '%' ORDER BY "
+	sql := "SELECT id, name, gender, birth_date FROM persons WHERE name LIKE '%' || $1 || '%' AND " + isActor + " ORDER BY "
 	switch orderBy {
 	case "name":
 		sql += "name"
@@ -109,7 +117,6 @@ func (a ActorRepositoryImpl) GetAllActorsLikeName(name string, orderBy string) (
 		if err := rows.Scan(&actor.ID, &actor.Name, &actor.Gender, &actor.BirthDate); err != nil {
 			return nil, err
 		}
-		actor.BirthDateJson = actor.BirthDate.Format("2006-01-02")
 		actors = append(actors, actor)
 	}
 
@@ -123,7 +130,7 @@ func (a ActorRepositoryImpl) GetAllActorsLikeName(name string, orderBy string) (
 func (a ActorRepositoryImpl) GetActorMapByIDs(actorIDs []int) (map[int][]Actor, error) {
 	actorMap := make(map[int][]Actor)
 
-	sql := "SELECT id, name, gender, birth_date FROM actors WHERE id = ANY($1)"
+	sql := "SELECT id, name, gender, birth_date FROM persons WHERE id = ANY($1)"
 
 	rows, err := a.db.Query(context.Background(), sql, actorIDs)
 	if err != nil {
@@ -137,7 +144,6 @@ func (a ActorRepositoryImpl) GetActorMapByIDs(actorIDs []int) (map[int][]Actor,
 		if err != nil {
 			return nil, err
 		}
-		actor.BirthDateJson = actor.BirthDate.Format("2006-01-02")
 		actorMap[actor.ID] = append(actorMap[actor.ID], actor)
 	}
 
@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+type ApiKeyRepositoryImpl struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewApiKeyRepository(db *pgxpool.Pool, logger *zap.Logger) *ApiKeyRepositoryImpl {
+	logger.Info("create")
+	return &ApiKeyRepositoryImpl{db: db, logger: logger}
+}
+
+type ApiKey struct {
+	ID          int        `db:"id"`
+	Name        string     `db:"name"`
+	Prefix      string     `db:"prefix"`
+	KeyHash     string     `db:"key_hash"`
+	CreatedBy   *int       `db:"created_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+	LastUsedAt  *time.Time `db:"last_used_at"`
+	RevokedAt   *time.Time `db:"revoked_at"`
+	Permissions []string
+}
+
+type ApiKeyRepository interface {
+	CreateApiKey(key *ApiKey) error
+	GetApiKeyByHash(hash string) (ApiKey, error)
+	GetApiKeys() ([]ApiKey, error)
+	TouchApiKey(id int) error
+	RevokeApiKey(id int) (int64, error)
+}
+
+const apiKeySelect = `SELECT k.id, k.name, k.prefix, k.key_hash, k.created_by, k.created_at, k.expires_at, k.last_used_at, k.revoked_at,
+		COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+	FROM api_keys k
+	LEFT JOIN api_key_permissions kp ON kp.api_key_id = k.id
+	LEFT JOIN permissions p ON p.id = kp.permission_id`
+
+func scanApiKey(row pgx.Row) (ApiKey, error) {
+	var key ApiKey
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &key.CreatedBy, &key.CreatedAt,
+		&key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.Permissions)
+	return key, err
+}
+
+// CreateApiKey stores the key together with its permissions in one transaction.
+func (a ApiKeyRepositoryImpl) CreateApiKey(key *ApiKey) error {
+	ctx := context.Background()
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := "INSERT INTO api_keys (name, prefix, key_hash, created_by, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at"
+	err = tx.QueryRow(ctx, query, key.Name, key.Prefix, key.KeyHash, key.CreatedBy, key.ExpiresAt).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	query = "INSERT INTO api_key_permissions (api_key_id, permission_id) SELECT $1, id FROM permissions WHERE name = ANY($2)"
+	res, err := tx.Exec(ctx, query, key.ID, key.Permissions)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() != int64(len(key.Permissions)) {
+		return fmt.Errorf("unknown permission in %v", key.Permissions)
+	}
+	return tx.Commit(ctx)
+}
+
+func (a ApiKeyRepositoryImpl) GetApiKeyByHash(hash string) (ApiKey, error) {
+	query := apiKeySelect + " WHERE k.key_hash = $1 GROUP BY k.id"
+	key, err := scanApiKey(a.db.QueryRow(context.Background(), query, hash))
+	if err != nil {
+		return ApiKey{}, err
+	}
+	return key, nil
+}
+
+func (a ApiKeyRepositoryImpl) GetApiKeys() ([]ApiKey, error) {
+	query := apiKeySelect + " GROUP BY k.id ORDER BY k.id"
+	rows, err := a.db.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []ApiKey
+	for rows.Next() {
+		key, err := scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// TouchApiKey records the use of the key. The timestamp is written at most once
+// a minute to keep busy keys from updating the row on every request.
+func (a ApiKeyRepositoryImpl) TouchApiKey(id int) error {
+	query := "UPDATE api_keys SET last_used_at = now() WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')"
+	_, err := a.db.Exec(context.Background(), query, id)
+	return err
+}
+
+func (a ApiKeyRepositoryImpl) RevokeApiKey(id int) (int64, error) {
+	query := "UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL"
+	res, err := a.db.Exec(context.Background(), query, id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
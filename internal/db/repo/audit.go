@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+type AuditRepositoryImpl struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewAuditRepository(db *pgxpool.Pool, logger *zap.Logger) *AuditRepositoryImpl {
+	logger.Info("create")
+	return &AuditRepositoryImpl{db: db, logger: logger}
+}
+
+// AuditEvent is a recorded change of a catalog entity. Before and After hold the
+// JSON state of the entity, Before is empty for creations and After for deletions.
+// The table is append-only, events are never changed or removed.
+// ActorUserID is nil for changes made with an API key and once the user is deleted.
+type AuditEvent struct {
+	ID          int64     `db:"id"`
+	ActorLogin  string    `db:"actor_login"`
+	ActorUserID *int      `db:"actor_user_id"`
+	Action      string    `db:"action"`
+	EntityType  string    `db:"entity_type"`
+	EntityID    int       `db:"entity_id"`
+	Before      []byte    `db:"before"`
+	After       []byte    `db:"after"`
+	RequestID   string    `db:"request_id"`
+	IP          string    `db:"ip"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// AuditFilter selects audit events, zero fields match everything.
+// From is inclusive and To is exclusive.
+// Logins can be reused after an account is deleted, ActorUserID selects the
+// events of one user.
+type AuditFilter struct {
+	EntityType  string
+	EntityID    int
+	ActorLogin  string
+	ActorUserID int
+	From        *time.Time
+	To          *time.Time
+}
+
+type AuditRepository interface {
+	CreateAuditEvent(event *AuditEvent) error
+	GetAuditEvents(filter AuditFilter, limit int, offset int) ([]AuditEvent, int, error)
+}
+
+func scanAuditEvent(row pgx.Row, extra ...interface{}) (AuditEvent, error) {
+	var event AuditEvent
+	dest := append([]interface{}{&event.ID, &event.ActorLogin, &event.ActorUserID, &event.Action, &event.EntityType, &event.EntityID,
+		&event.Before, &event.After, &event.RequestID, &event.IP, &event.CreatedAt}, extra...)
+	err := row.Scan(dest...)
+	return event, err
+}
+
+func (a AuditRepositoryImpl) CreateAuditEvent(event *AuditEvent) error {
+	sql := `INSERT INTO audit_events (actor_login, actor_user_id, action, entity_type, entity_id, before, after, request_id, ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at`
+	return a.db.QueryRow(context.Background(), sql, event.ActorLogin, event.ActorUserID, event.Action, event.EntityType, event.EntityID,
+		event.Before, event.After, event.RequestID, event.IP).Scan(&event.ID, &event.CreatedAt)
+}
+
+// GetAuditEvents returns a page of matching events, newest first, and the total number of matches.
+func (a AuditRepositoryImpl) GetAuditEvents(filter AuditFilter, limit int, offset int) ([]AuditEvent, int, error) {
+	sql := `SELECT id, actor_login, actor_user_id, action, entity_type, entity_id, before, after, request_id, ip, created_at,
+			count(*) OVER()
+		FROM audit_events
+		WHERE ($1 = '' OR entity_type = $1) AND ($2 = 0 OR entity_id = $2) AND ($3 = '' OR actor_login = $3)
+			AND ($4 = 0 OR actor_user_id = $4)
+			AND ($5::timestamptz IS NULL OR created_at >= $5) AND ($6::timestamptz IS NULL OR created_at < $6)
+		ORDER BY id DESC LIMIT $7 OFFSET $8`
+	rows, err := a.db.Query(context.Background(), sql, filter.EntityType, filter.EntityID, filter.ActorLogin,
+		filter.ActorUserID, filter.From, filter.To, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []AuditEvent
+	var total int
+	for rows.Next() {
+		event, err := scanAuditEvent(rows, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+	return events, total, rows.Err()
+}
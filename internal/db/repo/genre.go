@@ -0,0 +1,120 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrGenreTaken is returned by CreateGenre when a genre with the name exists.
+	ErrGenreTaken = errors.New("genre exists")
+	// ErrUnknownGenre is returned by SetMovieGenres when a name is not a genre.
+	ErrUnknownGenre = errors.New("unknown genre")
+)
+
+type GenreRepositoryImpl struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewGenreRepository(db *pgxpool.Pool, logger *zap.Logger) *GenreRepositoryImpl {
+	logger.Info("create")
+	return &GenreRepositoryImpl{db: db, logger: logger}
+}
+
+type Genre struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+}
+
+type GenreRepository interface {
+	CreateGenre(genre *Genre) error
+	GetGenres() ([]Genre, error)
+	SetMovieGenres(movieID int, names []string) error
+	GetGenresByMovieIDs(movieIDs []int) (map[int][]Genre, error)
+}
+
+func (g GenreRepositoryImpl) CreateGenre(genre *Genre) error {
+	sql := "INSERT INTO genres (name) VALUES ($1) RETURNING id"
+	err := g.db.QueryRow(context.Background(), sql, genre.Name).Scan(&genre.ID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrGenreTaken
+	}
+	return err
+}
+
+func (g GenreRepositoryImpl) GetGenres() ([]Genre, error) {
+	rows, err := g.db.Query(context.Background(), "SELECT id, name FROM genres ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var genres []Genre
+	for rows.Next() {
+		var genre Genre
+		if err := rows.Scan(&genre.ID, &genre.Name); err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return genres, nil
+}
+
+// SetMovieGenres replaces the genres of the movie in one transaction.
+func (g GenreRepositoryImpl) SetMovieGenres(movieID int, names []string) error {
+	ctx := context.Background()
+	tx, err := g.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "DELETE FROM movies_genres WHERE movie_id = $1", movieID)
+	if err != nil {
+		return err
+	}
+
+	sql := "INSERT INTO movies_genres (movie_id, genre_id) SELECT $1, id FROM genres WHERE name = ANY($2)"
+	res, err := tx.Exec(ctx, sql, movieID, names)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() != int64(len(names)) {
+		return ErrUnknownGenre
+	}
+	return tx.Commit(ctx)
+}
+
+func (g GenreRepositoryImpl) GetGenresByMovieIDs(movieIDs []int) (map[int][]Genre, error) {
+	genres := make(map[int][]Genre)
+
+	sql := `SELECT mg.movie_id, g.id, g.name FROM movies_genres mg
+		JOIN genres g ON g.id = mg.genre_id
+		WHERE mg.movie_id = ANY($1) ORDER BY g.name`
+	rows, err := g.db.Query(context.Background(), sql, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movieID int
+		var genre Genre
+		if err := rows.Scan(&movieID, &genre.ID, &genre.Name); err != nil {
+			return nil, err
+		}
+		genres[movieID] = append(genres[movieID], genre)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return genres, nil
+}
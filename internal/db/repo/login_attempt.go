@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	LoginScopeLogin = "login"
+	LoginScopeIP    = "ip"
+)
+
+type LoginAttemptRepositoryImpl struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewLoginAttemptRepository(db *pgxpool.Pool, logger *zap.Logger) *LoginAttemptRepositoryImpl {
+	logger.Info("create")
+	return &LoginAttemptRepositoryImpl{db: db, logger: logger}
+}
+
+// LoginAttempt counts the failed logins of a login name or of a client address.
+type LoginAttempt struct {
+	Scope         string     `db:"scope"`
+	Subject       string     `db:"subject"`
+	Failures      int        `db:"failures"`
+	LastFailureAt time.Time  `db:"last_failure_at"`
+	BlockedUntil  *time.Time `db:"blocked_until"`
+}
+
+type LoginAttemptRepository interface {
+	GetLoginAttempt(scope string, subject string) (LoginAttempt, error)
+	RecordLoginFailure(scope string, subject string, window time.Duration) (LoginAttempt, error)
+	SetLoginBlockedUntil(scope string, subject string, until time.Time) error
+	DeleteLoginAttempt(scope string, subject string) (int64, error)
+	GetBlockedLoginAttempts() ([]LoginAttempt, error)
+}
+
+const loginAttemptColumns = "scope, subject, failures, last_failure_at, blocked_until"
+
+func scanLoginAttempt(row pgx.Row) (LoginAttempt, error) {
+	var attempt LoginAttempt
+	err := row.Scan(&attempt.Scope, &attempt.Subject, &attempt.Failures, &attempt.LastFailureAt, &attempt.BlockedUntil)
+	return attempt, err
+}
+
+// GetLoginAttempt returns an empty attempt when nothing has failed yet.
+func (l LoginAttemptRepositoryImpl) GetLoginAttempt(scope string, subject string) (LoginAttempt, error) {
+	sql := "SELECT " + loginAttemptColumns + " FROM login_attempts WHERE scope = $1 AND subject = $2"
+	attempt, err := scanLoginAttempt(l.db.QueryRow(context.Background(), sql, scope, subject))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return LoginAttempt{Scope: scope, Subject: subject}, nil
+	}
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+	return attempt, nil
+}
+
+// RecordLoginFailure increments the failure counter. The counter starts over when
+// the previous failure is older than the window.
+func (l LoginAttemptRepositoryImpl) RecordLoginFailure(scope string, subject string, window time.Duration) (LoginAttempt, error) {
+	sql := `INSERT INTO login_attempts (scope, subject, failures) VALUES ($1, $2, 1)
+			ON CONFLICT (scope, subject) DO UPDATE SET
+				failures = CASE WHEN login_attempts.last_failure_at < now() - $3 * interval '1 second'
+					THEN 1 ELSE login_attempts.failures + 1 END,
+				last_failure_at = now()
+			RETURNING ` + loginAttemptColumns
+	attempt, err := scanLoginAttempt(l.db.QueryRow(context.Background(), sql, scope, subject, window.Seconds()))
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+	return attempt, nil
+}
+
+func (l LoginAttemptRepositoryImpl) SetLoginBlockedUntil(scope string, subject string, until time.Time) error {
+	sql := "UPDATE login_attempts SET blocked_until = $3 WHERE scope = $1 AND subject = $2"
+	_, err := l.db.Exec(context.Background(), sql, scope, subject, until)
+	return err
+}
+
+func (l LoginAttemptRepositoryImpl) DeleteLoginAttempt(scope string, subject string) (int64, error) {
+	sql := "DELETE FROM login_attempts WHERE scope = $1 AND subject = $2"
+	res, err := l.db.Exec(context.Background(), sql, scope, subject)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+func (l LoginAttemptRepositoryImpl) GetBlockedLoginAttempts() ([]LoginAttempt, error) {
+	sql := "SELECT " + loginAttemptColumns + " FROM login_attempts WHERE blocked_until > now() ORDER BY blocked_until DESC"
+	rows, err := l.db.Query(context.Background(), sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []LoginAttempt
+	for rows.Next() {
+		attempt, err := scanLoginAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
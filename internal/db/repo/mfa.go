@@ -0,0 +1,121 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type MfaRepositoryImpl struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewMfaRepository(db *pgxpool.Pool, logger *zap.Logger) *MfaRepositoryImpl {
+	logger.Info("create")
+	return &MfaRepositoryImpl{db: db, logger: logger}
+}
+
+// Mfa is the TOTP enrollment of a user. The secret is stored as soon as the
+// enrollment starts, Enabled is set once the user has confirmed a code.
+// LastStep is the last accepted time step, a code cannot be used twice.
+type Mfa struct {
+	UserID   int    `db:"user_id"`
+	Secret   string `db:"secret"`
+	Enabled  bool   `db:"enabled"`
+	LastStep int64  `db:"last_step"`
+}
+
+type MfaRepository interface {
+	GetMfa(userID int) (Mfa, error)
+	SetMfaSecret(userID int, secret string) error
+	EnableMfa(userID int, recoveryCodeHashes []string) error
+	DeleteMfa(userID int) (int64, error)
+	UseMfaStep(userID int, step int64) (int64, error)
+	UseRecoveryCode(userID int, hash string) (int64, error)
+}
+
+// GetMfa returns an empty enrollment when the user has not started one.
+func (m MfaRepositoryImpl) GetMfa(userID int) (Mfa, error) {
+	sql := "SELECT user_id, secret, enabled, last_step FROM user_mfa WHERE user_id = $1"
+	var mfa Mfa
+	err := m.db.QueryRow(context.Background(), sql, userID).Scan(&mfa.UserID, &mfa.Secret, &mfa.Enabled, &mfa.LastStep)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Mfa{UserID: userID}, nil
+	}
+	if err != nil {
+		return Mfa{}, err
+	}
+	return mfa, nil
+}
+
+// SetMfaSecret starts a new enrollment, it never replaces an enabled one.
+func (m MfaRepositoryImpl) SetMfaSecret(userID int, secret string) error {
+	sql := `INSERT INTO user_mfa (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = $2, last_step = 0, created_at = now() WHERE NOT user_mfa.enabled`
+	_, err := m.db.Exec(context.Background(), sql, userID, secret)
+	return err
+}
+
+// EnableMfa completes the enrollment and replaces the recovery codes.
+func (m MfaRepositoryImpl) EnableMfa(userID int, recoveryCodeHashes []string) error {
+	ctx := context.Background()
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "UPDATE user_mfa SET enabled = true WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	sql := "INSERT INTO mfa_recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])"
+	if _, err := tx.Exec(ctx, sql, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (m MfaRepositoryImpl) DeleteMfa(userID int) (int64, error) {
+	ctx := context.Background()
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(ctx, "DELETE FROM user_mfa WHERE user_id = $1", userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), tx.Commit(ctx)
+}
+
+// UseMfaStep records the time step of an accepted code. Zero affected rows
+// means the step, or a later one, has already been used.
+func (m MfaRepositoryImpl) UseMfaStep(userID int, step int64) (int64, error) {
+	sql := "UPDATE user_mfa SET last_step = $2 WHERE user_id = $1 AND last_step < $2"
+	res, err := m.db.Exec(context.Background(), sql, userID, step)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// UseRecoveryCode uses up an unused recovery code.
+func (m MfaRepositoryImpl) UseRecoveryCode(userID int, hash string) (int64, error) {
+	sql := "UPDATE mfa_recovery_codes SET used_at = now() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL"
+	res, err := m.db.Exec(context.Background(), sql, userID, hash)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
@@ -18,30 +18,75 @@ func NewMovieRepository(db *pgxpool.Pool, logger *zap.Logger) *MovieRepositoryIm
 }
 
 type Movie struct {
-	ID              int       `db:"id" json:"ID"`
-	Title           string    `db:"title" json:"title,omitempty"`
-	Description     string    `db:"description" json:"description,omitempty"`
-	ReleaseDateJson string    `db:"-" json:"releaseDate,omitempty"`
-	ReleaseDate     time.Time `db:"release_date" json:"-"`
-	Rating          int       `db:"rating" json:"rating,omitempty"`
+	ID          int       `db:"id"`
+	Title       string    `db:"title"`
+	Description string    `db:"description"`
+	ReleaseDate time.Time `db:"release_date"`
+	Rating      int       `db:"rating"`
+}
+
+// MovieFilter narrows GetMovies, empty fields match every movie. Title, Actor
+// and Director match a part of the title or of the name of a credited person,
+// Genre is the exact genre name.
+type MovieFilter struct {
+	Title    string
+	Actor    string
+	Genre    string
+	Director string
 }
 
 type MovieRepository interface {
-	CreateMovie(movie *Movie) error
+	CreateMovie(movie *Movie, credits []PersonCredit) ([]MovieActor, []Actor, error)
 	GetMovieMapByIDs(movieIDs []int, orderBy string) (map[int]Movie, error)
 	DeleteMovieById(id int) (int64, error)
 	UpdateMovie(movie Movie) (int64, error)
 	GetMovieById(id int) (Movie, error)
-	GetMoviesLikeTitle(title string, orderBy string) ([]Movie, error)
+	GetMovies(filter MovieFilter, orderBy string) ([]Movie, error)
 }
 
-func (m MovieRepositoryImpl) CreateMovie(movie *Movie) error {
+// CreateMovie creates the movie with its cast and crew in one transaction,
+// persons that do not exist yet are created. It returns the credits with their
+// persons set and the created persons. ErrCreditExists is returned when a
+// person is credited twice with the same credit type.
+func (m MovieRepositoryImpl) CreateMovie(movie *Movie, credits []PersonCredit) ([]MovieActor, []Actor, error) {
+	ctx := context.Background()
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	sql := "INSERT INTO movies (title, description, release_date, rating) VALUES ($1, $2, $3, $4) RETURNING id"
-	err := m.db.QueryRow(context.Background(), sql, movie.Title, movie.Description, movie.ReleaseDate, movie.Rating).Scan(&movie.ID)
+	err = tx.QueryRow(ctx, sql, movie.Title, movie.Description, movie.ReleaseDate, movie.Rating).Scan(&movie.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved, created, err := createPersons(ctx, tx, credits)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	var cast, crew []MovieActor
+	for _, credit := range resolved {
+		if credit.CreditType == CreditActor {
+			cast = append(cast, credit)
+		} else {
+			crew = append(crew, credit)
+		}
+	}
+	if len(cast) > 0 {
+		sql, values := castInsert(movie.ID, cast)
+		if _, err := tx.Exec(ctx, sql, values...); err != nil {
+			return nil, nil, creditError(err)
+		}
+	}
+	if len(crew) > 0 {
+		sql, values := crewInsert(movie.ID, crew)
+		if _, err := tx.Exec(ctx, sql, values...); err != nil {
+			return nil, nil, creditError(err)
+		}
 	}
-	return nil
+	return resolved, created, tx.Commit(ctx)
 }
 
 func (m MovieRepositoryImpl) GetMovieMapByIDs(movieIDs []int, orderBy string) (map[int]Movie, error) {
@@ -68,7 +113,6 @@ func (m MovieRepositoryImpl) GetMovieMapByIDs(movieIDs []int, orderBy string) (m
 	for rows.Next() {
 		var movie Movie
 		err := rows.Scan(&movie.ID, &movie.Title, &movie.Description, &movie.ReleaseDate, &movie.Rating)
-		movie.ReleaseDateJson = movie.ReleaseDate.Format("2006-01-02")
 		if err != nil {
 			return nil, err
 		}
@@ -110,12 +154,19 @@ func (m MovieRepositoryImpl) GetMovieById(id int) (Movie, error) {
 	if err != nil {
 		return Movie{}, err
 	}
-	movie.ReleaseDateJson = movie.ReleaseDate.Format("2006-01-02")
 	return movie, nil
 }
 
-func (m MovieRepositoryImpl) GetMoviesLikeTitle(title string, orderBy string) ([]Movie, error) {
-	sql := "SELECT * FROM movies WHERE title LIKE '%' || $1 || '%' ORDER BY "
+func (m MovieRepositoryImpl) GetMovies(filter MovieFilter, orderBy string) ([]Movie, error) {
+	sql := `SELECT m.id, m.title, m.description, m.release_date, m.rating FROM movies m
+		WHERE m.title LIKE '%' || $1 || '%'
+		AND ($2::text = '' OR EXISTS (SELECT 1 FROM movie_credits mc JOIN persons p ON p.id = mc.person_id
+			WHERE mc.movie_id = m.id AND mc.credit_type = 'actor' AND p.name LIKE '%' || $2 || '%'))
+		AND ($3::text = '' OR EXISTS (SELECT 1 FROM movies_genres mg JOIN genres g ON g.id = mg.genre_id
+			WHERE mg.movie_id = m.id AND g.name = $3))
+		AND ($4::text = '' OR EXISTS (SELECT 1 FROM movie_credits mc JOIN persons p ON p.id = mc.person_id
+			WHERE mc.movie_id = m.id AND mc.credit_type = 'director' AND p.name LIKE '%' || $4 || '%'))
+		ORDER BY `
 	switch orderBy {
 	case "rating":
 		sql += "rating DESC"
@@ -123,10 +174,14 @@ func (m MovieRepositoryImpl) GetMoviesLikeTitle(title string, orderBy string) ([
 		sql += "release_date DESC"
 	case "title":
 		sql += "title"
+	case "score":
+		sql += "(SELECT AVG(r.score) FROM reviews r WHERE r.movie_id = m.id) DESC NULLS LAST, m.id"
+	case "votes":
+		sql += "(SELECT COUNT(*) FROM reviews r WHERE r.movie_id = m.id) DESC, m.id"
 	default:
 		sql += "rating DESC"
 	}
-	rows, err := m.db.Query(context.Background(), sql, title)
+	rows, err := m.db.Query(context.Background(), sql, filter.Title, filter.Actor, filter.Genre, filter.Director)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +194,6 @@ func (m MovieRepositoryImpl) GetMoviesLikeTitle(title string, orderBy string) ([
 		if err := rows.Scan(&movie.ID, &movie.Title, &movie.Description, &movie.ReleaseDate, &movie.Rating); err != nil {
 			return nil, err
 		}
-		movie.ReleaseDateJson = movie.ReleaseDate.Format("2006-01-02")
 		movies = append(movies, movie)
 	}
 	if err := rows.Err(); err != nil {
@@ -2,12 +2,25 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 	"strings"
 )
 
+var (
+	// ErrCreditExists is returned when a person is credited on the movie twice
+	// with the same credit type.
+	ErrCreditExists = errors.New("credit exists")
+	// ErrCreditNotFound is returned when the credit to remove does not exist.
+	ErrCreditNotFound = errors.New("credit not found")
+	// ErrMovieNotFound is returned when the cast of a missing movie is changed.
+	ErrMovieNotFound = errors.New("movie not found")
+)
+
 type MovieActorRepositoryImpl struct {
 	db     *pgxpool.Pool
 	logger *zap.Logger
@@ -18,41 +31,190 @@ func NewMovieActorRepository(db *pgxpool.Pool, logger *zap.Logger) *MovieActorRe
 	return &MovieActorRepositoryImpl{db: db, logger: logger}
 }
 
+// Credit types of MovieActor.CreditType.
+const (
+	CreditActor    = "actor"
+	CreditDirector = "director"
+	CreditWriter   = "writer"
+	CreditProducer = "producer"
+	CreditComposer = "composer"
+)
+
+// MovieActor credits a person on a movie. The relation methods without a
+// credit type work on the cast, CreditActor. Character, Billing and Cameo
+// describe a cast credit, a zero Billing is stored as not billed.
 type MovieActor struct {
-	ID      int `db:"id" json:"-"`
-	MovieID int `db:"movie_id" json:"movieID"`
-	ActorID int `db:"actor_id" json:"actorID"`
+	MovieID    int    `db:"movie_id"`
+	ActorID    int    `db:"person_id"`
+	CreditType string `db:"credit_type"`
+	Character  string `db:"character_name"`
+	Billing    int    `db:"billing_order"`
+	Cameo      bool   `db:"cameo"`
+}
+
+// PersonCredit is a credit of a person that may not exist yet. When ActorID is
+// zero the person is looked up by the name of Person and created from Person
+// when no person has the name.
+type PersonCredit struct {
+	MovieActor
+	Person Actor
+}
+
+// CastChange is the cast of a movie before and after a change, Created holds
+// the persons created for the change.
+type CastChange struct {
+	Before  []MovieActor
+	After   []MovieActor
+	Created []Actor
 }
 
 type MovieActorRepository interface {
-	CreateMovieActorRelation(movieID int, actorIDs []int) error
-	GetRelationByMovieIDs(movieIDs []int) (map[int][]int, error)
+	GetCastByMovieIDs(movieIDs []int) (map[int][]MovieActor, error)
 	GetRelationByActorIDs(actorIDs []int) (map[int][]int, error)
+	AddMovieActor(movieID int, credit PersonCredit) (CastChange, error)
+	DeleteMovieActor(movieID int, actorID int) (CastChange, error)
+	ReplaceMovieCast(movieID int, cast []PersonCredit) (CastChange, error)
+	GetCrewByMovieIDs(movieIDs []int) (map[int][]MovieActor, error)
 }
 
-func (m MovieActorRepositoryImpl) CreateMovieActorRelation(movieID int, actorIDs []int) error {
-	var values []interface{}
-	values = append(values, movieID)
+// castInsert builds the statement inserting the cast credits of the movie.
+func castInsert(movieID int, cast []MovieActor) (string, []interface{}) {
+	values := []interface{}{movieID}
+	placeholders := make([]string, len(cast))
+	for i, credit := range cast {
+		values = append(values, credit.ActorID, credit.Character, credit.Billing, credit.Cameo)
+		placeholders[i] = fmt.Sprintf("($1, $%d, NULLIF($%d, ''), NULLIF($%d, 0), $%d)", 4*i+2, 4*i+3, 4*i+4, 4*i+5)
+	}
 
-	for _, actorID := range actorIDs {
-		values = append(values, actorID)
+	sql := "INSERT INTO movie_credits (movie_id, person_id, character_name, billing_order, cameo) VALUES " + strings.Join(placeholders, ", ")
+	return sql, values
+}
+
+func creditError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrCreditExists
 	}
+	return err
+}
 
-	sql := "INSERT INTO movies_actors (movie_id, actor_id) VALUES "
-	placeholders := make([]string, len(actorIDs))
-	for i := range actorIDs {
-		placeholders[i] = fmt.Sprintf("($1, $%d)", i+2)
+// createPersons sets the person of every credit, persons that do not exist
+// yet are created. It returns the credits and the created persons.
+func createPersons(ctx context.Context, tx pgx.Tx, credits []PersonCredit) ([]MovieActor, []Actor, error) {
+	res := make([]MovieActor, 0, len(credits))
+	var created []Actor
+	for _, credit := range credits {
+		if credit.ActorID == 0 {
+			person := credit.Person
+			sql := `INSERT INTO persons (name, gender, birth_date) VALUES ($1, $2, $3)
+				ON CONFLICT (name) DO NOTHING RETURNING id`
+			err := tx.QueryRow(ctx, sql, person.Name, person.Gender, person.BirthDate).Scan(&person.ID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				err = tx.QueryRow(ctx, "SELECT id FROM persons WHERE name = $1", person.Name).Scan(&person.ID)
+			} else if err == nil {
+				created = append(created, person)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			credit.ActorID = person.ID
+		}
+		res = append(res, credit.MovieActor)
 	}
-	sql += strings.Join(placeholders, ", ")
+	return res, created, nil
+}
 
-	_, err := m.db.Exec(context.Background(), sql, values...)
-	return err
+// changeCast runs change in a transaction that holds the lock of the movie, so
+// that the cast read before and after it is the one change worked on.
+func (m MovieActorRepositoryImpl) changeCast(movieID int, change func(ctx context.Context, tx pgx.Tx) ([]Actor, error)) (CastChange, error) {
+	ctx := context.Background()
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return CastChange{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, "SELECT id FROM movies WHERE id = $1 FOR UPDATE", movieID).Scan(&movieID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CastChange{}, ErrMovieNotFound
+	}
+	if err != nil {
+		return CastChange{}, err
+	}
+
+	var res CastChange
+	if res.Before, err = castOf(ctx, tx, movieID); err != nil {
+		return CastChange{}, err
+	}
+	if res.Created, err = change(ctx, tx); err != nil {
+		return CastChange{}, err
+	}
+	if res.After, err = castOf(ctx, tx, movieID); err != nil {
+		return CastChange{}, err
+	}
+	return res, tx.Commit(ctx)
+}
+
+// AddMovieActor adds the actor to the cast of the movie, creating the person
+// when it does not exist. ErrCreditExists is returned when the actor is in the
+// cast already and ErrMovieNotFound when there is no such movie.
+func (m MovieActorRepositoryImpl) AddMovieActor(movieID int, credit PersonCredit) (CastChange, error) {
+	return m.changeCast(movieID, func(ctx context.Context, tx pgx.Tx) ([]Actor, error) {
+		cast, created, err := createPersons(ctx, tx, []PersonCredit{credit})
+		if err != nil {
+			return nil, err
+		}
+		sql, values := castInsert(movieID, cast)
+		if _, err := tx.Exec(ctx, sql, values...); err != nil {
+			return nil, creditError(err)
+		}
+		return created, nil
+	})
+}
+
+// DeleteMovieActor removes the actor from the cast of the movie, crew credits
+// of the person are kept. ErrCreditNotFound is returned when the actor is not
+// in the cast.
+func (m MovieActorRepositoryImpl) DeleteMovieActor(movieID int, actorID int) (CastChange, error) {
+	return m.changeCast(movieID, func(ctx context.Context, tx pgx.Tx) ([]Actor, error) {
+		sql := "DELETE FROM movie_credits WHERE movie_id = $1 AND person_id = $2 AND credit_type = 'actor'"
+		res, err := tx.Exec(ctx, sql, movieID, actorID)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrCreditNotFound
+		}
+		return nil, nil
+	})
+}
+
+// ReplaceMovieCast replaces the cast of the movie and creates the persons that
+// do not exist in one transaction, the crew is not changed.
+func (m MovieActorRepositoryImpl) ReplaceMovieCast(movieID int, credits []PersonCredit) (CastChange, error) {
+	return m.changeCast(movieID, func(ctx context.Context, tx pgx.Tx) ([]Actor, error) {
+		cast, created, err := createPersons(ctx, tx, credits)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM movie_credits WHERE movie_id = $1 AND credit_type = 'actor'", movieID)
+		if err != nil {
+			return nil, err
+		}
+		if len(cast) > 0 {
+			sql, values := castInsert(movieID, cast)
+			if _, err := tx.Exec(ctx, sql, values...); err != nil {
+				return nil, creditError(err)
+			}
+		}
+		return created, nil
+	})
 }
 
 func (m MovieActorRepositoryImpl) GetRelationByActorIDs(actorIDs []int) (map[int][]int, error) {
 	relations := make(map[int][]int)
 
-	query := "SELECT actor_id, movie_id FROM movies_actors WHERE actor_id = ANY($1)"
+	query := "SELECT person_id, movie_id FROM movie_credits WHERE person_id = ANY($1) AND credit_type = 'actor'"
 	rows, err := m.db.Query(context.Background(), query, actorIDs)
 	if err != nil {
 		return nil, err
@@ -74,10 +236,72 @@ func (m MovieActorRepositoryImpl) GetRelationByActorIDs(actorIDs []int) (map[int
 	return relations, nil
 }
 
-func (m MovieActorRepositoryImpl) GetRelationByMovieIDs(movieIDs []int) (map[int][]int, error) {
-	relations := make(map[int][]int)
+const castSelect = `SELECT movie_id, person_id, credit_type, COALESCE(character_name, ''), COALESCE(billing_order, 0), cameo
+	FROM movie_credits WHERE movie_id = ANY($1) AND credit_type = 'actor'
+	ORDER BY billing_order NULLS LAST, person_id`
+
+// GetCastByMovieIDs returns the cast credits ordered by billing, credits
+// without billing come last.
+func (m MovieActorRepositoryImpl) GetCastByMovieIDs(movieIDs []int) (map[int][]MovieActor, error) {
+	rows, err := m.db.Query(context.Background(), castSelect, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+	return scanCast(rows)
+}
+
+// castOf returns the cast credits of the movie as seen by the transaction.
+func castOf(ctx context.Context, tx pgx.Tx, movieID int) ([]MovieActor, error) {
+	rows, err := tx.Query(ctx, castSelect, []int{movieID})
+	if err != nil {
+		return nil, err
+	}
+	cast, err := scanCast(rows)
+	if err != nil {
+		return nil, err
+	}
+	return cast[movieID], nil
+}
 
-	query := "SELECT movie_id, actor_id FROM movies_actors WHERE movie_id = ANY($1)"
+func scanCast(rows pgx.Rows) (map[int][]MovieActor, error) {
+	defer rows.Close()
+
+	cast := make(map[int][]MovieActor)
+	for rows.Next() {
+		var credit MovieActor
+		err := rows.Scan(&credit.MovieID, &credit.ActorID, &credit.CreditType, &credit.Character, &credit.Billing, &credit.Cameo)
+		if err != nil {
+			return nil, err
+		}
+		cast[credit.MovieID] = append(cast[credit.MovieID], credit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cast, nil
+}
+
+// crewInsert builds the statement inserting the crew credits of the movie.
+func crewInsert(movieID int, crew []MovieActor) (string, []interface{}) {
+	values := []interface{}{movieID}
+	placeholders := make([]string, len(crew))
+	for i, credit := range crew {
+		values = append(values, credit.ActorID, credit.CreditType)
+		placeholders[i] = fmt.Sprintf("($1, $%d, $%d)", 2*i+2, 2*i+3)
+	}
+
+	sql := "INSERT INTO movie_credits (movie_id, person_id, credit_type) VALUES " + strings.Join(placeholders, ", ")
+	return sql, values
+}
+
+// GetCrewByMovieIDs returns the credits other than the cast, ordered by credit type.
+func (m MovieActorRepositoryImpl) GetCrewByMovieIDs(movieIDs []int) (map[int][]MovieActor, error) {
+	crew := make(map[int][]MovieActor)
+
+	query := `SELECT movie_id, person_id, credit_type FROM movie_credits
+		WHERE movie_id = ANY($1) AND credit_type <> 'actor' ORDER BY credit_type, person_id`
 	rows, err := m.db.Query(context.Background(), query, movieIDs)
 	if err != nil {
 		return nil, err
@@ -85,16 +309,16 @@ func (m MovieActorRepositoryImpl) GetRelationByMovieIDs(movieIDs []int) (map[int
 	defer rows.Close()
 
 	for rows.Next() {
-		var movieID, actorID int
-		if err := rows.Scan(&movieID, &actorID); err != nil {
+		var credit MovieActor
+		if err := rows.Scan(&credit.MovieID, &credit.ActorID, &credit.CreditType); err != nil {
 			return nil, err
 		}
-		relations[movieID] = append(relations[movieID], actorID)
+		crew[credit.MovieID] = append(crew[credit.MovieID], credit)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return relations, nil
+	return crew, nil
 }
@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+type OidcRepositoryImpl struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewOidcRepository(db *pgxpool.Pool, logger *zap.Logger) *OidcRepositoryImpl {
+	logger.Info("create")
+	return &OidcRepositoryImpl{db: db, logger: logger}
+}
+
+// OidcLoginState is a started external login waiting for the provider to
+// redirect back. Only the hash of the state parameter is stored.
+type OidcLoginState struct {
+	StateHash    string    `db:"state_hash"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// UserIdentity links an account of an external provider to a user.
+type UserIdentity struct {
+	Issuer    string    `db:"issuer"`
+	Subject   string    `db:"subject"`
+	UserID    int       `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type OidcRepository interface {
+	CreateLoginState(state *OidcLoginState) error
+	UseLoginState(hash string) (OidcLoginState, error)
+	GetIdentityUserId(issuer string, subject string) (int, error)
+	CreateIdentity(issuer string, subject string, userID int) error
+	GetUserIdentities(userID int) ([]UserIdentity, error)
+}
+
+func (o OidcRepositoryImpl) CreateLoginState(state *OidcLoginState) error {
+	ctx := context.Background()
+	if _, err := o.db.Exec(ctx, "DELETE FROM oidc_login_states WHERE expires_at <= now()"); err != nil {
+		return err
+	}
+	sql := "INSERT INTO oidc_login_states (state_hash, nonce, code_verifier, expires_at) VALUES ($1, $2, $3, $4)"
+	_, err := o.db.Exec(ctx, sql, state.StateHash, state.Nonce, state.CodeVerifier, state.ExpiresAt)
+	return err
+}
+
+// UseLoginState deletes an unexpired state and returns it, a state can be
+// used only once.
+func (o OidcRepositoryImpl) UseLoginState(hash string) (OidcLoginState, error) {
+	sql := "DELETE FROM oidc_login_states WHERE state_hash = $1 AND expires_at > now() RETURNING state_hash, nonce, code_verifier, expires_at"
+	var state OidcLoginState
+	err := o.db.QueryRow(context.Background(), sql, hash).Scan(&state.StateHash, &state.Nonce, &state.CodeVerifier, &state.ExpiresAt)
+	if err != nil {
+		return OidcLoginState{}, err
+	}
+	return state, nil
+}
+
+// GetIdentityUserId returns the user linked to the external identity or zero
+// when the identity is not linked yet.
+func (o OidcRepositoryImpl) GetIdentityUserId(issuer string, subject string) (int, error) {
+	sql := "SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2"
+	var userID int
+	err := o.db.QueryRow(context.Background(), sql, issuer, subject).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (o OidcRepositoryImpl) CreateIdentity(issuer string, subject string, userID int) error {
+	sql := "INSERT INTO user_identities (issuer, subject, user_id) VALUES ($1, $2, $3)"
+	_, err := o.db.Exec(context.Background(), sql, issuer, subject, userID)
+	return err
+}
+
+func (o OidcRepositoryImpl) GetUserIdentities(userID int) ([]UserIdentity, error) {
+	sql := "SELECT issuer, subject, user_id, created_at FROM user_identities WHERE user_id = $1 ORDER BY created_at"
+	rows, err := o.db.Query(context.Background(), sql, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []UserIdentity
+	for rows.Next() {
+		var identity UserIdentity
+		if err := rows.Scan(&identity.Issuer, &identity.Subject, &identity.UserID, &identity.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
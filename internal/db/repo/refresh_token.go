@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+type RefreshTokenRepositoryImpl struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRefreshTokenRepository(db *pgxpool.Pool, logger *zap.Logger) *RefreshTokenRepositoryImpl {
+	logger.Info("create")
+	return &RefreshTokenRepositoryImpl{db: db, logger: logger}
+}
+
+type RefreshToken struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	Family    string     `db:"family"`
+	TokenHash string     `db:"token_hash"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(token *RefreshToken) error
+	GetRefreshTokenByHash(hash string) (RefreshToken, error)
+	RevokeRefreshToken(id int) (int64, error)
+	RevokeRefreshTokenFamily(family string) (int64, error)
+	RevokeUserRefreshTokens(userID int) (int64, error)
+	GetUserRefreshTokens(userID int) ([]RefreshToken, error)
+}
+
+func (r RefreshTokenRepositoryImpl) CreateRefreshToken(token *RefreshToken) error {
+	sql := "INSERT INTO refresh_tokens (user_id, family, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id, created_at"
+	err := r.db.QueryRow(context.Background(), sql, token.UserID, token.Family, token.TokenHash, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r RefreshTokenRepositoryImpl) GetRefreshTokenByHash(hash string) (RefreshToken, error) {
+	var token RefreshToken
+	sql := "SELECT id, user_id, family, token_hash, created_at, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = $1"
+	err := r.db.QueryRow(context.Background(), sql, hash).Scan(&token.ID, &token.UserID, &token.Family, &token.TokenHash,
+		&token.CreatedAt, &token.ExpiresAt, &token.RevokedAt)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	return token, nil
+}
+
+// RevokeRefreshToken revokes a single token. Zero affected rows means the token
+// has already been revoked, e.g. by a concurrent refresh with the same token.
+func (r RefreshTokenRepositoryImpl) RevokeRefreshToken(id int) (int64, error) {
+	sql := "UPDATE refresh_tokens SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL"
+	res, err := r.db.Exec(context.Background(), sql, id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+func (r RefreshTokenRepositoryImpl) RevokeRefreshTokenFamily(family string) (int64, error) {
+	sql := "UPDATE refresh_tokens SET revoked_at = now() WHERE family = $1 AND revoked_at IS NULL"
+	res, err := r.db.Exec(context.Background(), sql, family)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+func (r RefreshTokenRepositoryImpl) RevokeUserRefreshTokens(userID int) (int64, error) {
+	sql := "UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL"
+	res, err := r.db.Exec(context.Background(), sql, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// GetUserRefreshTokens returns all refresh tokens of the user, newest first.
+func (r RefreshTokenRepositoryImpl) GetUserRefreshTokens(userID int) ([]RefreshToken, error) {
+	sql := "SELECT id, user_id, family, token_hash, created_at, expires_at, revoked_at FROM refresh_tokens WHERE user_id = $1 ORDER BY id DESC"
+	rows, err := r.db.Query(context.Background(), sql, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []RefreshToken
+	for rows.Next() {
+		var token RefreshToken
+		err := rows.Scan(&token.ID, &token.UserID, &token.Family, &token.TokenHash, &token.CreatedAt, &token.ExpiresAt, &token.RevokedAt)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
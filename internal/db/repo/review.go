@@ -0,0 +1,150 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ErrReviewTaken is returned by CreateReview when the user has rated the movie already.
+var ErrReviewTaken = errors.New("review exists")
+
+type ReviewRepositoryImpl struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewReviewRepository(db *pgxpool.Pool, logger *zap.Logger) *ReviewRepositoryImpl {
+	logger.Info("create")
+	return &ReviewRepositoryImpl{db: db, logger: logger}
+}
+
+// Review is the score from 1 to 10 a user gave a movie, Text is empty when
+// the user only rated it.
+type Review struct {
+	ID        int       `db:"id"`
+	MovieID   int       `db:"movie_id"`
+	UserID    int       `db:"user_id"`
+	Score     int       `db:"score"`
+	Text      string    `db:"review"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// MovieScore aggregates the reviews of a movie.
+type MovieScore struct {
+	Average float64
+	Votes   int
+}
+
+type ReviewRepository interface {
+	CreateReview(review *Review) error
+	GetReviewById(id int) (Review, error)
+	GetUserReviews(userID int) ([]Review, error)
+	UpdateReview(review *Review) (int64, error)
+	DeleteReview(id int, userID int) (int64, error)
+	GetScoresByMovieIDs(movieIDs []int) (map[int]MovieScore, error)
+}
+
+func (r ReviewRepositoryImpl) CreateReview(review *Review) error {
+	sql := `INSERT INTO reviews (movie_id, user_id, score, review) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(context.Background(), sql, review.MovieID, review.UserID, review.Score, review.Text).
+		Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrReviewTaken
+	}
+	return err
+}
+
+func (r ReviewRepositoryImpl) GetReviewById(id int) (Review, error) {
+	sql := "SELECT id, movie_id, user_id, score, review, created_at, updated_at FROM reviews WHERE id = $1"
+	var review Review
+	err := r.db.QueryRow(context.Background(), sql, id).Scan(&review.ID, &review.MovieID, &review.UserID,
+		&review.Score, &review.Text, &review.CreatedAt, &review.UpdatedAt)
+	if err != nil {
+		return Review{}, err
+	}
+	return review, nil
+}
+
+// GetUserReviews returns the reviews of the user, the latest first.
+func (r ReviewRepositoryImpl) GetUserReviews(userID int) ([]Review, error) {
+	sql := `SELECT id, movie_id, user_id, score, review, created_at, updated_at FROM reviews
+		WHERE user_id = $1 ORDER BY created_at DESC, id DESC`
+	rows, err := r.db.Query(context.Background(), sql, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []Review
+	for rows.Next() {
+		var review Review
+		err := rows.Scan(&review.ID, &review.MovieID, &review.UserID, &review.Score, &review.Text, &review.CreatedAt, &review.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// UpdateReview changes the score and the text of a review of review.UserID
+// and sets review.UpdatedAt.
+func (r ReviewRepositoryImpl) UpdateReview(review *Review) (int64, error) {
+	sql := `UPDATE reviews SET score = $3, review = $4, updated_at = now()
+		WHERE id = $1 AND user_id = $2 RETURNING updated_at`
+	err := r.db.QueryRow(context.Background(), sql, review.ID, review.UserID, review.Score, review.Text).Scan(&review.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// DeleteReview deletes the review when it belongs to the user.
+func (r ReviewRepositoryImpl) DeleteReview(id int, userID int) (int64, error) {
+	res, err := r.db.Exec(context.Background(), "DELETE FROM reviews WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// GetScoresByMovieIDs returns the average score rounded to one decimal and the
+// number of votes, movies without reviews are left out.
+func (r ReviewRepositoryImpl) GetScoresByMovieIDs(movieIDs []int) (map[int]MovieScore, error) {
+	scores := make(map[int]MovieScore)
+
+	sql := `SELECT movie_id, ROUND(AVG(score), 1)::float8, COUNT(*) FROM reviews
+		WHERE movie_id = ANY($1) GROUP BY movie_id`
+	rows, err := r.db.Query(context.Background(), sql, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movieID int
+		var score MovieScore
+		if err := rows.Scan(&movieID, &score.Average, &score.Votes); err != nil {
+			return nil, err
+		}
+		scores[movieID] = score
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
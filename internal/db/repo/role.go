@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
@@ -19,12 +20,21 @@ func NewRoleRepository(db *pgxpool.Pool, logger *zap.Logger) *RoleRepositoryImpl
 }
 
 type Role struct {
-	ID   int    `db:"id" json:"ID"`
-	Name string `db:"name" json:"name"`
+	ID          int      `db:"id"`
+	Name        string   `db:"name"`
+	Permissions []string `db:"-"`
 }
 
 type RoleRepository interface {
 	GetRoleById(id int) (Role, error)
+	GetRoleByName(name string) (Role, error)
+	GetRoleByUserId(userID int) (Role, error)
+	GetRoles() ([]Role, error)
+	CreateRole(role *Role) error
+	SetRolePermissions(roleID int, permissions []string) error
+	DeleteRoleByName(name string) (int64, error)
+	GetPermissions() ([]string, error)
+	GetPermissionsByRoleName(name string) ([]string, error)
 }
 
 func (r RoleRepositoryImpl) GetRoleById(id int) (Role, error) {
@@ -42,3 +52,144 @@ func (r RoleRepositoryImpl) GetRoleById(id int) (Role, error) {
 	}
 	return role, nil
 }
+
+func (r RoleRepositoryImpl) GetRoleByName(name string) (Role, error) {
+	query := "SELECT id, name FROM roles WHERE name = $1"
+
+	var role Role
+	err := r.db.QueryRow(context.Background(), query, name).Scan(&role.ID, &role.Name)
+	if err != nil {
+		return Role{}, fmt.Errorf("error retrieving role %q: %w", name, err)
+	}
+	return role, nil
+}
+
+// GetRoleByUserId returns the role of an enabled user, disabled users have no role.
+func (r RoleRepositoryImpl) GetRoleByUserId(userID int) (Role, error) {
+	query := "SELECT r.id, r.name FROM roles r JOIN users u ON u.role_id = r.id WHERE u.id = $1 AND NOT u.disabled"
+
+	var role Role
+	err := r.db.QueryRow(context.Background(), query, userID).Scan(&role.ID, &role.Name)
+	if err != nil {
+		return Role{}, fmt.Errorf("error retrieving role of user %d: %w", userID, err)
+	}
+	return role, nil
+}
+
+func (r RoleRepositoryImpl) GetRoles() ([]Role, error) {
+	query := `SELECT r.id, r.name, COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		GROUP BY r.id ORDER BY r.id`
+
+	rows, err := r.db.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []Role
+	for rows.Next() {
+		var role Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.Permissions); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r RoleRepositoryImpl) CreateRole(role *Role) error {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, "INSERT INTO roles (name) VALUES ($1) RETURNING id", role.Name).Scan(&role.ID)
+	if err != nil {
+		return err
+	}
+	if err := setRolePermissions(ctx, tx, role.ID, role.Permissions); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r RoleRepositoryImpl) SetRolePermissions(roleID int, permissions []string) error {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setRolePermissions(ctx, tx, roleID, permissions); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func setRolePermissions(ctx context.Context, tx pgx.Tx, roleID int, permissions []string) error {
+	_, err := tx.Exec(ctx, "DELETE FROM role_permissions WHERE role_id = $1", roleID)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO role_permissions (role_id, permission_id) SELECT $1, id FROM permissions WHERE name = ANY($2)"
+	res, err := tx.Exec(ctx, query, roleID, permissions)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() != int64(len(permissions)) {
+		return fmt.Errorf("unknown permission in %v", permissions)
+	}
+	return nil
+}
+
+func (r RoleRepositoryImpl) DeleteRoleByName(name string) (int64, error) {
+	query := "DELETE FROM roles WHERE name = $1"
+	res, err := r.db.Exec(context.Background(), query, name)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+func (r RoleRepositoryImpl) GetPermissions() ([]string, error) {
+	return r.queryPermissions("SELECT name FROM permissions ORDER BY name")
+}
+
+func (r RoleRepositoryImpl) GetPermissionsByRoleName(name string) ([]string, error) {
+	query := `SELECT p.name FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		JOIN roles r ON r.id = rp.role_id
+		WHERE r.name = $1 ORDER BY p.name`
+	return r.queryPermissions(query, name)
+}
+
+func (r RoleRepositoryImpl) queryPermissions(query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
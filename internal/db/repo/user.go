@@ -2,10 +2,28 @@ package repo
 
 import (
 	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
+	"time"
 )
 
+var (
+	// ErrLoginTaken is returned by CreateUser when the login is already in use.
+	ErrLoginTaken = errors.New("login is taken")
+	// ErrEmailTaken is returned by SetEmailVerified when another user has
+	// verified the email.
+	ErrEmailTaken = errors.New("email is taken")
+	// ErrLastHolder is returned when a change would leave no enabled user with
+	// the permission the change has to keep.
+	ErrLastHolder = errors.New("no enabled user would keep the permission")
+)
+
+// uniqueViolation is the postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
 type UserRepositoryImpl struct {
 	db     *pgxpool.Pool
 	logger *zap.Logger
@@ -17,32 +35,299 @@ func NewUserRepository(db *pgxpool.Pool, logger *zap.Logger) *UserRepositoryImpl
 }
 
 type User struct {
-	ID     int    `db:"id" json:"ID"`
-	Login  string `db:"login" json:"login"`
-	Pass   string `db:"pass" json:"pass"`
-	RoleID int    `db:"role_id" json:"-"`
+	ID        int       `db:"id"`
+	Login     string    `db:"login"`
+	Pass      string    `db:"pass"`
+	RoleID    int       `db:"role_id"`
+	Role      string    `db:"-"`
+	Disabled  bool      `db:"disabled"`
+	CreatedAt time.Time `db:"created_at"`
+
+	MustChangePassword bool   `db:"must_change_password"`
+	Email              string `db:"email"`
+	EmailVerified      bool   `db:"email_verified"`
+	DisplayName        string `db:"display_name"`
 }
 
 type UserRepository interface {
 	GetUserByLogin(login string) (User, error)
-	CreateUser(user User) error
+	GetUserById(id int) (User, error)
+	GetUsers(search string, limit int, offset int) ([]User, int, error)
+	CreateUser(user *User) error
+	SetUserRole(userID int, roleID int, keep string) (int64, error)
+	SetUserDisabled(userID int, disabled bool, keep string) (int64, error)
+	SetMustChangePassword(userID int, mustChange bool) (int64, error)
+	UpdatePassword(userID int, pass string) (int64, error)
+	SetPasswordHash(userID int, pass string) (int64, error)
+	GetUserByEmail(email string) (User, error)
+	SetUserEmail(userID int, email string) (int64, error)
+	SetEmailVerified(userID int, email string) (int64, error)
+	DeleteUserById(id int, keep string) (int64, error)
+	SetDisplayName(userID int, name string) (int64, error)
+	DeleteAccount(userID int, keep string) (int64, error)
 }
 
-func (u UserRepositoryImpl) GetUserByLogin(login string) (User, error) {
+const (
+	userColumns = "u.id, u.login, u.pass, u.role_id, COALESCE(r.name, ''), u.disabled, u.created_at, u.must_change_password, COALESCE(u.email, ''), u.email_verified, COALESCE(u.display_name, '')"
+	userSelect  = "SELECT " + userColumns + " FROM users u LEFT JOIN roles r ON r.id = u.role_id "
+)
+
+func scanUser(row pgx.Row, extra ...interface{}) (User, error) {
 	var user User
-	sql := "SELECT * FROM users WHERE login = $1 LIMIT 1"
-	err := u.db.QueryRow(context.Background(), sql, login).Scan(&user.ID, &user.Login, &user.Pass, &user.RoleID)
+	dest := append([]interface{}{&user.ID, &user.Login, &user.Pass, &user.RoleID, &user.Role, &user.Disabled, &user.CreatedAt,
+		&user.MustChangePassword, &user.Email, &user.EmailVerified, &user.DisplayName}, extra...)
+	err := row.Scan(dest...)
+	return user, err
+}
+
+func (u UserRepositoryImpl) GetUserByLogin(login string) (User, error) {
+	sql := userSelect + "WHERE u.login = $1 LIMIT 1"
+	user, err := scanUser(u.db.QueryRow(context.Background(), sql, login))
 	if err != nil {
 		return User{}, err
 	}
 	return user, nil
 }
 
-func (u UserRepositoryImpl) CreateUser(user User) error {
+func (u UserRepositoryImpl) GetUserById(id int) (User, error) {
+	sql := userSelect + "WHERE u.id = $1"
+	user, err := scanUser(u.db.QueryRow(context.Background(), sql, id))
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// GetUsers returns a page of users whose login contains search literally, "%" and "_"
+// are not wildcards, and the total number of matches.
+func (u UserRepositoryImpl) GetUsers(search string, limit int, offset int) ([]User, int, error) {
+	sql := "SELECT " + userColumns + ", count(*) OVER() " +
+		"FROM users u LEFT JOIN roles r ON r.id = u.role_id " +
+		"WHERE strpos(u.login, $1) > 0 ORDER BY u.id LIMIT $2 OFFSET $3"
+	rows, err := u.db.Query(context.Background(), sql, search, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []User
+	var total int
+	for rows.Next() {
+		user, err := scanUser(rows, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (u UserRepositoryImpl) CreateUser(user *User) error {
 	sql := "INSERT INTO users (login, pass) VALUES ($1, $2) RETURNING id"
-	err := u.db.QueryRow(context.Background(), sql, user.Login, user.Pass).Scan(&user.ID)
+	args := []interface{}{user.Login, user.Pass}
+	if user.RoleID != 0 {
+		sql = "INSERT INTO users (login, pass, role_id) VALUES ($1, $2, $3) RETURNING id"
+		args = append(args, user.RoleID)
+	}
+	err := u.db.QueryRow(context.Background(), sql, args...).Scan(&user.ID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrLoginTaken
+	}
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+const holdersSelect = `SELECT u.id FROM users u
+	JOIN role_permissions rp ON rp.role_id = u.role_id
+	JOIN permissions p ON p.id = rp.permission_id
+	WHERE p.name = $1 AND NOT u.disabled`
+
+// keepHolder runs change in a transaction and fails with ErrLastHolder when
+// the change leaves no enabled user with the permission keep. The holders are
+// locked first, so that concurrent changes cannot remove the last two of them
+// at once. An empty keep runs change without the check.
+func (u UserRepositoryImpl) keepHolder(keep string, change func(ctx context.Context, tx pgx.Tx) (int64, error)) (int64, error) {
+	ctx := context.Background()
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var before int
+	if keep != "" {
+		rows, err := tx.Query(ctx, holdersSelect+" FOR UPDATE OF u", keep)
+		if err != nil {
+			return 0, err
+		}
+		for rows.Next() {
+			before++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := change(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	if before > 0 {
+		var after int
+		err := tx.QueryRow(ctx, "SELECT count(*) FROM ("+holdersSelect+") h", keep).Scan(&after)
+		if err != nil {
+			return 0, err
+		}
+		if after == 0 {
+			return 0, ErrLastHolder
+		}
+	}
+	return res, tx.Commit(ctx)
+}
+
+// SetUserRole changes the role of the user, keep is the permission that an
+// enabled user has to hold afterwards, see keepHolder.
+func (u UserRepositoryImpl) SetUserRole(userID int, roleID int, keep string) (int64, error) {
+	return u.keepHolder(keep, func(ctx context.Context, tx pgx.Tx) (int64, error) {
+		res, err := tx.Exec(ctx, "UPDATE users SET role_id = $2 WHERE id = $1", userID, roleID)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected(), nil
+	})
+}
+
+// SetUserDisabled enables or disables the user, keep is the permission that an
+// enabled user has to hold afterwards, see keepHolder.
+func (u UserRepositoryImpl) SetUserDisabled(userID int, disabled bool, keep string) (int64, error) {
+	return u.keepHolder(keep, func(ctx context.Context, tx pgx.Tx) (int64, error) {
+		res, err := tx.Exec(ctx, "UPDATE users SET disabled = $2 WHERE id = $1", userID, disabled)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected(), nil
+	})
+}
+
+func (u UserRepositoryImpl) SetMustChangePassword(userID int, mustChange bool) (int64, error) {
+	sql := "UPDATE users SET must_change_password = $2 WHERE id = $1"
+	res, err := u.db.Exec(context.Background(), sql, userID, mustChange)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// UpdatePassword stores a new password hash and clears the must change password flag.
+func (u UserRepositoryImpl) UpdatePassword(userID int, pass string) (int64, error) {
+	sql := "UPDATE users SET pass = $2, must_change_password = false WHERE id = $1"
+	res, err := u.db.Exec(context.Background(), sql, userID, pass)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// SetPasswordHash replaces the hash of the same password, e.g. after the hashing
+// settings have changed. Unlike UpdatePassword it keeps the must change password flag.
+func (u UserRepositoryImpl) SetPasswordHash(userID int, pass string) (int64, error) {
+	sql := "UPDATE users SET pass = $2 WHERE id = $1"
+	res, err := u.db.Exec(context.Background(), sql, userID, pass)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// GetUserByEmail returns the user that has verified the email, unverified
+// emails are not unique.
+func (u UserRepositoryImpl) GetUserByEmail(email string) (User, error) {
+	sql := userSelect + "WHERE lower(u.email) = lower($1) AND u.email_verified"
+	user, err := scanUser(u.db.QueryRow(context.Background(), sql, email))
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// SetUserEmail changes the email, the new email is not verified. Only verified
+// emails are unique, so an address is not reserved before it is verified.
+func (u UserRepositoryImpl) SetUserEmail(userID int, email string) (int64, error) {
+	sql := "UPDATE users SET email = $2, email_verified = false WHERE id = $1"
+	res, err := u.db.Exec(context.Background(), sql, userID, email)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// SetEmailVerified marks the email verified unless it has been changed since.
+// ErrEmailTaken is returned when another user has verified the email.
+func (u UserRepositoryImpl) SetEmailVerified(userID int, email string) (int64, error) {
+	sql := "UPDATE users SET email_verified = true WHERE id = $1 AND email = $2"
+	res, err := u.db.Exec(context.Background(), sql, userID, email)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return 0, ErrEmailTaken
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// DeleteUserById deletes the user, keep is the permission that an enabled user
+// has to hold afterwards, see keepHolder.
+func (u UserRepositoryImpl) DeleteUserById(id int, keep string) (int64, error) {
+	return u.keepHolder(keep, func(ctx context.Context, tx pgx.Tx) (int64, error) {
+		res, err := tx.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected(), nil
+	})
+}
+
+func (u UserRepositoryImpl) SetDisplayName(userID int, name string) (int64, error) {
+	sql := "UPDATE users SET display_name = NULLIF($2, '') WHERE id = $1"
+	res, err := u.db.Exec(context.Background(), sql, userID, name)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// DeleteAccount deletes the user together with everything the user owns.
+// Sessions, tokens, the second factor and linked identities are removed by
+// the foreign keys, API keys the user issued and the failed login counter
+// are removed here. The audit log is append-only and keeps the login, only the
+// user id of the events is cleared by the foreign key. keep is
+// the permission that an enabled user has to hold afterwards, see keepHolder.
+func (u UserRepositoryImpl) DeleteAccount(userID int, keep string) (int64, error) {
+	return u.keepHolder(keep, func(ctx context.Context, tx pgx.Tx) (int64, error) {
+		if _, err := tx.Exec(ctx, "DELETE FROM api_keys WHERE created_by = $1", userID); err != nil {
+			return 0, err
+		}
+		var login string
+		err := tx.QueryRow(ctx, "DELETE FROM users WHERE id = $1 RETURNING login", userID).Scan(&login)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		sql := "DELETE FROM login_attempts WHERE scope = $1 AND subject = $2"
+		if _, err := tx.Exec(ctx, sql, LoginScopeLogin, login); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	})
+}
@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	TokenPurposeEmailVerify   = "email_verify"
+	TokenPurposePasswordReset = "password_reset"
+)
+
+type UserTokenRepositoryImpl struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewUserTokenRepository(db *pgxpool.Pool, logger *zap.Logger) *UserTokenRepositoryImpl {
+	logger.Info("create")
+	return &UserTokenRepositoryImpl{db: db, logger: logger}
+}
+
+// UserToken is a single-use token mailed to a user, e.g. to verify an email or
+// to reset the password. Email is the address being verified.
+type UserToken struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	Purpose   string     `db:"purpose"`
+	TokenHash string     `db:"token_hash"`
+	Email     string     `db:"email"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+type UserTokenRepository interface {
+	CreateUserToken(token *UserToken) error
+	GetValidUserToken(hash string, purpose string) (UserToken, error)
+	UseUserToken(hash string, purpose string) (UserToken, error)
+	InvalidateUserTokens(userID int, purpose string) (int64, error)
+}
+
+const userTokenColumns = "id, user_id, purpose, token_hash, COALESCE(email, ''), created_at, expires_at, used_at"
+
+func scanUserToken(row pgx.Row) (UserToken, error) {
+	var token UserToken
+	err := row.Scan(&token.ID, &token.UserID, &token.Purpose, &token.TokenHash, &token.Email,
+		&token.CreatedAt, &token.ExpiresAt, &token.UsedAt)
+	return token, err
+}
+
+func (t UserTokenRepositoryImpl) CreateUserToken(token *UserToken) error {
+	sql := "INSERT INTO user_tokens (user_id, purpose, token_hash, email, expires_at) VALUES ($1, $2, $3, NULLIF($4, ''), $5) RETURNING id, created_at"
+	err := t.db.QueryRow(context.Background(), sql, token.UserID, token.Purpose, token.TokenHash, token.Email, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetValidUserToken returns an unused and unexpired token without using it up.
+func (t UserTokenRepositoryImpl) GetValidUserToken(hash string, purpose string) (UserToken, error) {
+	sql := "SELECT " + userTokenColumns + " FROM user_tokens WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()"
+	token, err := scanUserToken(t.db.QueryRow(context.Background(), sql, hash, purpose))
+	if err != nil {
+		return UserToken{}, err
+	}
+	return token, nil
+}
+
+// UseUserToken marks a valid token as used and returns it. A token can be used
+// only once, concurrent calls with the same token get no rows.
+func (t UserTokenRepositoryImpl) UseUserToken(hash string, purpose string) (UserToken, error) {
+	sql := "UPDATE user_tokens SET used_at = now() WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now() RETURNING " + userTokenColumns
+	token, err := scanUserToken(t.db.QueryRow(context.Background(), sql, hash, purpose))
+	if err != nil {
+		return UserToken{}, err
+	}
+	return token, nil
+}
+
+// InvalidateUserTokens uses up the outstanding tokens of the user, so only the
+// latest one sent stays valid.
+func (t UserTokenRepositoryImpl) InvalidateUserTokens(userID int, purpose string) (int64, error) {
+	sql := "UPDATE user_tokens SET used_at = now() WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL"
+	res, err := t.db.Exec(context.Background(), sql, userID, purpose)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
@@ -3,8 +3,6 @@ package handlers
 import (
 	"go.uber.org/zap"
 	"net/http"
-	"time"
-	"vk-inter-test-go/internal/db/repo"
 	"vk-inter-test-go/internal/io/ioutils"
 	"vk-inter-test-go/internal/io/models"
 )
@@ -16,26 +14,34 @@ import (
 // @Tags Actors
 // @Accept  json
 // @Produce  json
-// @Param body body repo.Actor true "Данные актера"
+// @Param body body models.Actor true "Данные актера"
 // @Param Authorization header string true "Bearer"
 // @Security bearerAuth
-// @Success 200 {object} repo.Actor "Успешно созданный актер"
+// @Success 200 {object} models.Actor "Успешно созданный актер"
 // @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
 // @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
-// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимой роли"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимого разрешения"
 // @Router /api/actor [post]
 func (c *Controller) CreateActor(w http.ResponseWriter, req *http.Request) {
 
-	var actor repo.Actor
-	err := ioutils.DecodeRequestBody(req, &actor)
-	if err != nil || !ioutils.ActorJsonValidate(&actor) {
+	var body models.Actor
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || !ioutils.ActorJsonValidate(body) {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+	actor, err := body.ToRepo()
+	if err != nil {
 		ioutils.HandleInvalidJson(w)
 		return
 	}
 
 	var answer interface{}
 
-	createActor, err := c.Bl.CreateActor(actor)
+	createActor, err := c.Bl.CreateActor(req.Context(), actor)
+	if c.respAuditFailed(w, err) {
+		return
+	}
 	if err != nil {
 		c.logger.Info("err", zap.Error(err))
 		w.WriteHeader(http.StatusBadRequest)
@@ -43,7 +49,7 @@ func (c *Controller) CreateActor(w http.ResponseWriter, req *http.Request) {
 			Error: "err : '" + err.Error() + "'",
 		}
 	} else {
-		answer = createActor
+		answer = models.ActorFromRepo(createActor)
 	}
 	c.logger.Info("resp : ", zap.Reflect("answer :", createActor))
 	ioutils.RespJson(w, answer)
@@ -52,7 +58,7 @@ func (c *Controller) CreateActor(w http.ResponseWriter, req *http.Request) {
 // DeleteActor удаляет актера по имени.
 //
 // @Summary Удаляет актера по имени
-// @Description Удаляет актера с указанным именем.
+// @Description Удаляет актера с указанным именем вместе со всеми его участиями в фильмах, включая участие в съемочной группе. Люди, входящие только в съемочные группы, актерами не считаются и не удаляются.
 // @Tags Actors
 // @Param name query string true "Имя актера для удаления"
 // @Param Authorization header string true "Bearer"
@@ -60,7 +66,7 @@ func (c *Controller) CreateActor(w http.ResponseWriter, req *http.Request) {
 // @Success 200 {object} models.OkResponse "Успешное удаление актера"
 // @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
 // @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
-// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимой роли"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимого разрешения"
 // @Failure 404 {object} models.ErrorResponse "Актер не найден"
 // @Router /api/actor [delete]
 func (c *Controller) DeleteActor(w http.ResponseWriter, req *http.Request) {
@@ -68,7 +74,10 @@ func (c *Controller) DeleteActor(w http.ResponseWriter, req *http.Request) {
 
 	var answer interface{}
 
-	res, err := c.Bl.DeleteActor(name)
+	res, err := c.Bl.DeleteActor(req.Context(), name)
+	if c.respAuditFailed(w, err) {
+		return
+	}
 	if res == 0 {
 		w.WriteHeader(http.StatusNotFound)
 		answer = models.ErrorResponse{
@@ -101,31 +110,32 @@ func (c *Controller) DeleteActor(w http.ResponseWriter, req *http.Request) {
 // @Produce  json
 // @Param Authorization header string true "Bearer"
 // @Security bearerAuth
-// @Param body body repo.Actor true "Данные актера для обновления"
-// @Success 200 {object} repo.Actor "Обновленные данные актера"
+// @Param body body models.Actor true "Данные актера для обновления"
+// @Success 200 {object} models.Actor "Обновленные данные актера"
 // @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
-// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимой роли"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимого разрешения"
 // @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
 // @Failure 404 {object} models.ErrorResponse "Актер не найден"
 // @Router /api/actor [put]
 func (c *Controller) UpdateActor(w http.ResponseWriter, req *http.Request) {
-	var actor repo.Actor
-	err := ioutils.DecodeRequestBody(req, &actor)
-
-	if len(actor.BirthDateJson) > 0 {
-		actor.BirthDate, err = time.Parse("2006-01-02", actor.BirthDateJson)
-		if err != nil {
-			c.logger.Info("err :", zap.Error(err))
-			ioutils.HandleInvalidJson(w)
-			return
-		}
+	var body models.Actor
+	err := ioutils.DecodeRequestBody(req, &body)
+
+	actor, err := body.ToRepo()
+	if err != nil {
+		c.logger.Info("err :", zap.Error(err))
+		ioutils.HandleInvalidJson(w)
+		return
 	}
 	var answer interface{}
 	if len(actor.Gender) > 0 && !(actor.Gender == "male" || actor.Gender == "female") {
 		ioutils.HandleInvalidJson(w)
 		return
 	}
-	actor, err = c.Bl.UpdateActor(actor)
+	actor, err = c.Bl.UpdateActor(req.Context(), actor)
+	if c.respAuditFailed(w, err) {
+		return
+	}
 	if err != nil {
 		c.logger.Info("err", zap.Error(err))
 		w.WriteHeader(http.StatusNotFound)
@@ -133,8 +143,7 @@ func (c *Controller) UpdateActor(w http.ResponseWriter, req *http.Request) {
 			Error: "err : '" + err.Error() + "'",
 		}
 	} else {
-		actor.BirthDateJson = actor.BirthDate.Format("2006-01-02")
-		answer = actor
+		answer = models.ActorFromRepo(actor)
 	}
 	c.logger.Info("resp : ", zap.Reflect("answer :", answer))
 
@@ -144,11 +153,11 @@ func (c *Controller) UpdateActor(w http.ResponseWriter, req *http.Request) {
 // GetAllActors получает всех актеров.
 //
 // @Summary Получает всех актеров или актеров с определенным именем
-// @Description Получает всех актеров, если имя не указано, или актеров с определенным именем, если имя указано в запросе.
+// @Description Получает всех актеров, если имя не указано, или актеров с определенным именем, если имя указано в запросе. Люди, входящие только в съемочные группы, не выводятся. При включенном гостевом доступе токен не обязателен.
 // @Tags Actors
 // @Param name query string false "Имя актера для фильтрации"
 // @Param sort query string false "Поле для сортировки, Доступные значения: 'name', 'date'"
-// @Param Authorization header string true "Bearer"
+// @Param Authorization header string false "Bearer"
 // @Security bearerAuth
 // @Accept  json
 // @Produce  json
@@ -156,6 +165,7 @@ func (c *Controller) UpdateActor(w http.ResponseWriter, req *http.Request) {
 // @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
 // @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
 // @Failure 404 {object} models.ErrorResponse "Актер не найден"
+// @Failure 429 {object} models.ErrorResponse "Превышен лимит запросов без токена, см. заголовок Retry-After"
 // @Router /api/actor [get]
 func (c *Controller) GetAllActors(w http.ResponseWriter, req *http.Request) {
 	name := req.URL.Query().Get("name")
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+)
+
+// GetApiKeys lists API keys.
+//
+// @Summary Lists API keys
+// @Description Lists API keys with their permissions, expiry and last use. The keys themselves are not stored and cannot be shown.
+// @Tags Admin
+// @Produce  json
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {array} models.ApiKeyIo "API keys"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения apikey:manage"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/admin/apikeys [get]
+func (c *Controller) GetApiKeys(w http.ResponseWriter, req *http.Request) {
+	keys, err := c.Bl.GetApiKeys()
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	ioutils.RespJson(w, keys)
+}
+
+// CreateApiKey issues an API key.
+//
+// @Summary Issues an API key
+// @Description Issues an API key with the given permissions and optional expiry. The key is returned only in this response, pass it in the X-API-Key header. Only permissions the caller has can be granted.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param body body models.ApiKeyCreateRequest true "Key name, permissions and expiry"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.ApiKeyCreated "Issued key"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format, unknown permission or expiry in the past"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения apikey:manage или выдаваемого разрешения"
+// @Router /api/admin/apikeys [post]
+func (c *Controller) CreateApiKey(w http.ResponseWriter, req *http.Request) {
+	var body models.ApiKeyCreateRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.Name) == 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	key, err := c.Bl.CreateApiKey(req.Context(), body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		if errors.Is(err, bl.ErrPermissionNotHeld) {
+			w.WriteHeader(http.StatusForbidden)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	ioutils.RespJson(w, key)
+}
+
+// RevokeApiKey revokes an API key.
+//
+// @Summary Revokes an API key
+// @Description Revokes the API key, requests with it are rejected from now on.
+// @Tags Admin
+// @Produce  json
+// @Param id query integer true "API key ID"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.OkResponse "Revoked"
+// @Failure 400 {object} models.ErrorResponse "Не верное значение ID"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения apikey:manage"
+// @Failure 404 {object} models.ErrorResponse "Key not found or already revoked"
+// @Router /api/admin/apikeys [delete]
+func (c *Controller) RevokeApiKey(w http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("Не верное значение ID", w)
+		return
+	}
+
+	err = c.Bl.RevokeApiKey(id)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		if errors.Is(err, bl.ErrApiKeyNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "API key revoked"})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/ioutils"
+)
+
+// GetAuditEvents lists recorded changes of the catalog.
+//
+// @Summary Lists audit events
+// @Description Lists changes of movies and actors, newest first, with the user who made them, the state before and after, the request ID and the client address. All filters are optional.
+// @Tags Admin
+// @Produce  json
+// @Param entity query string false "Entity type: movie or actor"
+// @Param entityId query integer false "Entity ID"
+// @Param user query string false "Login of the user who made the change"
+// @Param from query string false "Start of the time range, RFC 3339, inclusive"
+// @Param to query string false "End of the time range, RFC 3339, exclusive"
+// @Param page query integer false "Page number, starting from 1"
+// @Param limit query integer false "Page size, 20 by default, at most 100"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.AuditPage "Audit events"
+// @Failure 400 {object} models.ErrorResponse "Invalid filter or paging parameters"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения audit:read"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/admin/audit [get]
+func (c *Controller) GetAuditEvents(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+	query := req.URL.Query()
+
+	filter := repo.AuditFilter{
+		EntityType: query.Get("entity"),
+		ActorLogin: query.Get("user"),
+	}
+	var err error
+	if str := query.Get("entityId"); len(str) > 0 {
+		filter.EntityID, err = strconv.Atoi(str)
+		if err != nil || filter.EntityID <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			ioutils.RespErrorText("Не верное значение ID", w)
+			return
+		}
+	}
+	for name, dest := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		str := query.Get(name)
+		if len(str) == 0 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ioutils.RespErrorText("invalid "+name+", expected RFC 3339", w)
+			return
+		}
+		*dest = &t
+	}
+
+	page, limit, ok := pageParams(req)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("invalid page or limit", w)
+		return
+	}
+
+	events, err := c.Bl.GetAuditEvents(filter, page, limit)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText("internal error", w)
+		return
+	}
+	ioutils.RespJson(w, events)
+}
+
+// respAuditFailed reports a change that is saved without its audit event as a
+// server error, it returns false for other errors.
+func (c *Controller) respAuditFailed(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, bl.ErrAuditFailed) {
+		return false
+	}
+	w.WriteHeader(http.StatusInternalServerError)
+	ioutils.RespErrorText(err.Error(), w)
+	return true
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+)
+
+// AddMovieActor добавляет актера в состав фильма.
+//
+// @Summary Добавляет актера в состав фильма
+// @Description Добавляет актера с ролью, порядком в титрах и признаком камео в состав фильма. Актер ищется по имени, неизвестный актер создается.
+// @Tags Movies
+// @Accept  json
+// @Produce  json
+// @Param body body models.MovieActorRequest true "ID фильма и данные актера"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {array} models.CastMember "Актерский состав фильма"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимого разрешения"
+// @Failure 404 {object} models.ErrorResponse "Фильм не найден"
+// @Failure 409 {object} models.ErrorResponse "Актер уже в составе фильма"
+// @Router /api/movie/cast [post]
+func (c *Controller) AddMovieActor(w http.ResponseWriter, req *http.Request) {
+	var body models.MovieActorRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || body.MovieID <= 0 || !ioutils.CastMemberValidate(body.CastMember) {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	cast, err := c.Bl.AddMovieActor(req.Context(), body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respCastError(w, err)
+		return
+	}
+	ioutils.RespJson(w, cast)
+}
+
+// RemoveMovieActor удаляет актера из состава фильма.
+//
+// @Summary Удаляет актера из состава фильма
+// @Description Удаляет актера из актерского состава фильма, участие человека в съемочной группе сохраняется.
+// @Tags Movies
+// @Produce  json
+// @Param movieId query integer true "ID фильма"
+// @Param actorId query integer true "ID актера"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {array} models.CastMember "Актерский состав фильма"
+// @Failure 400 {object} models.ErrorResponse "Неверное значение ID"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимого разрешения"
+// @Failure 404 {object} models.ErrorResponse "Фильм не найден или актер не в составе фильма"
+// @Router /api/movie/cast [delete]
+func (c *Controller) RemoveMovieActor(w http.ResponseWriter, req *http.Request) {
+	movieID, err := strconv.Atoi(req.URL.Query().Get("movieId"))
+	if err != nil || movieID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("Не верное значение ID", w)
+		return
+	}
+	actorID, err := strconv.Atoi(req.URL.Query().Get("actorId"))
+	if err != nil || actorID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("Не верное значение ID", w)
+		return
+	}
+
+	cast, err := c.Bl.RemoveMovieActor(req.Context(), movieID, actorID)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respCastError(w, err)
+		return
+	}
+	ioutils.RespJson(w, cast)
+}
+
+// ReplaceMovieCast заменяет актерский состав фильма.
+//
+// @Summary Заменяет актерский состав фильма
+// @Description Заменяет весь актерский состав фильма перечисленными актерами в одной транзакции, пустой список удаляет состав. Съемочная группа не меняется, неизвестные актеры создаются.
+// @Tags Movies
+// @Accept  json
+// @Produce  json
+// @Param body body models.MovieCastRequest true "ID фильма и актерский состав"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {array} models.CastMember "Актерский состав фильма"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимого разрешения"
+// @Failure 404 {object} models.ErrorResponse "Фильм не найден"
+// @Failure 409 {object} models.ErrorResponse "Актер указан несколько раз"
+// @Router /api/movie/cast [put]
+func (c *Controller) ReplaceMovieCast(w http.ResponseWriter, req *http.Request) {
+	var body models.MovieCastRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || body.MovieID <= 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+	for _, member := range body.Actors {
+		if !ioutils.CastMemberValidate(member) {
+			ioutils.HandleInvalidJson(w)
+			return
+		}
+	}
+
+	cast, err := c.Bl.ReplaceMovieCast(req.Context(), body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respCastError(w, err)
+		return
+	}
+	ioutils.RespJson(w, cast)
+}
+
+func (c *Controller) respCastError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bl.ErrInvalidActor):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, bl.ErrMovieNotFound), errors.Is(err, bl.ErrActorNotInCast):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, bl.ErrActorInCast):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, bl.ErrAuditFailed):
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText("internal error", w)
+		return
+	}
+	ioutils.RespErrorText(err.Error(), w)
+}
@@ -3,14 +3,16 @@ package handlers
 import (
 	"go.uber.org/zap"
 	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/config"
 )
 
 type Controller struct {
-	Bl     *bl.BL
-	logger *zap.Logger
+	Bl      *bl.BL
+	options config.OptionsSrv
+	logger  *zap.Logger
 }
 
-func NewController(bl *bl.BL, logger *zap.Logger) *Controller {
+func NewController(bl *bl.BL, options config.OptionsSrv, logger *zap.Logger) *Controller {
 	logger = logger.Named("Handler")
-	return &Controller{Bl: bl, logger: logger}
+	return &Controller{Bl: bl, options: options, logger: logger}
 }
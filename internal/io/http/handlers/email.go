@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+)
+
+// SetEmail changes the email of the current user.
+//
+// @Summary Sets the email
+// @Description Changes the email of the authenticated user and mails a verification token to it. Password resets are sent only to verified emails.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer access token"
+// @Param body body models.EmailRequest true "Email"
+// @Success 200 {object} models.OkResponse "Verification token sent"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format or email"
+// @Failure 401 {object} models.ErrorResponse "Wrong bearer"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/user/email [post]
+func (c *Controller) SetEmail(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	var body models.EmailRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.Email) == 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	err = c.Bl.SetEmail(req.Context(), body.Email)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		switch {
+		case errors.Is(err, bl.ErrInvalidEmail):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, bl.ErrUserNotFound):
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "Verification token sent"})
+}
+
+// VerifyEmail confirms an email with the mailed token.
+//
+// @Summary Verifies the email
+// @Description Confirms the email with the token from the verification mail. The token can be used once.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param body body models.TokenRequest true "Verification token"
+// @Success 200 {object} models.OkResponse "Email verified"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format, invalid or expired token"
+// @Failure 409 {object} models.ErrorResponse "Email is verified by another user"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/user/email/verify [post]
+func (c *Controller) VerifyEmail(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	var body models.TokenRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.Token) == 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	err = c.Bl.VerifyEmail(body.Token)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		switch {
+		case errors.Is(err, bl.ErrInvalidUserToken):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, bl.ErrEmailTaken):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "Email verified"})
+}
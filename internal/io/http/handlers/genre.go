@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+)
+
+// GetGenres получает все жанры.
+//
+// @Summary Получает все жанры
+// @Description Получает список всех жанров, отсортированный по названию. При включенном гостевом доступе токен не обязателен.
+// @Tags Genres
+// @Produce  json
+// @Param Authorization header string false "Bearer"
+// @Security bearerAuth
+// @Success 200 {array} models.Genre "Список жанров"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 429 {object} models.ErrorResponse "Превышен лимит запросов без токена, см. заголовок Retry-After"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /api/genre [get]
+func (c *Controller) GetGenres(w http.ResponseWriter, req *http.Request) {
+	genres, err := c.Bl.GetGenres()
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText("internal error", w)
+		return
+	}
+	ioutils.RespJson(w, genres)
+}
+
+// CreateGenre создает новый жанр.
+//
+// @Summary Создает новый жанр
+// @Description Создает жанр с указанным названием, название хранится в нижнем регистре.
+// @Tags Genres
+// @Accept  json
+// @Produce  json
+// @Param body body models.GenreCreateRequest true "Название жанра"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.Genre "Созданный жанр"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения genre:manage"
+// @Failure 409 {object} models.ErrorResponse "Жанр уже существует"
+// @Router /api/genre [post]
+func (c *Controller) CreateGenre(w http.ResponseWriter, req *http.Request) {
+	var body models.GenreCreateRequest
+	if err := ioutils.DecodeRequestBody(req, &body); err != nil {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	genre, err := c.Bl.CreateGenre(req.Context(), body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respGenreError(w, err)
+		return
+	}
+	ioutils.RespJson(w, genre)
+}
+
+// SetMovieGenres назначает жанры фильму.
+//
+// @Summary Назначает жанры фильму
+// @Description Заменяет жанры фильма перечисленными, пустой список снимает все жанры. Жанры должны быть созданы заранее.
+// @Tags Genres
+// @Accept  json
+// @Produce  json
+// @Param body body models.MovieGenresRequest true "ID фильма и названия жанров"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {array} models.Genre "Жанры фильма"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат данных или неизвестный жанр"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения genre:manage"
+// @Failure 404 {object} models.ErrorResponse "Фильм не найден"
+// @Router /api/movie/genres [put]
+func (c *Controller) SetMovieGenres(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPut {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+	var body models.MovieGenresRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || body.MovieID <= 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	genres, err := c.Bl.SetMovieGenres(req.Context(), body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respGenreError(w, err)
+		return
+	}
+	ioutils.RespJson(w, genres)
+}
+
+func (c *Controller) respGenreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bl.ErrInvalidGenre), errors.Is(err, bl.ErrGenreNotFound):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, bl.ErrMovieNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, bl.ErrGenreExists):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, bl.ErrAuditFailed):
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText("internal error", w)
+		return
+	}
+	ioutils.RespErrorText(err.Error(), w)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+)
+
+// GetLockouts lists blocked logins and client addresses.
+//
+// @Summary Lists login lockouts
+// @Description Lists the logins (scope "login") and client addresses (scope "ip") that are blocked after failed login attempts.
+// @Tags Admin
+// @Produce  json
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {array} models.LockoutIo "Lockouts"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения user:manage"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/admin/lockouts [get]
+func (c *Controller) GetLockouts(w http.ResponseWriter, req *http.Request) {
+	lockouts, err := c.Bl.GetLockouts()
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	ioutils.RespJson(w, lockouts)
+}
+
+// Unlock lifts a login lockout.
+//
+// @Summary Unlocks a login or a client address
+// @Description Lifts the block and resets the failed attempts of the login or the client address. Exactly one of the parameters is required.
+// @Tags Admin
+// @Produce  json
+// @Param login query string false "Login"
+// @Param ip query string false "Client address"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.OkResponse "Unlocked"
+// @Failure 400 {object} models.ErrorResponse "Neither or both of login and ip"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения user:manage"
+// @Failure 404 {object} models.ErrorResponse "No failed attempts recorded"
+// @Router /api/admin/lockouts [delete]
+func (c *Controller) Unlock(w http.ResponseWriter, req *http.Request) {
+	login, ip := req.URL.Query().Get("login"), req.URL.Query().Get("ip")
+	if (len(login) > 0) == (len(ip) > 0) {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("one of login or ip is required", w)
+		return
+	}
+	scope, subject := repo.LoginScopeLogin, login
+	if len(ip) > 0 {
+		scope, subject = repo.LoginScopeIP, ip
+	}
+
+	err := c.Bl.Unlock(scope, subject)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		if errors.Is(err, bl.ErrLockoutNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "Unlocked"})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+)
+
+// GetMe returns the profile of the current user.
+//
+// @Summary Returns the own profile
+// @Description Returns the profile of the authenticated user.
+// @Tags Users
+// @Produce  json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} models.ProfileIo "Profile"
+// @Failure 401 {object} models.ErrorResponse "Wrong bearer"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/me [get]
+func (c *Controller) GetMe(w http.ResponseWriter, req *http.Request) {
+	profile, err := c.Bl.GetProfile(req.Context())
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respMeError(w, err)
+		return
+	}
+	ioutils.RespJson(w, profile)
+}
+
+// UpdateMe changes the display data of the current user.
+//
+// @Summary Updates the own profile
+// @Description Changes the display name of the authenticated user, an empty name clears it. The email is changed with /api/user/email.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer access token"
+// @Param body body models.ProfileUpdateRequest true "Profile data"
+// @Success 200 {object} models.ProfileIo "Updated profile"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format or display name"
+// @Failure 401 {object} models.ErrorResponse "Wrong bearer"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/me [patch]
+func (c *Controller) UpdateMe(w http.ResponseWriter, req *http.Request) {
+	var body models.ProfileUpdateRequest
+	if err := ioutils.DecodeRequestBody(req, &body); err != nil {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	profile, err := c.Bl.UpdateProfile(req.Context(), body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respMeError(w, err)
+		return
+	}
+	ioutils.RespJson(w, profile)
+}
+
+// DeleteMe deletes the account of the current user.
+//
+// @Summary Deletes the own account
+// @Description Deletes the authenticated user after the password is confirmed, together with sessions, API keys issued by the user, the second factor and linked external accounts. The audit log keeps the login of past changes. Users created by an external login set a password with a password reset first.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer access token"
+// @Param body body models.AccountDeleteRequest true "Password"
+// @Success 200 {object} models.OkResponse "Deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format"
+// @Failure 401 {object} models.ErrorResponse "Wrong bearer or password"
+// @Failure 409 {object} models.ErrorResponse "The last administrator cannot be deleted"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/me [delete]
+func (c *Controller) DeleteMe(w http.ResponseWriter, req *http.Request) {
+	var body models.AccountDeleteRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.Pass) == 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	if err := c.Bl.DeleteAccount(req.Context(), body); err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respMeError(w, err)
+		return
+	}
+	if c.cookieSession(req) {
+		c.clearSessionCookies(w)
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "Account deleted"})
+}
+
+// ExportMe returns everything stored about the current user.
+//
+// @Summary Exports the own data
+// @Description Returns the profile, sessions, API keys, linked external accounts and the audit events of the authenticated user as a JSON download. Password, token hashes and the second factor secret are not exported.
+// @Tags Users
+// @Produce  json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} models.UserExport "Stored data"
+// @Failure 401 {object} models.ErrorResponse "Wrong bearer"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/me/export [get]
+func (c *Controller) ExportMe(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	export, err := c.Bl.ExportUserData(req.Context())
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respMeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="user-data.json"`)
+	ioutils.RespJson(w, export)
+}
+
+func (c *Controller) respMeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bl.ErrInvalidDisplayName):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, bl.ErrUserNotFound), errors.Is(err, bl.ErrWrongPassword):
+		w.WriteHeader(http.StatusUnauthorized)
+	case errors.Is(err, bl.ErrUserDisabled):
+		w.WriteHeader(http.StatusForbidden)
+	case errors.Is(err, bl.ErrLastAdmin):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText("internal error", w)
+		return
+	}
+	ioutils.RespErrorText(err.Error(), w)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"math"
+	"net/http"
+	"strconv"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+)
+
+// LoginMfa completes a login with the second factor.
+//
+// @Summary Completes a two-factor login
+// @Description Exchanges the mfa token returned by /api/login and a TOTP code or a recovery code for the tokens. Each code can be used once, wrong codes count as failed logins.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param body body models.MfaLoginRequest true "Mfa token and code"
+// @Param session query string false "cookie: set the tokens as HttpOnly cookies, the body carries the CSRF token"
+// @Success 200 {object} models.TokenResponse "Generated access and refresh tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format"
+// @Failure 401 {object} models.ErrorResponse "Invalid mfa token or code"
+// @Failure 403 {object} models.ErrorResponse "User is disabled"
+// @Failure 429 {object} models.ErrorResponse "Too many failed attempts, see the Retry-After header"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/login/mfa [post]
+func (c *Controller) LoginMfa(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	var body models.MfaLoginRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.MfaToken) == 0 || (len(body.Code) == 0 && len(body.RecoveryCode) == 0) {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	tokens, err := c.Bl.CompleteMfaLogin(body, ioutils.ClientIP(req))
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		var throttled *bl.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			w.WriteHeader(http.StatusTooManyRequests)
+			ioutils.RespErrorText(err.Error(), w)
+		case errors.Is(err, bl.ErrInvalidMfaToken), errors.Is(err, bl.ErrInvalidMfaCode), errors.Is(err, bl.ErrMfaNotEnrolled):
+			w.WriteHeader(http.StatusUnauthorized)
+			ioutils.RespErrorText(err.Error(), w)
+		case errors.Is(err, bl.ErrUserDisabled):
+			w.WriteHeader(http.StatusForbidden)
+			ioutils.RespErrorText(err.Error(), w)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			ioutils.RespErrorText("internal error", w)
+		}
+		return
+	}
+	c.respTokens(w, req, tokens)
+}
+
+// EnrollMfa starts the two-factor enrollment of the current user.
+//
+// @Summary Starts a two-factor enrollment
+// @Description Generates a TOTP secret and the otpauth URI for an authenticator app. The second factor is enabled after a code is confirmed. Users whose role requires a second factor can call only the enrollment endpoints until they enroll.
+// @Tags Users
+// @Produce  json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} models.MfaEnrollResponse "Secret and URI"
+// @Failure 401 {object} models.ErrorResponse "Wrong bearer"
+// @Failure 409 {object} models.ErrorResponse "Already enabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/user/mfa/enroll [post]
+func (c *Controller) EnrollMfa(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	enrollment, err := c.Bl.StartMfaEnrollment(req.Context())
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respMfaError(w, err)
+		return
+	}
+	ioutils.RespJson(w, enrollment)
+}
+
+// ConfirmMfa enables the second factor of the current user.
+//
+// @Summary Confirms a two-factor enrollment
+// @Description Enables the second factor with a code from the authenticator app. Returns the recovery codes, they are shown only once. Other sessions are revoked and a new token pair is returned.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer access token"
+// @Param body body models.MfaCodeRequest true "Code"
+// @Success 200 {object} models.MfaEnabledResponse "Recovery codes and new tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format, invalid code or no enrollment started"
+// @Failure 401 {object} models.ErrorResponse "Wrong bearer"
+// @Failure 409 {object} models.ErrorResponse "Already enabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/user/mfa/confirm [post]
+func (c *Controller) ConfirmMfa(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	var body models.MfaCodeRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.Code) == 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	enabled, err := c.Bl.ConfirmMfaEnrollment(req.Context(), body.Code)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respMfaError(w, err)
+		return
+	}
+	if c.cookieSession(req) {
+		enabled.Tokens, err = c.setSessionCookies(w, enabled.Tokens)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			ioutils.RespErrorText("internal error", w)
+			return
+		}
+	}
+	ioutils.RespJson(w, enabled)
+}
+
+// DisableMfa removes the second factor of the current user.
+//
+// @Summary Disables two-factor authentication
+// @Description Removes the second factor after checking a TOTP code or a recovery code. Not allowed when the role requires a second factor.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer access token"
+// @Param body body models.MfaCodeRequest true "Code or recovery code"
+// @Success 200 {object} models.OkResponse "Disabled"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format, invalid code or not enabled"
+// @Failure 401 {object} models.ErrorResponse "Wrong bearer"
+// @Failure 403 {object} models.ErrorResponse "Mandatory for the role"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/user/mfa [delete]
+func (c *Controller) DisableMfa(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodDelete {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	var body models.MfaCodeRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || (len(body.Code) == 0 && len(body.RecoveryCode) == 0) {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	err = c.Bl.DisableMfa(req.Context(), body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respMfaError(w, err)
+		return
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "Two-factor authentication disabled"})
+}
+
+func (c *Controller) respMfaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bl.ErrInvalidMfaCode), errors.Is(err, bl.ErrMfaNotEnrolled):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, bl.ErrMfaAlreadyEnabled):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, bl.ErrMfaMandatory):
+		w.WriteHeader(http.StatusForbidden)
+	case errors.Is(err, bl.ErrUserNotFound):
+		w.WriteHeader(http.StatusUnauthorized)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText("internal error", w)
+		return
+	}
+	ioutils.RespErrorText(err.Error(), w)
+}
@@ -1,30 +1,147 @@
 package handlers
 
 import (
+	"errors"
 	"go.uber.org/zap"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"vk-inter-test-go/internal/bl"
 	"vk-inter-test-go/internal/io/ioutils"
 	"vk-inter-test-go/internal/io/models"
-	utilsJwt "vk-inter-test-go/internal/utils"
 )
 
 func (c *Controller) GlobalMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.logger.Info("", zap.Reflect("req", r.URL))
-		next.ServeHTTP(w, r)
+		c.setCorsHeaders(w, r)
+		if r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) > 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		requestID := ioutils.RequestID(r)
+		w.Header().Set("X-Request-ID", requestID)
+		c.logger.Info("", zap.Reflect("req", r.URL), zap.String("request", requestID))
+		info := bl.RequestInfo{ID: requestID, IP: ioutils.ClientIP(r)}
+		next.ServeHTTP(w, r.WithContext(bl.WithRequestInfo(r.Context(), info)))
 	})
 }
 
+// setCorsHeaders allows any origin without credentials, unless an allow-list
+// is configured: then only the listed origins may call the API, with cookies.
+func (c *Controller) setCorsHeaders(w http.ResponseWriter, r *http.Request) {
+	if len(c.options.CorsOrigins) == 0 {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if len(origin) == 0 || !slices.Contains(c.options.CorsOrigins, origin) {
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID, X-CSRF-Token")
+	w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
+}
+
+// bearerToken reads the token from the "Authorization: Bearer <token>" header.
+// The bare "Bearer" header is still accepted as a deprecated fallback.
+func (c *Controller) bearerToken(w http.ResponseWriter, r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > 0 {
+		scheme, token, ok := strings.Cut(auth, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(token)
+	}
+	if token := r.Header.Get("Bearer"); len(token) > 0 {
+		c.logger.Warn("deprecated Bearer header", zap.String("path", r.URL.Path))
+		w.Header().Set("Deprecation", "true")
+		return token
+	}
+	return ""
+}
+
+// AuthMiddleware accepts an API key in the X-API-Key header, a bearer token or,
+// in cookie session mode, the access token cookie together with the CSRF token.
 func (c *Controller) AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return c.authMiddleware(next)
+}
+
+// PasswordChangeAuthMiddleware also admits principals that still have to change
+// their password. It guards the password change endpoint only.
+func (c *Controller) PasswordChangeAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return c.authMiddleware(next, bl.RestrictionPasswordChange)
+}
+
+// MfaEnrollAuthMiddleware also admits principals that still have to enroll a
+// second factor. It guards the enrollment endpoints only.
+func (c *Controller) MfaEnrollAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return c.authMiddleware(next, bl.RestrictionMfaEnrollment)
+}
+
+// GuestAuthMiddleware admits GET requests without any credentials as the guest
+// principal when guest access is enabled. Other requests are authenticated by
+// AuthMiddleware, so writes still need a user or an API key.
+func (c *Controller) GuestAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	authenticated := c.authMiddleware(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !c.options.GuestAccess || r.Method != http.MethodGet || hasCredentials(r) {
+			authenticated(w, r)
+			return
+		}
+		principal, err := c.Bl.AuthenticateGuest(ioutils.ClientIP(r))
+		if err != nil {
+			c.logger.Info("err", zap.Error(err))
+			var limited *bl.GuestRateLimitedError
+			if errors.As(err, &limited) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+				w.WriteHeader(http.StatusTooManyRequests)
+			} else {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+			ioutils.RespErrorText(err.Error(), w)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(bl.WithPrincipal(r.Context(), principal)))
+	}
+}
+
+// hasCredentials reports whether the request presents any kind of credentials,
+// such requests are never downgraded to the guest.
+func hasCredentials(r *http.Request) bool {
+	for _, header := range []string{"X-API-Key", "Authorization", "Bearer"} {
+		if len(r.Header.Get(header)) > 0 {
+			return true
+		}
+	}
+	return hasCookie(r, accessCookie)
+}
+
+// authMiddleware rejects restricted principals unless the restriction is allowed.
+func (c *Controller) authMiddleware(next http.HandlerFunc, allowed ...string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		c.logger.Info("authMiddleware", zap.Reflect("header", r.Header))
-		if c.Bl.CheckJwt(r.Header.Get("Bearer")) {
-			next.ServeHTTP(w, r)
+		c.logger.Info("authMiddleware", zap.String("path", r.URL.Path))
+		var principal bl.Principal
+		var err error
+		if key := r.Header.Get("X-API-Key"); len(key) > 0 {
+			principal, err = c.Bl.AuthenticateApiKey(key)
+		} else if c.cookieSession(r) {
+			if !validCsrf(r) {
+				respCsrfError(w)
+				return
+			}
+			principal, err = c.Bl.Authenticate(cookieValue(r, accessCookie))
 		} else {
+			principal, err = c.Bl.Authenticate(c.bearerToken(w, r))
+		}
+		if err != nil {
+			c.logger.Info("err", zap.Error(err))
 			w.WriteHeader(http.StatusUnauthorized)
 			answer := models.ErrorResponse{
 				Error: "wrong bearer",
@@ -32,26 +149,40 @@ func (c *Controller) AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			ioutils.RespJson(w, answer)
 			return
 		}
+		if restriction := principal.Restriction(); len(restriction) > 0 && !slices.Contains(allowed, restriction) {
+			w.WriteHeader(http.StatusForbidden)
+			answer := models.ErrorResponse{
+				Error: restriction,
+			}
+			ioutils.RespJson(w, answer)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(bl.WithPrincipal(r.Context(), principal)))
 	}
 }
 
 func (c *Controller) RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		c.logger.Info("CheckRole")
-		bearer := r.Header.Get("Bearer")
-		userName, err := utilsJwt.ExtractUsernameFromToken(bearer)
-		if err != nil {
-			w.WriteHeader(http.StatusConflict)
+		principal, ok := c.Bl.CheckRole(r.Context(), role)
+		if ok {
+			next.ServeHTTP(w, r.WithContext(bl.WithPrincipal(r.Context(), principal)))
+		} else {
+			w.WriteHeader(http.StatusForbidden)
 			answer := models.ErrorResponse{
-				Error: "Token Extract Error :" + err.Error(),
+				Error: "Access denied",
 			}
 			ioutils.RespJson(w, answer)
 			return
 		}
-		if c.Bl.CheckRole(userName, role) {
-			next.ServeHTTP(w, r)
+	}
+}
 
-		} else {
+func (c *Controller) RequirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.logger.Info("CheckPermission", zap.String("permission", permission))
+		principal, ok := c.Bl.CheckPermission(r.Context(), permission)
+		if !ok {
 			w.WriteHeader(http.StatusForbidden)
 			answer := models.ErrorResponse{
 				Error: "Access denied",
@@ -59,5 +190,6 @@ func (c *Controller) RequireRole(role string, next http.HandlerFunc) http.Handle
 			ioutils.RespJson(w, answer)
 			return
 		}
+		next.ServeHTTP(w, r.WithContext(bl.WithPrincipal(r.Context(), principal)))
 	}
 }
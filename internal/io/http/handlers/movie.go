@@ -4,7 +4,6 @@ import (
 	"go.uber.org/zap"
 	"net/http"
 	"strconv"
-	"time"
 	"vk-inter-test-go/internal/db/repo"
 	"vk-inter-test-go/internal/io/ioutils"
 	"vk-inter-test-go/internal/io/models"
@@ -13,7 +12,7 @@ import (
 // CreateMovie создает новый фильм.
 //
 // @Summary Создает новый фильм
-// @Description Создает новый фильм с данными, предоставленными в теле запроса.
+// @Description Создает новый фильм с данными, предоставленными в теле запроса. Съемочная группа передается в crew с должностью job: director, writer, producer или composer; неизвестные люди создаются. Жанры из тела запроса не сохраняются, они назначаются через /api/movie/genres.
 // @Tags Movies
 // @Accept  json
 // @Produce  json
@@ -23,20 +22,23 @@ import (
 // @Success 200 {object} models.MovieIo "Успешно созданный фильм"
 // @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
 // @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
-// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимой роли"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимого разрешения"
 // @Router /api/movie [post]
 func (c *Controller) CreateMovie(w http.ResponseWriter, req *http.Request) {
 
 	var movie models.MovieIo
 	err := ioutils.DecodeRequestBody(req, &movie)
-	if err != nil || !ioutils.MovieJsonValidate(&movie) {
+	if err != nil || !ioutils.MovieJsonValidate(movie) {
 		ioutils.HandleInvalidJson(w)
 		return
 	}
 
 	var answer interface{}
 
-	movieIo, err := c.Bl.CreateMovie(movie)
+	movieIo, err := c.Bl.CreateMovie(req.Context(), movie)
+	if c.respAuditFailed(w, err) {
+		return
+	}
 	if err != nil {
 		c.logger.Info("err", zap.Error(err))
 		w.WriteHeader(http.StatusBadRequest)
@@ -62,7 +64,7 @@ func (c *Controller) CreateMovie(w http.ResponseWriter, req *http.Request) {
 // @Success 200 {object} models.OkResponse "Успешное удаление фильма"
 // @Failure 400 {object} models.ErrorResponse "Неверное значение ID или ошибка удаления"
 // @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
-// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимой роли"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимого разрешения"
 // @Router /api/movie [delete]
 func (c *Controller) DeleteMovie(w http.ResponseWriter, req *http.Request) {
 	idStr := req.URL.Query().Get("id")
@@ -72,7 +74,10 @@ func (c *Controller) DeleteMovie(w http.ResponseWriter, req *http.Request) {
 		ioutils.RespErrorText("Не верное значение ID", w)
 		return
 	}
-	rows, err := c.Bl.DeleteMovie(id)
+	rows, err := c.Bl.DeleteMovie(req.Context(), id)
+	if c.respAuditFailed(w, err) {
+		return
+	}
 	if err != nil || rows == 0 {
 		w.WriteHeader(http.StatusBadRequest)
 		ioutils.RespErrorText("Ошибка удаления", w)
@@ -91,26 +96,24 @@ func (c *Controller) DeleteMovie(w http.ResponseWriter, req *http.Request) {
 // @Tags Movies
 // @Accept  json
 // @Produce  json
-// @Param body body repo.Movie true "Данные фильма для обновления"
+// @Param body body models.Movie true "Данные фильма для обновления"
 // @Param Authorization header string true "Bearer"
 // @Security bearerAuth
-// @Success 200 {object} repo.Movie "Обновленные данные фильма"
+// @Success 200 {object} models.Movie "Обновленные данные фильма"
 // @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
 // @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
-// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимой роли"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: отсутствие необходимого разрешения"
 // @Failure 404 {object} models.ErrorResponse "Фильм не найден"
 // @Router /api/movie [put]
 func (c *Controller) UpdateMovie(w http.ResponseWriter, req *http.Request) {
-	var movie repo.Movie
-	err := ioutils.DecodeRequestBody(req, &movie)
+	var body models.Movie
+	err := ioutils.DecodeRequestBody(req, &body)
 
-	if len(movie.ReleaseDateJson) > 0 {
-		movie.ReleaseDate, err = time.Parse("2006-01-02", movie.ReleaseDateJson)
-		if err != nil {
-			c.logger.Info("err :", zap.Error(err))
-			ioutils.HandleInvalidJson(w)
-			return
-		}
+	movie, err := body.ToRepo()
+	if err != nil {
+		c.logger.Info("err :", zap.Error(err))
+		ioutils.HandleInvalidJson(w)
+		return
 	}
 
 	if movie.Rating < 0 || movie.Rating > 10 {
@@ -119,7 +122,10 @@ func (c *Controller) UpdateMovie(w http.ResponseWriter, req *http.Request) {
 	}
 
 	var answer interface{}
-	movie, err = c.Bl.UpdateMovie(movie)
+	movie, err = c.Bl.UpdateMovie(req.Context(), movie)
+	if c.respAuditFailed(w, err) {
+		return
+	}
 	if err != nil {
 		c.logger.Info("err", zap.Error(err))
 		w.WriteHeader(http.StatusNotFound)
@@ -127,23 +133,24 @@ func (c *Controller) UpdateMovie(w http.ResponseWriter, req *http.Request) {
 			Error: "err : '" + err.Error() + "'",
 		}
 	} else {
-		movie.ReleaseDateJson = movie.ReleaseDate.Format("2006-01-02")
-		answer = movie
+		answer = models.MovieFromRepo(movie)
 	}
 	c.logger.Info("resp : ", zap.Reflect("answer :", answer))
 
 	ioutils.RespJson(w, answer)
 }
 
-// GetMovies получает все фильмы или фильмы с определенным заголовком или именем актера.
+// GetMovies получает все фильмы или фильмы с определенным заголовком, именем актера, режиссера или жанром.
 //
-// @Summary Получает все фильмы или фильмы с определенным заголовком или именем актера
-// @Description Получает все фильмы, если ни один из параметров не указан, или фильмы с определенным заголовком или именем актера.
+// @Summary Получает все фильмы или фильмы с определенным заголовком, именем актера, режиссера или жанром
+// @Description Получает все фильмы, если ни один из параметров не указан, или фильмы, подходящие под все указанные параметры. При включенном гостевом доступе токен не обязателен.
 // @Tags Movies
 // @Param title query string false "Заголовок фильма для фильтрации"
 // @Param name query string false "Имя актера для фильтрации"
-// @Param sort query string false "Поле для сортировки, Доступные значения: 'rating', 'title', 'date'"
-// @Param Authorization header string true "Bearer"
+// @Param genre query string false "Жанр для фильтрации"
+// @Param director query string false "Имя режиссера для фильтрации"
+// @Param sort query string false "Поле для сортировки, Доступные значения: 'rating', 'title', 'date', 'score' (средняя оценка пользователей), 'votes' (число оценок)"
+// @Param Authorization header string false "Bearer"
 // @Security bearerAuth
 // @Accept  json
 // @Produce  json
@@ -151,28 +158,19 @@ func (c *Controller) UpdateMovie(w http.ResponseWriter, req *http.Request) {
 // @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
 // @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
 // @Failure 404 {object} models.ErrorResponse "Фильмы не найден"
+// @Failure 429 {object} models.ErrorResponse "Превышен лимит запросов без токена, см. заголовок Retry-After"
 // @Router /api/movie [get]
 func (c *Controller) GetMovies(w http.ResponseWriter, req *http.Request) {
-	title := req.URL.Query().Get("title")
-	name := req.URL.Query().Get("name")
-	orderBy := req.URL.Query().Get("sort")
-
-	if len(name) != 0 {
-		movieIo, err := c.Bl.GetAllMoviesByNameActor(name, orderBy)
-		if err != nil {
-			ioutils.RespErrorText(err.Error(), w)
-			return
-		}
-		if len(movieIo) == 0 {
-			w.WriteHeader(http.StatusNotFound)
-			answer := models.ErrorResponse{Error: "не найдено ни одного фильма по заданным параметрам"}
-			ioutils.RespJson(w, answer)
-			return
-		}
-		ioutils.RespJson(w, movieIo)
-		return
+	query := req.URL.Query()
+	filter := repo.MovieFilter{
+		Title:    query.Get("title"),
+		Actor:    query.Get("name"),
+		Genre:    query.Get("genre"),
+		Director: query.Get("director"),
 	}
-	movieIo, err := c.Bl.GetAllMoviesByTitle(title, orderBy)
+	orderBy := query.Get("sort")
+
+	movieIo, err := c.Bl.GetMovies(filter, orderBy)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		ioutils.RespErrorText(err.Error(), w)
@@ -186,6 +184,4 @@ func (c *Controller) GetMovies(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 	ioutils.RespJson(w, movieIo)
-	return
-
 }
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/utils"
+)
+
+// oidcStateCookie binds a login to the browser that started it, it holds the
+// hash of the state and is checked by the callback.
+const (
+	oidcStateCookie = "oidc_state"
+	oidcStatePath   = "/api/login/oidc"
+)
+
+func (c *Controller) oidcStateCookie(value string, maxAge int) *http.Cookie {
+	cookie := c.cookie(oidcStateCookie, value, oidcStatePath, maxAge, true)
+	// The provider redirects back with a top-level cross-site navigation.
+	cookie.SameSite = http.SameSiteLaxMode
+	return cookie
+}
+
+// OidcLogin redirects the browser to the external identity provider.
+//
+// @Summary Starts a login with the external identity provider
+// @Description Redirects to the OpenID Connect provider, which redirects back to /api/login/oidc/callback. The login uses the authorization code flow with PKCE, the HttpOnly oidc_state cookie binds it to the browser.
+// @Tags Users
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} models.ErrorResponse "External login is not configured"
+// @Failure 502 {object} models.ErrorResponse "Provider is unavailable"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/login/oidc [get]
+func (c *Controller) OidcLogin(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	uri, state, err := c.Bl.StartOidcLogin(req.Context())
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respOidcError(w, err)
+		return
+	}
+	http.SetCookie(w, c.oidcStateCookie(utils.HashToken(state), int(c.options.OidcStateTTL.Seconds())))
+	http.Redirect(w, req, uri, http.StatusFound)
+}
+
+// OidcCallback completes a login with the external identity provider.
+//
+// @Summary Completes a login with the external identity provider
+// @Description The provider redirects here with the authorization code. The local user is created on the first login, the role is mapped from the groups of the account. Users with a second factor get an mfa token for /api/login/mfa. Add session=cookie to the registered redirect url to get the tokens as cookies.
+// @Tags Users
+// @Produce  json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State of the login"
+// @Success 200 {object} models.TokenResponse "Generated access and refresh tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid or expired login, or the login was started by another browser"
+// @Failure 401 {object} models.ErrorResponse "The provider denied the login"
+// @Failure 403 {object} models.ErrorResponse "User is disabled"
+// @Failure 404 {object} models.ErrorResponse "External login is not configured"
+// @Failure 409 {object} models.ErrorResponse "Login is taken by a local user"
+// @Failure 502 {object} models.ErrorResponse "Provider is unavailable or its answer cannot be verified"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/login/oidc/callback [get]
+func (c *Controller) OidcCallback(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	binding := cookieValue(req, oidcStateCookie)
+	http.SetCookie(w, c.oidcStateCookie("", -1))
+
+	query := req.URL.Query()
+	if providerErr := query.Get("error"); len(providerErr) > 0 {
+		c.logger.Info("oidc provider error", zap.String("error", providerErr))
+		w.WriteHeader(http.StatusUnauthorized)
+		ioutils.RespErrorText("login denied by the provider: "+providerErr, w)
+		return
+	}
+	if len(query.Get("code")) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("code is required", w)
+		return
+	}
+
+	hash := utils.HashToken(query.Get("state"))
+	if len(binding) == 0 || subtle.ConstantTimeCompare([]byte(binding), []byte(hash)) != 1 {
+		c.respOidcError(w, bl.ErrInvalidOidcState)
+		return
+	}
+
+	tokens, err := c.Bl.CompleteOidcLogin(req.Context(), query.Get("code"), query.Get("state"))
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respOidcError(w, err)
+		return
+	}
+	c.respTokens(w, req, tokens)
+}
+
+func (c *Controller) respOidcError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bl.ErrOidcDisabled):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, bl.ErrInvalidOidcState):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, bl.ErrOidcLogin):
+		w.WriteHeader(http.StatusBadGateway)
+	case errors.Is(err, bl.ErrUserDisabled):
+		w.WriteHeader(http.StatusForbidden)
+	case errors.Is(err, bl.ErrOidcLoginTaken):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText("internal error", w)
+		return
+	}
+	ioutils.RespErrorText(err.Error(), w)
+}
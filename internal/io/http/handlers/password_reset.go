@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+)
+
+// RequestPasswordReset mails a password reset token.
+//
+// @Summary Requests a password reset
+// @Description Mails a single-use reset token to the user with the given verified email. The answer is the same whether such a user exists or not.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param body body models.EmailRequest true "Email"
+// @Success 200 {object} models.OkResponse "Request accepted"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/password/reset/request [post]
+func (c *Controller) RequestPasswordReset(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	var body models.EmailRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.Email) == 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	err = c.Bl.RequestPasswordReset(body.Email)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText("internal error", w)
+		return
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "If the email is registered and verified, a reset token has been sent"})
+}
+
+// ConfirmPasswordReset sets a new password with a reset token.
+//
+// @Summary Resets the password
+// @Description Sets a new password with the mailed reset token. The token can be used once, all sessions of the user are revoked.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param body body models.PasswordResetConfirmRequest true "Reset token and new password"
+// @Success 200 {object} models.OkResponse "Password changed"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format, invalid or expired token, weak password"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/password/reset/confirm [post]
+func (c *Controller) ConfirmPasswordReset(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	var body models.PasswordResetConfirmRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.Token) == 0 || len(body.NewPass) == 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	err = c.Bl.ConfirmPasswordReset(body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		if errors.Is(err, bl.ErrInvalidUserToken) || errors.Is(err, bl.ErrWeakPassword) {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "Password changed"})
+}
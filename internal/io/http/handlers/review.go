@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+)
+
+// CreateReview оценивает фильм.
+//
+// @Summary Оценивает фильм
+// @Description Сохраняет оценку фильма от 1 до 10 и необязательный отзыв текущего пользователя. Каждый пользователь оценивает фильм один раз, затем отзыв редактируется.
+// @Tags Reviews
+// @Accept  json
+// @Produce  json
+// @Param body body models.ReviewCreateRequest true "ID фильма, оценка и отзыв"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.ReviewIo "Созданный отзыв"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат данных, оценка или длина отзыва"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 404 {object} models.ErrorResponse "Фильм не найден"
+// @Failure 409 {object} models.ErrorResponse "Фильм уже оценен"
+// @Router /api/review [post]
+func (c *Controller) CreateReview(w http.ResponseWriter, req *http.Request) {
+	var body models.ReviewCreateRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || body.MovieID <= 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	review, err := c.Bl.CreateReview(req.Context(), body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respReviewError(w, err)
+		return
+	}
+	ioutils.RespJson(w, review)
+}
+
+// GetMyReviews получает отзывы текущего пользователя.
+//
+// @Summary Получает свои отзывы
+// @Description Получает оценки и отзывы текущего пользователя, последние первыми.
+// @Tags Reviews
+// @Produce  json
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {array} models.ReviewIo "Список отзывов"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /api/review [get]
+func (c *Controller) GetMyReviews(w http.ResponseWriter, req *http.Request) {
+	reviews, err := c.Bl.GetMyReviews(req.Context())
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respReviewError(w, err)
+		return
+	}
+	ioutils.RespJson(w, reviews)
+}
+
+// UpdateReview изменяет свой отзыв.
+//
+// @Summary Изменяет свой отзыв
+// @Description Изменяет оценку и текст отзыва текущего пользователя. Незаданные поля не меняются, пустой review удаляет текст.
+// @Tags Reviews
+// @Accept  json
+// @Produce  json
+// @Param body body models.ReviewUpdateRequest true "ID отзыва, оценка и отзыв"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.ReviewIo "Измененный отзыв"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат данных, оценка или длина отзыва"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 404 {object} models.ErrorResponse "Отзыв не найден"
+// @Router /api/review [patch]
+func (c *Controller) UpdateReview(w http.ResponseWriter, req *http.Request) {
+	var body models.ReviewUpdateRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || body.ID <= 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	review, err := c.Bl.UpdateReview(req.Context(), body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respReviewError(w, err)
+		return
+	}
+	ioutils.RespJson(w, review)
+}
+
+// DeleteReview удаляет свой отзыв.
+//
+// @Summary Удаляет свой отзыв
+// @Description Удаляет оценку и отзыв текущего пользователя с указанным ID.
+// @Tags Reviews
+// @Param id query integer true "ID отзыва"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.OkResponse "Отзыв удален"
+// @Failure 400 {object} models.ErrorResponse "Неверное значение ID"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 404 {object} models.ErrorResponse "Отзыв не найден"
+// @Router /api/review [delete]
+func (c *Controller) DeleteReview(w http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("Не верное значение ID", w)
+		return
+	}
+
+	if err := c.Bl.DeleteReview(req.Context(), id); err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respReviewError(w, err)
+		return
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "Запись удалена"})
+}
+
+func (c *Controller) respReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bl.ErrInvalidReview):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, bl.ErrUserNotFound):
+		w.WriteHeader(http.StatusUnauthorized)
+	case errors.Is(err, bl.ErrUserDisabled):
+		w.WriteHeader(http.StatusForbidden)
+	case errors.Is(err, bl.ErrMovieNotFound), errors.Is(err, bl.ErrReviewNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, bl.ErrReviewExists):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText("internal error", w)
+		return
+	}
+	ioutils.RespErrorText(err.Error(), w)
+}
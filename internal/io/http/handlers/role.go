@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/utils"
+)
+
+// GetRoles lists roles with their permissions.
+//
+// @Summary Lists roles
+// @Description Lists all roles with the permissions granted to them.
+// @Tags Admin
+// @Produce  json
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {array} models.Role "Roles"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения role:manage"
+// @Router /api/admin/roles [get]
+func (c *Controller) GetRoles(w http.ResponseWriter, req *http.Request) {
+	roles, err := c.Bl.GetRoles()
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	answer := make([]models.Role, 0, len(roles))
+	for _, role := range roles {
+		answer = append(answer, models.RoleFromRepo(role))
+	}
+	ioutils.RespJson(w, answer)
+}
+
+// CreateRole creates a role.
+//
+// @Summary Creates a role
+// @Description Creates a role with the given permissions.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param body body models.Role true "Role name and permissions"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.Role "Created role"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format, role exists or unknown permission"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения role:manage"
+// @Router /api/admin/roles [post]
+func (c *Controller) CreateRole(w http.ResponseWriter, req *http.Request) {
+	var body models.Role
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || !ioutils.RoleJsonValidate(body) {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+	if _, clean := utils.Sanitize(body.Name); !clean {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("имя содержит непотдерживаемые символы", w)
+		return
+	}
+
+	role, err := c.Bl.CreateRole(body.ToRepo())
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("err : '"+err.Error()+"'", w)
+		return
+	}
+	ioutils.RespJson(w, models.RoleFromRepo(role))
+}
+
+// UpdateRole replaces the permissions of a role.
+//
+// @Summary Replaces role permissions
+// @Description Replaces the permissions of the role with the given name. The admin role cannot be changed.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param body body models.Role true "Role name and permissions"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.Role "Updated role"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format, builtin role or unknown permission"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения role:manage"
+// @Router /api/admin/roles [put]
+func (c *Controller) UpdateRole(w http.ResponseWriter, req *http.Request) {
+	var body models.Role
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || !ioutils.RoleJsonValidate(body) {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	role, err := c.Bl.UpdateRolePermissions(body.ToRepo())
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("err : '"+err.Error()+"'", w)
+		return
+	}
+	ioutils.RespJson(w, models.RoleFromRepo(role))
+}
+
+// DeleteRole deletes a role.
+//
+// @Summary Deletes a role
+// @Description Deletes the role with the given name. Builtin roles and roles assigned to users cannot be deleted.
+// @Tags Admin
+// @Param name query string true "Role name"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.OkResponse "Role deleted"
+// @Failure 400 {object} models.ErrorResponse "Builtin role or role in use"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения role:manage"
+// @Failure 404 {object} models.ErrorResponse "Role not found"
+// @Router /api/admin/roles [delete]
+func (c *Controller) DeleteRole(w http.ResponseWriter, req *http.Request) {
+	name := req.URL.Query().Get("name")
+
+	rows, err := c.Bl.DeleteRole(name)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		if errors.Is(err, bl.ErrBuiltinRole) {
+			ioutils.RespErrorText(err.Error(), w)
+		} else {
+			ioutils.RespErrorText("role is in use", w)
+		}
+		return
+	}
+	if rows == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		ioutils.RespErrorText("role not found", w)
+		return
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "Role " + name + " deleted"})
+}
+
+// GetPermissions lists all known permissions.
+//
+// @Summary Lists permissions
+// @Description Lists the permissions that can be granted to roles.
+// @Tags Admin
+// @Produce  json
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {array} string "Permissions"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения role:manage"
+// @Router /api/admin/permissions [get]
+func (c *Controller) GetPermissions(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+	permissions, err := c.Bl.GetPermissions()
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	ioutils.RespJson(w, permissions)
+}
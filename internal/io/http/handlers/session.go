@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/utils"
+)
+
+// Cookie session mode keeps the tokens of browser clients out of JavaScript:
+// access and refresh tokens travel in HttpOnly cookies, requests authenticated
+// by cookie must repeat the readable CSRF cookie in the X-CSRF-Token header.
+const (
+	accessCookie  = "access_token"
+	refreshCookie = "refresh_token"
+	csrfCookie    = "csrf_token"
+	csrfHeader    = "X-CSRF-Token"
+)
+
+// cookieSession reports whether the tokens of the request travel in cookies:
+// the client asked for it with ?session=cookie or presents a session cookie
+// instead of a bearer.
+func (c *Controller) cookieSession(req *http.Request) bool {
+	if !c.options.SessionCookies {
+		return false
+	}
+	if req.URL.Query().Get("session") == "cookie" {
+		return true
+	}
+	if len(req.Header.Get("Authorization")) > 0 || len(req.Header.Get("Bearer")) > 0 {
+		return false
+	}
+	return hasCookie(req, accessCookie) || hasCookie(req, refreshCookie)
+}
+
+// respTokens answers with the tokens, in cookie session mode they are set as
+// cookies and only the CSRF token and the lifetime stay in the body.
+func (c *Controller) respTokens(w http.ResponseWriter, req *http.Request, tokens models.TokenResponse) {
+	if len(tokens.Bearer) == 0 || !c.cookieSession(req) {
+		ioutils.RespJson(w, tokens)
+		return
+	}
+	tokens, err := c.setSessionCookies(w, tokens)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText("internal error", w)
+		return
+	}
+	ioutils.RespJson(w, tokens)
+}
+
+// setSessionCookies moves the tokens into cookies and issues a new CSRF token.
+func (c *Controller) setSessionCookies(w http.ResponseWriter, tokens models.TokenResponse) (models.TokenResponse, error) {
+	csrf, err := utils.NewOpaqueToken()
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	http.SetCookie(w, c.cookie(accessCookie, tokens.Bearer, "/", int(tokens.ExpiresIn), true))
+	http.SetCookie(w, c.cookie(refreshCookie, tokens.Refresh, "/api", int(c.options.RefreshTTL.Seconds()), true))
+	http.SetCookie(w, c.cookie(csrfCookie, csrf, "/", int(c.options.RefreshTTL.Seconds()), false))
+	return models.TokenResponse{ExpiresIn: tokens.ExpiresIn, CsrfToken: csrf}, nil
+}
+
+func (c *Controller) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, c.cookie(accessCookie, "", "/", -1, true))
+	http.SetCookie(w, c.cookie(refreshCookie, "", "/api", -1, true))
+	http.SetCookie(w, c.cookie(csrfCookie, "", "/", -1, false))
+}
+
+func (c *Controller) cookie(name string, value string, path string, maxAge int, httpOnly bool) *http.Cookie {
+	sameSite := http.SameSiteStrictMode
+	switch c.options.CookieSameSite {
+	case "lax":
+		sameSite = http.SameSiteLaxMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   c.options.CookieDomain,
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   !c.options.CookieInsecure,
+		SameSite: sameSite,
+	}
+}
+
+// validCsrf checks the double-submitted CSRF token of unsafe requests.
+func validCsrf(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	cookie, err := req.Cookie(csrfCookie)
+	if err != nil || len(cookie.Value) == 0 {
+		return false
+	}
+	header := req.Header.Get(csrfHeader)
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) == 1
+}
+
+func hasCookie(req *http.Request, name string) bool {
+	cookie, err := req.Cookie(name)
+	return err == nil && len(cookie.Value) > 0
+}
+
+func cookieValue(req *http.Request, name string) string {
+	cookie, err := req.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+func respCsrfError(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusForbidden)
+	ioutils.RespErrorText("csrf token mismatch", w)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/utils"
+)
+
+// RefreshToken exchanges a refresh token for a new token pair.
+//
+// @Summary Refreshes the access token
+// @Description Exchanges a refresh token for a new access and refresh token. The presented refresh token is revoked; presenting it again revokes the whole session. In cookie session mode the refresh token is read from the cookie and the X-CSRF-Token header is required.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param body body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.TokenResponse "Generated tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format"
+// @Failure 401 {object} models.ErrorResponse "Invalid, expired or reused refresh token"
+// @Router /api/token/refresh [post]
+func (c *Controller) RefreshToken(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	refresh, ok := c.refreshFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	tokens, err := c.Bl.RefreshToken(refresh)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		if errors.Is(err, bl.ErrInvalidRefreshToken) || errors.Is(err, bl.ErrRefreshTokenReused) {
+			w.WriteHeader(http.StatusUnauthorized)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+
+	c.respTokens(w, req, tokens)
+}
+
+// Logout revokes the session of a refresh token.
+//
+// @Summary Logs out
+// @Description Revokes the refresh token and every token rotated from the same login. In cookie session mode the session cookies are cleared.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param body body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.OkResponse "Session revoked"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format"
+// @Failure 401 {object} models.ErrorResponse "Invalid refresh token"
+// @Router /api/logout [post]
+func (c *Controller) Logout(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	refresh, ok := c.refreshFromRequest(w, req)
+	if !ok {
+		return
+	}
+	if c.cookieSession(req) {
+		c.clearSessionCookies(w)
+	}
+
+	err := c.Bl.Logout(refresh)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		if errors.Is(err, bl.ErrInvalidRefreshToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+
+	ioutils.RespJson(w, models.OkResponse{Ok: "session revoked"})
+}
+
+// refreshFromRequest reads the refresh token from the cookie in cookie session
+// mode, checking the CSRF token, or from the body. It answers itself on failure.
+func (c *Controller) refreshFromRequest(w http.ResponseWriter, req *http.Request) (string, bool) {
+	if c.cookieSession(req) && hasCookie(req, refreshCookie) {
+		if !validCsrf(req) {
+			respCsrfError(w)
+			return "", false
+		}
+		return cookieValue(req, refreshCookie), true
+	}
+
+	var body models.RefreshRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.Refresh) == 0 {
+		ioutils.HandleInvalidJson(w)
+		return "", false
+	}
+	return body.Refresh, true
+}
+
+// JWKS publishes the public keys tokens are signed with.
+//
+// @Summary Public signing keys
+// @Description Returns the public keys in JWK Set format, so other services can verify issued tokens. The set is empty when tokens are signed with a shared HMAC secret.
+// @Tags Users
+// @Produce  json
+// @Success 200 {object} utils.JWKSet "JWK Set"
+// @Router /.well-known/jwks.json [get]
+func (c *Controller) JWKS(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	ioutils.RespJson(w, utils.PublicJWKS())
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"go.uber.org/zap"
+	"math"
 	"net/http"
+	"strconv"
+	"vk-inter-test-go/internal/bl"
 	"vk-inter-test-go/internal/db/repo"
 	"vk-inter-test-go/internal/io/ioutils"
 	"vk-inter-test-go/internal/io/models"
@@ -16,9 +20,10 @@ import (
 // @Tags Users
 // @Accept  json
 // @Produce  json
-// @Param body body repo.User true "User data"
-// @Success 200 {object} models.TokenResponse "Generated token"
-// @Failure 400 {object} models.ErrorResponse "Invalid data format or unsupported characters in the username"
+// @Param body body models.LoginRequest true "User data"
+// @Success 200 {object} models.TokenResponse "Generated access and refresh tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format, unsupported characters in the username or weak password"
+// @Failure 409 {object} models.ErrorResponse "Login already exists"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /api/create/user [post]
 func (c *Controller) CreateUser(w http.ResponseWriter, req *http.Request) {
@@ -27,80 +32,151 @@ func (c *Controller) CreateUser(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	var user repo.User
-	err := ioutils.DecodeRequestBody(req, &user)
-	if err != nil || !ioutils.UserJsonValidate(user) {
+	var body models.LoginRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || !ioutils.UserJsonValidate(body) {
 		ioutils.HandleInvalidJson(w)
 		return
 	}
-
-	var answer interface{}
+	user := repo.User{Login: body.Login, Pass: body.Pass}
 
 	var cleanInput bool
 	user.Login, cleanInput = utils.Sanitize(user.Login)
 	if !cleanInput {
-		answer = models.ErrorResponse{
-			Error: "имя содержит непотдерживаемые символы",
-		}
-	} else {
-		token, err := c.Bl.CreateUser(user)
-		if err != nil {
-			c.logger.Info("err", zap.Error(err))
-			answer = models.ErrorResponse{
-				Error: "user is exist: '" + user.Login + "'",
-			}
-		} else {
-			answer = models.TokenResponse{Bearer: token}
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("имя содержит непотдерживаемые символы", w)
+		return
+	}
+
+	tokens, err := c.Bl.CreateUser(user)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		switch {
+		case errors.Is(err, bl.ErrWeakPassword):
+			w.WriteHeader(http.StatusBadRequest)
+			ioutils.RespErrorText(err.Error(), w)
+		case errors.Is(err, bl.ErrUserExists):
+			w.WriteHeader(http.StatusConflict)
+			ioutils.RespErrorText("user is exist: '"+user.Login+"'", w)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			ioutils.RespErrorText("internal error", w)
 		}
+		return
 	}
 
-	ioutils.RespJson(w, answer)
+	c.respTokens(w, req, tokens)
 }
 
 // AuthUser authenticates a user.
 //
 // @Summary Authenticates a user
-// @Description Authenticates a user with the provided data in the request body.
+// @Description Authenticates a user with the provided data in the request body. GET with a body is deprecated, use POST. Users with two-factor authentication get mfaRequired and an mfaToken instead of the tokens, see /api/login/mfa.
 // @Tags Users
 // @Accept  json
 // @Produce  json
-// @Param body body repo.User true "User data"
-// @Success 200 {object} models.TokenResponse "Generated token"
+// @Param body body models.LoginRequest true "User data"
+// @Param session query string false "cookie: set the tokens as HttpOnly cookies, the body carries the CSRF token"
+// @Success 200 {object} models.TokenResponse "Generated access and refresh tokens or the mfa token"
 // @Failure 400 {object} models.ErrorResponse "Invalid data format or unsupported characters in the username"
+// @Failure 401 {object} models.ErrorResponse "Wrong login or password"
+// @Failure 403 {object} models.ErrorResponse "User is disabled"
+// @Failure 429 {object} models.ErrorResponse "Too many failed attempts, see the Retry-After header"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/login [post]
 // @Router /api/login [get]
 func (c *Controller) AuthUser(w http.ResponseWriter, req *http.Request) {
-	if req.Method != http.MethodGet {
+	switch req.Method {
+	case http.MethodPost:
+	case http.MethodGet:
+		c.logger.Warn("deprecated GET /api/login")
+		w.Header().Set("Deprecation", "true")
+	default:
 		ioutils.HandleInvalidMethodResponse(w, req.Method)
 		return
 	}
-	var user repo.User
-	err := ioutils.DecodeRequestBody(req, &user)
-	if err != nil || !ioutils.UserJsonValidate(user) {
+	var body models.LoginRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || !ioutils.UserJsonValidate(body) {
 		ioutils.HandleInvalidJson(w)
 		return
 	}
-
-	var answer interface{}
+	user := repo.User{Login: body.Login, Pass: body.Pass}
 
 	var cleanInput bool
 	user.Login, cleanInput = utils.Sanitize(user.Login)
 	if !cleanInput {
-		answer = models.ErrorResponse{
-			Error: "имя содержит непотдерживаемые символы",
-		}
-	} else {
-		token, err := c.Bl.AuthUser(user)
-		if err != nil {
-			c.logger.Info("err", zap.Error(err))
-			answer = models.ErrorResponse{
-				Error: "wrong pass",
-			}
-		} else {
-			answer = models.TokenResponse{Bearer: token}
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("имя содержит непотдерживаемые символы", w)
+		return
+	}
+
+	tokens, err := c.Bl.AuthUser(user, ioutils.ClientIP(req))
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		var throttled *bl.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			w.WriteHeader(http.StatusTooManyRequests)
+			ioutils.RespErrorText(err.Error(), w)
+		case errors.Is(err, bl.ErrWrongPassword):
+			w.WriteHeader(http.StatusUnauthorized)
+			ioutils.RespErrorText("wrong pass", w)
+		case errors.Is(err, bl.ErrUserDisabled):
+			w.WriteHeader(http.StatusForbidden)
+			ioutils.RespErrorText(err.Error(), w)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			ioutils.RespErrorText("internal error", w)
 		}
+		return
+	}
+
+	c.respTokens(w, req, tokens)
+}
+
+// ChangePassword changes the password of the current user.
+//
+// @Summary Changes the password
+// @Description Replaces the password of the authenticated user. Other sessions are revoked and a new token pair is returned. Users created with a temporary password can call only this endpoint until they change it.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer access token"
+// @Param body body models.PasswordChangeRequest true "Old and new password"
+// @Success 200 {object} models.TokenResponse "Generated access and refresh tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format or the new password does not meet the policy"
+// @Failure 401 {object} models.ErrorResponse "Wrong bearer or wrong old password"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /api/user/password [post]
+func (c *Controller) ChangePassword(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ioutils.HandleInvalidMethodResponse(w, req.Method)
+		return
+	}
+
+	var body models.PasswordChangeRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.OldPass) == 0 || len(body.NewPass) == 0 {
+		ioutils.HandleInvalidJson(w)
+		return
 	}
 
-	ioutils.RespJson(w, answer)
+	tokens, err := c.Bl.ChangePassword(req.Context(), body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		switch {
+		case errors.Is(err, bl.ErrWrongPassword), errors.Is(err, bl.ErrUserNotFound):
+			w.WriteHeader(http.StatusUnauthorized)
+		case errors.Is(err, bl.ErrWeakPassword):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
 
+	c.respTokens(w, req, tokens)
 }
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/utils"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// GetUsers lists users page by page, or returns a single user by ID.
+//
+// @Summary Lists users
+// @Description Lists users whose login contains the search string, page by page. With the id parameter returns a single user.
+// @Tags Admin
+// @Produce  json
+// @Param id query integer false "User ID"
+// @Param search query string false "Part of the login"
+// @Param page query integer false "Page number, starting from 1"
+// @Param limit query integer false "Page size, 20 by default, at most 100"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.UserPage "Users"
+// @Failure 400 {object} models.ErrorResponse "Invalid paging parameters"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения user:manage"
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Router /api/admin/users [get]
+func (c *Controller) GetUsers(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	if idStr := query.Get("id"); len(idStr) > 0 {
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			ioutils.RespErrorText("Не верное значение ID", w)
+			return
+		}
+		user, err := c.Bl.GetUser(id)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			ioutils.RespErrorText(err.Error(), w)
+			return
+		}
+		ioutils.RespJson(w, user)
+		return
+	}
+
+	page, limit, ok := pageParams(req)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("invalid page or limit", w)
+		return
+	}
+
+	users, err := c.Bl.GetUsers(query.Get("search"), page, limit)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		ioutils.RespErrorText(err.Error(), w)
+		return
+	}
+	ioutils.RespJson(w, users)
+}
+
+// AdminCreateUser creates a user with a role.
+//
+// @Summary Creates a user
+// @Description Creates a user with the given role, the default role is used when it is omitted.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param body body models.UserCreateRequest true "User data"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.UserIo "Created user"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format, unknown role or weak password"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения user:manage"
+// @Failure 409 {object} models.ErrorResponse "Login already exists"
+// @Router /api/admin/users [post]
+func (c *Controller) AdminCreateUser(w http.ResponseWriter, req *http.Request) {
+	var body models.UserCreateRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || len(body.Login) == 0 || len(body.Pass) == 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+	if _, clean := utils.Sanitize(body.Login); !clean {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("имя содержит непотдерживаемые символы", w)
+		return
+	}
+
+	user, err := c.Bl.CreateUserByAdmin(body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("err : '"+err.Error()+"'", w)
+		return
+	}
+	ioutils.RespJson(w, user)
+}
+
+// AdminUpdateUser changes the role of a user or disables the account.
+//
+// @Summary Updates a user
+// @Description Changes the role of a user and enables or disables the account. Disabling revokes all sessions of the user. The last admin cannot be demoted or disabled.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param body body models.UserUpdateRequest true "Changes"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.UserIo "Updated user"
+// @Failure 400 {object} models.ErrorResponse "Invalid data format or unknown role"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения user:manage"
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Failure 409 {object} models.ErrorResponse "The last admin cannot be removed"
+// @Router /api/admin/users [patch]
+func (c *Controller) AdminUpdateUser(w http.ResponseWriter, req *http.Request) {
+	var body models.UserUpdateRequest
+	err := ioutils.DecodeRequestBody(req, &body)
+	if err != nil || body.ID <= 0 {
+		ioutils.HandleInvalidJson(w)
+		return
+	}
+
+	user, err := c.Bl.UpdateUserByAdmin(body)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respUserAdminError(w, err)
+		return
+	}
+	ioutils.RespJson(w, user)
+}
+
+// AdminDeleteUser deletes a user.
+//
+// @Summary Deletes a user
+// @Description Deletes the user with the given ID. The last admin cannot be deleted.
+// @Tags Admin
+// @Param id query integer true "User ID"
+// @Param Authorization header string true "Bearer"
+// @Security bearerAuth
+// @Success 200 {object} models.OkResponse "User deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 401 {object} models.ErrorResponse "Отказано в доступе: ошибка токена"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен: нет разрешения user:manage"
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Failure 409 {object} models.ErrorResponse "The last admin cannot be removed"
+// @Router /api/admin/users [delete]
+func (c *Controller) AdminDeleteUser(w http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		ioutils.RespErrorText("Не верное значение ID", w)
+		return
+	}
+
+	err = c.Bl.DeleteUser(id)
+	if err != nil {
+		c.logger.Info("err", zap.Error(err))
+		c.respUserAdminError(w, err)
+		return
+	}
+	ioutils.RespJson(w, models.OkResponse{Ok: "User deleted"})
+}
+
+func (c *Controller) respUserAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bl.ErrUserNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, bl.ErrLastAdmin), errors.Is(err, bl.ErrUserExists):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	ioutils.RespErrorText(err.Error(), w)
+}
+
+// pageParams reads the page and limit query parameters, applying the defaults.
+func pageParams(req *http.Request) (int, int, bool) {
+	page, limit := 1, defaultPageLimit
+	var err error
+	if str := req.URL.Query().Get("page"); len(str) > 0 {
+		page, err = strconv.Atoi(str)
+		if err != nil || page < 1 {
+			return 0, 0, false
+		}
+	}
+	if str := req.URL.Query().Get("limit"); len(str) > 0 {
+		limit, err = strconv.Atoi(str)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return 0, 0, false
+		}
+	}
+	return page, limit, true
+}
@@ -0,0 +1,15 @@
+package ioutils
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP returns the address of the connected client without the port.
+func ClientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
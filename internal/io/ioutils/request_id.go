@@ -0,0 +1,34 @@
+package ioutils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const maxRequestIDLen = 64
+
+// RequestID returns the X-Request-ID of the request when it is a sane token,
+// otherwise a new random ID.
+func RequestID(req *http.Request) string {
+	if id := req.Header.Get("X-Request-ID"); validRequestID(id) {
+		return id
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
+
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -2,34 +2,30 @@ package ioutils
 
 import (
 	"time"
+	"unicode/utf8"
 	"vk-inter-test-go/internal/db/repo"
 	"vk-inter-test-go/internal/io/models"
 )
 
-func UserJsonValidate(user repo.User) bool {
+func UserJsonValidate(user models.LoginRequest) bool {
 	if len(user.Login) > 0 && len(user.Pass) > 0 {
 		return true
 	}
 	return false
 }
 
-func ActorJsonValidate(actor *repo.Actor) bool {
+func ActorJsonValidate(actor models.Actor) bool {
 	if len(actor.Name) == 0 {
 		return false
 	}
 	if !(actor.Gender == "male" || actor.Gender == "female") {
 		return false
 	}
-	var err error
-
-	actor.BirthDate, err = time.Parse("2006-01-02", actor.BirthDateJson)
-	if err != nil {
-		return false
-	}
-	return true
+	_, err := time.Parse(models.DateLayout, actor.BirthDate)
+	return err == nil
 }
 
-func MovieJsonValidate(movie *models.MovieIo) bool {
+func MovieJsonValidate(movie models.MovieIo) bool {
 	if len(movie.Movie.Title) < 1 || len(movie.Movie.Title) > 150 {
 		return false
 	}
@@ -39,15 +35,40 @@ func MovieJsonValidate(movie *models.MovieIo) bool {
 	if movie.Movie.Rating > 10 || movie.Movie.Rating < 0 {
 		return false
 	}
-	var err error
-	movie.Movie.ReleaseDate, err = time.Parse("2006-01-02", movie.Movie.ReleaseDateJson)
+	_, err := time.Parse(models.DateLayout, movie.Movie.ReleaseDate)
 	if err != nil {
 		return false
 	}
-	for i, _ := range movie.Actors {
-		if !ActorJsonValidate(&movie.Actors[i]) {
+	// a person is credited at most once in the cast and once per crew job
+	cast := make(map[string]bool)
+	for _, member := range movie.Actors {
+		if !CastMemberValidate(member) || cast[member.Name] {
+			return false
+		}
+		cast[member.Name] = true
+	}
+	crew := make(map[[2]string]bool)
+	for _, member := range movie.Crew {
+		key := [2]string{member.Name, member.Job}
+		if !crewJobs[member.Job] || !ActorJsonValidate(member.Actor) || crew[key] {
 			return false
 		}
+		crew[key] = true
 	}
 	return true
 }
+
+func CastMemberValidate(member models.CastMember) bool {
+	return ActorJsonValidate(member.Actor) && member.Billing >= 0 && utf8.RuneCountInString(member.Character) <= 100
+}
+
+var crewJobs = map[string]bool{
+	repo.CreditDirector: true,
+	repo.CreditWriter:   true,
+	repo.CreditProducer: true,
+	repo.CreditComposer: true,
+}
+
+func RoleJsonValidate(role models.Role) bool {
+	return len(role.Name) > 0 && len(role.Name) <= 50
+}
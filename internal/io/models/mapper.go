@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+	"vk-inter-test-go/internal/db/repo"
+)
+
+// DateLayout is the format of the dates of the API.
+const DateLayout = "2006-01-02"
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(DateLayout)
+}
+
+// parseDate parses a date of the API, an empty date is the zero time.
+func parseDate(date string) (time.Time, error) {
+	if len(date) == 0 {
+		return time.Time{}, nil
+	}
+	return time.Parse(DateLayout, date)
+}
+
+func MovieFromRepo(movie repo.Movie) Movie {
+	return Movie{
+		ID:          movie.ID,
+		Title:       movie.Title,
+		Description: movie.Description,
+		ReleaseDate: formatDate(movie.ReleaseDate),
+		Rating:      movie.Rating,
+	}
+}
+
+// ToRepo maps the movie to the stored entity, an empty release date is left zero.
+func (m Movie) ToRepo() (repo.Movie, error) {
+	releaseDate, err := parseDate(m.ReleaseDate)
+	if err != nil {
+		return repo.Movie{}, err
+	}
+	return repo.Movie{
+		ID:          m.ID,
+		Title:       m.Title,
+		Description: m.Description,
+		ReleaseDate: releaseDate,
+		Rating:      m.Rating,
+	}, nil
+}
+
+func ActorFromRepo(actor repo.Actor) Actor {
+	return Actor{
+		ID:        actor.ID,
+		Name:      actor.Name,
+		Gender:    actor.Gender,
+		BirthDate: formatDate(actor.BirthDate),
+	}
+}
+
+// ToRepo maps the actor to the stored entity, an empty birth date is left zero.
+func (a Actor) ToRepo() (repo.Actor, error) {
+	birthDate, err := parseDate(a.BirthDate)
+	if err != nil {
+		return repo.Actor{}, err
+	}
+	return repo.Actor{
+		ID:        a.ID,
+		Name:      a.Name,
+		Gender:    a.Gender,
+		BirthDate: birthDate,
+	}, nil
+}
+
+func GenreFromRepo(genre repo.Genre) Genre {
+	return Genre{ID: genre.ID, Name: genre.Name}
+}
+
+func ReviewFromRepo(review repo.Review) ReviewIo {
+	return ReviewIo{
+		ID:        review.ID,
+		MovieID:   review.MovieID,
+		Score:     review.Score,
+		Review:    review.Text,
+		CreatedAt: review.CreatedAt,
+		UpdatedAt: review.UpdatedAt,
+	}
+}
+
+func RoleFromRepo(role repo.Role) Role {
+	return Role{ID: role.ID, Name: role.Name, Permissions: role.Permissions}
+}
+
+func (r Role) ToRepo() repo.Role {
+	return repo.Role{ID: r.ID, Name: r.Name, Permissions: r.Permissions}
+}
@@ -1,15 +1,171 @@
 package models
 
 import (
-	"vk-inter-test-go/internal/db/repo"
+	"encoding/json"
+	"time"
 )
 
+// Movie is a movie as it is sent and received over the API. Dates use the
+// DateLayout format.
+type Movie struct {
+	ID          int    `json:"ID"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	Rating      int    `json:"rating,omitempty"`
+}
+
+type Actor struct {
+	ID        int    `json:"ID"`
+	Name      string `json:"name,omitempty"`
+	Gender    string `json:"gender,omitempty"`
+	BirthDate string `json:"birthDate,omitempty"`
+}
+
+type Role struct {
+	ID          int      `json:"ID"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type Genre struct {
+	ID   int    `json:"ID"`
+	Name string `json:"name"`
+}
+
+// CastMember is an actor credited on a movie. Billing is the position in the
+// credits starting from 1, zero when the actor is not billed.
+type CastMember struct {
+	Actor
+	Character string `json:"character,omitempty"`
+	Billing   int    `json:"billing,omitempty"`
+	Cameo     bool   `json:"cameo,omitempty"`
+}
+
+// CrewMember is a person credited on a movie for a job other than acting:
+// director, writer, producer or composer.
+type CrewMember struct {
+	Actor
+	Job string `json:"job"`
+}
+
+// MovieIo is a movie with its credits and genres. UserScore is the average
+// score of the user reviews next to the editorial Movie.Rating, Votes is the
+// number of reviews.
 type MovieIo struct {
-	Movie  repo.Movie   `json:"movie"`
-	Actors []repo.Actor `json:"actors"`
+	Movie     Movie        `json:"movie"`
+	UserScore float64      `json:"userScore"`
+	Votes     int          `json:"votes"`
+	Actors    []CastMember `json:"actors"`
+	Crew      []CrewMember `json:"crew"`
+	Genres    []Genre      `json:"genres"`
+}
+
+type ReviewIo struct {
+	ID        int       `json:"ID"`
+	MovieID   int       `json:"movieId"`
+	Score     int       `json:"score"`
+	Review    string    `json:"review,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 type ActorIo struct {
-	Actor  repo.Actor   `json:"actor"`
-	Movies []repo.Movie `json:"movies"`
+	Actor  Actor   `json:"actor"`
+	Movies []Movie `json:"movies"`
+}
+
+type UserIo struct {
+	ID        int       `json:"ID"`
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	MustChangePassword bool   `json:"mustChangePassword"`
+	Email              string `json:"email,omitempty"`
+	EmailVerified      bool   `json:"emailVerified"`
+	DisplayName        string `json:"displayName,omitempty"`
+}
+
+// ProfileIo is the profile of the current user.
+type ProfileIo struct {
+	ID            int       `json:"ID"`
+	Login         string    `json:"login"`
+	DisplayName   string    `json:"displayName,omitempty"`
+	Role          string    `json:"role"`
+	Email         string    `json:"email,omitempty"`
+	EmailVerified bool      `json:"emailVerified"`
+	MfaEnabled    bool      `json:"mfaEnabled"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type SessionIo struct {
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+type IdentityIo struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UserExport is everything stored about a user. Secrets such as password and
+// token hashes or the second factor secret are left out.
+type UserExport struct {
+	ExportedAt  time.Time      `json:"exportedAt"`
+	Profile     ProfileIo      `json:"profile"`
+	Sessions    []SessionIo    `json:"sessions"`
+	ApiKeys     []ApiKeyIo     `json:"apiKeys"`
+	Identities  []IdentityIo   `json:"identities"`
+	AuditEvents []AuditEventIo `json:"auditEvents"`
+	Reviews     []ReviewIo     `json:"reviews"`
+}
+
+type UserPage struct {
+	Users []UserIo `json:"users"`
+	Total int      `json:"total"`
+	Page  int      `json:"page"`
+	Limit int      `json:"limit"`
+}
+
+type LockoutIo struct {
+	Scope        string    `json:"scope"`
+	Subject      string    `json:"subject"`
+	Failures     int       `json:"failures"`
+	BlockedUntil time.Time `json:"blockedUntil"`
+}
+
+type ApiKeyIo struct {
+	ID          int        `json:"ID"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Permissions []string   `json:"permissions"`
+	CreatedBy   *int       `json:"createdBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+}
+
+type AuditEventIo struct {
+	ID         int64           `json:"ID"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entityType"`
+	EntityID   int             `json:"entityId"`
+	Before     json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	RequestID  string          `json:"requestId"`
+	IP         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+type AuditPage struct {
+	Events []AuditEventIo `json:"events"`
+	Total  int            `json:"total"`
+	Page   int            `json:"page"`
+	Limit  int            `json:"limit"`
 }
@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+type RefreshRequest struct {
+	Refresh string `json:"refresh"`
+}
+
+// LoginRequest carries the credentials of /api/login and /api/create/user.
+type LoginRequest struct {
+	Login string `json:"login"`
+	Pass  string `json:"pass"`
+}
+
+type UserCreateRequest struct {
+	Login string `json:"login"`
+	Pass  string `json:"pass"`
+	Role  string `json:"role,omitempty"`
+}
+
+type UserUpdateRequest struct {
+	ID       int     `json:"ID"`
+	Role     *string `json:"role,omitempty"`
+	Disabled *bool   `json:"disabled,omitempty"`
+
+	MustChangePassword *bool `json:"mustChangePassword,omitempty"`
+}
+
+type PasswordChangeRequest struct {
+	OldPass string `json:"oldPass"`
+	NewPass string `json:"newPass"`
+}
+
+type EmailRequest struct {
+	Email string `json:"email"`
+}
+
+type TokenRequest struct {
+	Token string `json:"token"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token   string `json:"token"`
+	NewPass string `json:"newPass"`
+}
+
+type ApiKeyCreateRequest struct {
+	Name        string     `json:"name"`
+	Permissions []string   `json:"permissions"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+type MfaLoginRequest struct {
+	MfaToken     string `json:"mfaToken"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recoveryCode,omitempty"`
+}
+
+type MfaCodeRequest struct {
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recoveryCode,omitempty"`
+}
+
+type ProfileUpdateRequest struct {
+	DisplayName *string `json:"displayName,omitempty"`
+}
+
+// AccountDeleteRequest confirms the deletion of the own account with the password.
+type AccountDeleteRequest struct {
+	Pass string `json:"pass"`
+}
+
+type GenreCreateRequest struct {
+	Name string `json:"name"`
+}
+
+// MovieGenresRequest replaces the genres of a movie, given by name.
+type MovieGenresRequest struct {
+	MovieID int      `json:"movieId"`
+	Genres  []string `json:"genres"`
+}
+
+// MovieActorRequest adds an actor to the cast of a movie. The actor is found
+// by name and created when it is not known yet.
+type MovieActorRequest struct {
+	MovieID int `json:"movieId"`
+	CastMember
+}
+
+// MovieCastRequest replaces the whole cast of a movie, an empty list removes it.
+type MovieCastRequest struct {
+	MovieID int          `json:"movieId"`
+	Actors  []CastMember `json:"actors"`
+}
+
+// ReviewCreateRequest rates a movie from 1 to 10, the review text is optional.
+type ReviewCreateRequest struct {
+	MovieID int    `json:"movieId"`
+	Score   int    `json:"score"`
+	Review  string `json:"review,omitempty"`
+}
+
+// ReviewUpdateRequest changes an own review, a zero score and an absent review
+// keep the stored values and an empty review removes the text.
+type ReviewUpdateRequest struct {
+	ID     int     `json:"ID"`
+	Score  int     `json:"score,omitempty"`
+	Review *string `json:"review,omitempty"`
+}
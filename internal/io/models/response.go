@@ -4,10 +4,37 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// TokenResponse carries either the token pair or, when the second factor is
+// still required, the mfa token.
 type TokenResponse struct {
-	Bearer string `json:"bearer"`
+	Bearer    string `json:"bearer"`
+	Refresh   string `json:"refresh,omitempty"`
+	ExpiresIn int64  `json:"expiresIn,omitempty"`
+
+	MfaRequired bool   `json:"mfaRequired,omitempty"`
+	MfaToken    string `json:"mfaToken,omitempty"`
+
+	// CsrfToken is set in cookie session mode, where the tokens are cookies.
+	CsrfToken string `json:"csrfToken,omitempty"`
 }
 
 type OkResponse struct {
 	Ok string `json:"ok"`
 }
+
+// ApiKeyCreated carries the key itself, it is shown only once.
+type ApiKeyCreated struct {
+	ApiKey ApiKeyIo `json:"apiKey"`
+	Key    string   `json:"key"`
+}
+
+type MfaEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MfaEnabledResponse carries the recovery codes, they are shown only once.
+type MfaEnabledResponse struct {
+	RecoveryCodes []string      `json:"recoveryCodes"`
+	Tokens        TokenResponse `json:"tokens"`
+}
@@ -2,6 +2,7 @@ package io
 
 import (
 	"net/http"
+	"vk-inter-test-go/internal/bl"
 	"vk-inter-test-go/internal/io/http/handlers"
 	"vk-inter-test-go/internal/io/ioutils"
 )
@@ -11,38 +12,163 @@ func SetupRoutes(contr *handlers.Controller) http.Handler {
 
 	mux.HandleFunc("/api/create/user", contr.CreateUser)
 	mux.HandleFunc("/api/login", contr.AuthUser)
+	mux.HandleFunc("/api/login/mfa", contr.LoginMfa)
+	mux.HandleFunc("/api/login/oidc", contr.OidcLogin)
+	mux.HandleFunc("/api/login/oidc/callback", contr.OidcCallback)
+	mux.HandleFunc("/api/token/refresh", contr.RefreshToken)
+	mux.HandleFunc("/api/logout", contr.Logout)
+	mux.HandleFunc("/.well-known/jwks.json", contr.JWKS)
+	mux.HandleFunc("/api/user/password", contr.PasswordChangeAuthMiddleware(contr.ChangePassword))
+	mux.HandleFunc("/api/user/email", contr.AuthMiddleware(contr.SetEmail))
+	mux.HandleFunc("/api/user/email/verify", contr.VerifyEmail)
+	mux.HandleFunc("/api/user/mfa", contr.AuthMiddleware(contr.DisableMfa))
+	mux.HandleFunc("/api/user/mfa/enroll", contr.MfaEnrollAuthMiddleware(contr.EnrollMfa))
+	mux.HandleFunc("/api/user/mfa/confirm", contr.MfaEnrollAuthMiddleware(contr.ConfirmMfa))
+	mux.HandleFunc("/api/me", contr.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			contr.GetMe(w, r)
+		case http.MethodPatch:
+			contr.UpdateMe(w, r)
+		case http.MethodDelete:
+			contr.DeleteMe(w, r)
+
+		default:
+			ioutils.HandleInvalidMethodResponse(w, r.Method)
+		}
+	}))
+	mux.HandleFunc("/api/me/export", contr.AuthMiddleware(contr.ExportMe))
+	mux.HandleFunc("/api/password/reset/request", contr.RequestPasswordReset)
+	mux.HandleFunc("/api/password/reset/confirm", contr.ConfirmPasswordReset)
 
-	mux.HandleFunc("/api/actor", contr.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/actor", contr.GuestAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			contr.GetAllActors(w, r)
 		case http.MethodPost:
-			contr.RequireRole("admin", contr.CreateActor)(w, r)
+			contr.RequirePermission(bl.PermActorWrite, contr.CreateActor)(w, r)
 		case http.MethodDelete:
-			contr.RequireRole("admin", contr.DeleteActor)(w, r)
+			contr.RequirePermission(bl.PermActorDelete, contr.DeleteActor)(w, r)
 		case http.MethodPatch:
-			contr.RequireRole("admin", contr.UpdateActor)(w, r)
+			contr.RequirePermission(bl.PermActorWrite, contr.UpdateActor)(w, r)
 
 		default:
 			ioutils.HandleInvalidMethodResponse(w, r.Method)
 		}
 	}))
-	mux.HandleFunc("/api/movie", contr.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/movie", contr.GuestAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			contr.GetMovies(w, r)
 		case http.MethodPost:
-			contr.RequireRole("admin", contr.CreateMovie)(w, r)
+			contr.RequirePermission(bl.PermMovieWrite, contr.CreateMovie)(w, r)
+		case http.MethodDelete:
+			contr.RequirePermission(bl.PermMovieDelete, contr.DeleteMovie)(w, r)
+		case http.MethodPatch:
+			contr.RequirePermission(bl.PermMovieWrite, contr.UpdateMovie)(w, r)
+
+		default:
+			ioutils.HandleInvalidMethodResponse(w, r.Method)
+		}
+	}))
+
+	mux.HandleFunc("/api/movie/cast", contr.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			contr.RequirePermission(bl.PermMovieWrite, contr.AddMovieActor)(w, r)
 		case http.MethodDelete:
-			contr.RequireRole("admin", contr.DeleteMovie)(w, r)
+			contr.RequirePermission(bl.PermMovieWrite, contr.RemoveMovieActor)(w, r)
+		case http.MethodPut:
+			contr.RequirePermission(bl.PermMovieWrite, contr.ReplaceMovieCast)(w, r)
+		default:
+			ioutils.HandleInvalidMethodResponse(w, r.Method)
+		}
+	}))
+	mux.HandleFunc("/api/movie/genres", contr.AuthMiddleware(contr.RequirePermission(bl.PermGenreManage, contr.SetMovieGenres)))
+	mux.HandleFunc("/api/review", contr.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			contr.GetMyReviews(w, r)
+		case http.MethodPost:
+			contr.CreateReview(w, r)
 		case http.MethodPatch:
-			contr.RequireRole("admin", contr.UpdateMovie)(w, r)
+			contr.UpdateReview(w, r)
+		case http.MethodDelete:
+			contr.DeleteReview(w, r)
+
+		default:
+			ioutils.HandleInvalidMethodResponse(w, r.Method)
+		}
+	}))
+	mux.HandleFunc("/api/genre", contr.GuestAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			contr.GetGenres(w, r)
+		case http.MethodPost:
+			contr.RequirePermission(bl.PermGenreManage, contr.CreateGenre)(w, r)
 
 		default:
 			ioutils.HandleInvalidMethodResponse(w, r.Method)
 		}
 	}))
 
+	mux.HandleFunc("/api/admin/roles", contr.AuthMiddleware(contr.RequirePermission(bl.PermRoleManage, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			contr.GetRoles(w, r)
+		case http.MethodPost:
+			contr.CreateRole(w, r)
+		case http.MethodPut:
+			contr.UpdateRole(w, r)
+		case http.MethodDelete:
+			contr.DeleteRole(w, r)
+
+		default:
+			ioutils.HandleInvalidMethodResponse(w, r.Method)
+		}
+	})))
+	mux.HandleFunc("/api/admin/users", contr.AuthMiddleware(contr.RequirePermission(bl.PermUserManage, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			contr.GetUsers(w, r)
+		case http.MethodPost:
+			contr.AdminCreateUser(w, r)
+		case http.MethodPatch:
+			contr.AdminUpdateUser(w, r)
+		case http.MethodDelete:
+			contr.AdminDeleteUser(w, r)
+
+		default:
+			ioutils.HandleInvalidMethodResponse(w, r.Method)
+		}
+	})))
+	mux.HandleFunc("/api/admin/lockouts", contr.AuthMiddleware(contr.RequirePermission(bl.PermUserManage, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			contr.GetLockouts(w, r)
+		case http.MethodDelete:
+			contr.Unlock(w, r)
+
+		default:
+			ioutils.HandleInvalidMethodResponse(w, r.Method)
+		}
+	})))
+	mux.HandleFunc("/api/admin/apikeys", contr.AuthMiddleware(contr.RequirePermission(bl.PermApiKeyManage, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			contr.GetApiKeys(w, r)
+		case http.MethodPost:
+			contr.CreateApiKey(w, r)
+		case http.MethodDelete:
+			contr.RevokeApiKey(w, r)
+
+		default:
+			ioutils.HandleInvalidMethodResponse(w, r.Method)
+		}
+	})))
+	mux.HandleFunc("/api/admin/audit", contr.AuthMiddleware(contr.RequirePermission(bl.PermAuditRead, contr.GetAuditEvents)))
+	mux.HandleFunc("/api/admin/permissions", contr.AuthMiddleware(contr.RequirePermission(bl.PermRoleManage, contr.GetPermissions)))
+
 	muxN := use(mux, contr.GlobalMiddleware)
 
 	return muxN
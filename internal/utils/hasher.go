@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+const (
+	HashArgon2id = "argon2id"
+	HashBcrypt   = "bcrypt"
+
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+var (
+	ErrPasswordMismatch = errors.New("password does not match")
+	ErrUnknownHash      = errors.New("unknown password hash format")
+)
+
+// PasswordHasher hashes passwords into encoded strings that name the algorithm
+// and carry its parameters, so a hash can be verified after the settings change.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify returns ErrPasswordMismatch when the password does not match.
+	Verify(encoded string, password string) error
+	// NeedsRehash reports whether the hash was made with other settings than
+	// the current ones.
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2idHasher encodes hashes in the PHC format:
+// $argon2id$v=19$m=<memory KiB>,t=<time>,p=<threads>$<salt>$<key>
+type Argon2idHasher struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+// validate rejects the parameters argon2.IDKey cannot work with, it panics when
+// the time or the number of threads is zero. RFC 9106 requires at least 8 KiB
+// of memory per thread.
+func (h Argon2idHasher) validate() error {
+	if h.Time < 1 || h.Threads < 1 || h.Memory < 8*uint32(h.Threads) {
+		return fmt.Errorf("invalid argon2id parameters m=%d,t=%d,p=%d", h.Memory, h.Time, h.Threads)
+	}
+	return nil
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify takes the parameters from the encoded hash, not from the hasher.
+func (h Argon2idHasher) Verify(encoded string, password string) error {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+	actual := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (h Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	return err != nil || params != h
+}
+
+func decodeArgon2id(encoded string) (Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != HashArgon2id {
+		return Argon2idHasher{}, nil, nil, ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2idHasher{}, nil, nil, ErrUnknownHash
+	}
+	var params Argon2idHasher
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads)
+	if err != nil || params.validate() != nil {
+		return Argon2idHasher{}, nil, nil, ErrUnknownHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idHasher{}, nil, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2idHasher{}, nil, nil, ErrUnknownHash
+	}
+	return params, salt, key, nil
+}
+
+// BcryptHasher produces the $2a$ modular crypt format.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h BcryptHasher) Verify(encoded string, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+func (h BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
+
+// multiHasher hashes with the configured algorithm and verifies hashes of every
+// supported algorithm, picking it by the prefix of the encoded hash.
+type multiHasher struct {
+	current string
+	argon2  Argon2idHasher
+	bcrypt  BcryptHasher
+}
+
+func NewPasswordHasher(algorithm string, argon2 Argon2idHasher, bcrypt BcryptHasher) (PasswordHasher, error) {
+	if algorithm != HashArgon2id && algorithm != HashBcrypt {
+		return nil, fmt.Errorf("unsupported password hash %q", algorithm)
+	}
+	if err := argon2.validate(); err != nil {
+		return nil, err
+	}
+	return &multiHasher{current: algorithm, argon2: argon2, bcrypt: bcrypt}, nil
+}
+
+func (h *multiHasher) Hash(password string) (string, error) {
+	if h.current == HashBcrypt {
+		return h.bcrypt.Hash(password)
+	}
+	return h.argon2.Hash(password)
+}
+
+func (h *multiHasher) Verify(encoded string, password string) error {
+	hasher, ok := h.hasherOf(encoded)
+	if !ok {
+		return ErrUnknownHash
+	}
+	return hasher.Verify(encoded, password)
+}
+
+func (h *multiHasher) NeedsRehash(encoded string) bool {
+	hasher, ok := h.hasherOf(encoded)
+	if !ok || algorithmOf(encoded) != h.current {
+		return true
+	}
+	return hasher.NeedsRehash(encoded)
+}
+
+func (h *multiHasher) hasherOf(encoded string) (PasswordHasher, bool) {
+	switch algorithmOf(encoded) {
+	case HashArgon2id:
+		return h.argon2, true
+	case HashBcrypt:
+		return h.bcrypt, true
+	}
+	return nil, false
+}
+
+func algorithmOf(encoded string) string {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return HashArgon2id
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return HashBcrypt
+	}
+	return ""
+}
@@ -1,26 +1,54 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
-	"golang.org/x/crypto/bcrypt"
 	"time"
 )
 
-var jwtKey = []byte("secret")
+// TokenClaims are the application claims carried by an access token.
+type TokenClaims struct {
+	Subject string
+	UserID  int
+	Role    string
+	// MustChangePassword restricts the token to changing the password.
+	MustChangePassword bool
+	// MustEnrollMfa restricts the token to enrolling a second factor.
+	MustEnrollMfa bool
+	// Purpose marks tokens that are not access tokens, e.g. the token between
+	// the password and the second factor of a login.
+	Purpose string
+}
 
-func GenerateToken(ttl time.Duration, payload interface{}) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+func GenerateToken(ttl time.Duration, payload TokenClaims) (string, error) {
+	if keySet == nil {
+		return "", errors.New("generating JWT Token failed: keys are not configured")
+	}
+	key := keySet.keys[keySet.active]
+	token := jwt.New(key.method)
+	token.Header["kid"] = keySet.active
 
 	now := time.Now().UTC()
 	claims := token.Claims.(jwt.MapClaims)
 
-	claims["sub"] = payload
+	claims["sub"] = payload.Subject
+	claims["uid"] = payload.UserID
+	claims["role"] = payload.Role
+	if payload.MustChangePassword {
+		claims["mcp"] = true
+	}
+	if payload.MustEnrollMfa {
+		claims["mfe"] = true
+	}
+	if len(payload.Purpose) > 0 {
+		claims["pur"] = payload.Purpose
+	}
 	claims["exp"] = now.Add(ttl).Unix()
 	claims["iat"] = now.Unix()
 	claims["nbf"] = now.Unix()
 
-	tokenString, err := token.SignedString(jwtKey)
+	tokenString, err := token.SignedString(key.sign)
 
 	if err != nil {
 		return "", fmt.Errorf("generating JWT Token failed: %w", err)
@@ -29,52 +57,50 @@ func GenerateToken(ttl time.Duration, payload interface{}) (string, error) {
 	return tokenString, nil
 }
 
-func ValidateToken(token string) (interface{}, error) {
+func ValidateToken(token string) (TokenClaims, error) {
+	if keySet == nil {
+		return TokenClaims{}, errors.New("invalidate token: keys are not configured")
+	}
 	tok, err := jwt.Parse(token, func(jwtToken *jwt.Token) (interface{}, error) {
-		if _, ok := jwtToken.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := jwtToken.Header["kid"].(string)
+		key, ok := keySet.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+		if jwtToken.Method.Alg() != key.method.Alg() {
 			return nil, fmt.Errorf("unexpected method: %s", jwtToken.Header["alg"])
 		}
-
-		return jwtKey, nil
+		return key.verify, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("invalidate token: %w", err)
+		return TokenClaims{}, fmt.Errorf("invalidate token: %w", err)
 	}
 
 	claims, ok := tok.Claims.(jwt.MapClaims)
 	if !ok || !tok.Valid {
-		return nil, fmt.Errorf("invalid token claim")
-	}
-
-	return claims["sub"], nil
-}
-
-func VerifyPassword(hashedPassword string, candidatePassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(candidatePassword))
-}
-
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
+		return TokenClaims{}, fmt.Errorf("invalid token claim")
 	}
-	return string(hashedPassword), nil
-}
 
-func ExtractUsernameFromToken(tokenString string) (string, error) {
-	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
-	if err != nil {
-		return "", fmt.Errorf("failed to parse token: %w", err)
+	sub, ok := claims["sub"].(string)
+	if !ok || len(sub) == 0 {
+		return TokenClaims{}, fmt.Errorf("username not found in token")
 	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
+	uid, ok := claims["uid"].(float64)
 	if !ok {
-		return "", fmt.Errorf("claims not found in token")
-	}
-
-	if username, ok := claims["sub"].(string); ok {
-		return username, nil
+		return TokenClaims{}, fmt.Errorf("user id not found in token")
 	}
 
-	return "", fmt.Errorf("username not found in token")
+	role, _ := claims["role"].(string)
+	mustChangePassword, _ := claims["mcp"].(bool)
+	mustEnrollMfa, _ := claims["mfe"].(bool)
+	purpose, _ := claims["pur"].(string)
+
+	return TokenClaims{
+		Subject:            sub,
+		UserID:             int(uid),
+		Role:               role,
+		MustChangePassword: mustChangePassword,
+		MustEnrollMfa:      mustEnrollMfa,
+		Purpose:            purpose,
+	}, nil
 }
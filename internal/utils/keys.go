@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"math/big"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+type signingKey struct {
+	method jwt.SigningMethod
+	sign   interface{} // nil for keys that can only verify
+	verify interface{}
+}
+
+// KeySet holds the keys tokens are signed with, addressed by kid.
+// New tokens are signed with the active key, the retired ones are kept
+// only to validate tokens issued before a rotation.
+type KeySet struct {
+	active string
+	keys   map[string]signingKey
+}
+
+var keySet *KeySet
+
+// NewKeySet builds a key set for the given algorithm. For HS256 the keys are
+// shared secrets, for RS256 and EdDSA they are PEM encoded: a private key can
+// sign and verify, a public key only verifies tokens signed by a retired key.
+func NewKeySet(alg string, active string, keys map[string][]byte) (*KeySet, error) {
+	ks := &KeySet{active: active, keys: make(map[string]signingKey, len(keys))}
+	for kid, raw := range keys {
+		key, err := parseSigningKey(alg, raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+		ks.keys[kid] = key
+	}
+
+	activeKey, ok := ks.keys[active]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found", active)
+	}
+	if activeKey.sign == nil {
+		return nil, fmt.Errorf("active key %q is a public key and cannot sign", active)
+	}
+	return ks, nil
+}
+
+// SetKeySet installs the keys used by GenerateToken and ValidateToken.
+// It is expected to be called once on startup.
+func SetKeySet(ks *KeySet) {
+	keySet = ks
+}
+
+func parseSigningKey(alg string, raw []byte) (signingKey, error) {
+	switch alg {
+	case AlgHS256:
+		return signingKey{method: jwt.SigningMethodHS256, sign: raw, verify: raw}, nil
+	case AlgRS256:
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(raw); err == nil {
+			return signingKey{method: jwt.SigningMethodRS256, sign: private, verify: &private.PublicKey}, nil
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(raw)
+		if err != nil {
+			return signingKey{}, fmt.Errorf("not a RSA PEM key: %w", err)
+		}
+		return signingKey{method: jwt.SigningMethodRS256, verify: public}, nil
+	case AlgEdDSA:
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(raw); err == nil {
+			edPrivate := private.(ed25519.PrivateKey)
+			return signingKey{method: jwt.SigningMethodEdDSA, sign: edPrivate, verify: edPrivate.Public()}, nil
+		}
+		public, err := jwt.ParseEdPublicKeyFromPEM(raw)
+		if err != nil {
+			return signingKey{}, fmt.Errorf("not a Ed25519 PEM key: %w", err)
+		}
+		return signingKey{method: jwt.SigningMethodEdDSA, verify: public}, nil
+	}
+	return signingKey{}, fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS returns the public keys of the installed key set in JWK Set format (RFC 7517).
+// Shared HMAC secrets are never published, so the set is empty for HS256.
+func PublicJWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if keySet == nil {
+		return set
+	}
+	for kid, key := range keySet.keys {
+		switch public := key.verify.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	return set
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords are rejected regardless of the configured denylist.
+var commonPasswords = []string{
+	"password", "password1", "passw0rd", "123456", "1234567", "12345678", "123456789", "1234567890",
+	"qwerty", "qwerty123", "qwertyuiop", "1q2w3e4r", "111111", "000000", "abc123", "admin", "admin123",
+	"root", "secret", "letmein", "welcome", "iloveyou", "monkey", "dragon", "football", "baseball",
+	"master", "sunshine", "princess", "trustno1", "changeme",
+}
+
+// PasswordPolicy describes the requirements a new password has to meet.
+type PasswordPolicy struct {
+	MinLength  int
+	MinClasses int
+	denylist   map[string]bool
+}
+
+func NewPasswordPolicy(minLength int, minClasses int, denylist []string) PasswordPolicy {
+	policy := PasswordPolicy{MinLength: minLength, MinClasses: minClasses, denylist: make(map[string]bool)}
+	for _, list := range [][]string{commonPasswords, denylist} {
+		for _, password := range list {
+			policy.denylist[strings.ToLower(strings.TrimSpace(password))] = true
+		}
+	}
+	return policy
+}
+
+// Check returns a description of the first requirement the password does not meet.
+func (p PasswordPolicy) Check(password string, login string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+	if classes := characterClasses(password); classes < p.MinClasses {
+		return fmt.Errorf("password must contain at least %d of: lowercase, uppercase, digits, other characters", p.MinClasses)
+	}
+	lower := strings.ToLower(password)
+	if p.denylist[lower] {
+		return fmt.Errorf("password is too common")
+	}
+	if len(login) > 0 && lower == strings.ToLower(login) {
+		return fmt.Errorf("password must differ from the login")
+	}
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	var classes int
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			classes++
+		}
+	}
+	return classes
+}
@@ -23,3 +23,17 @@ func UniqueValues(m map[int][]int) []int {
 
 	return uniqueInts(values)
 }
+
+func UniqueStrings(slice []string) []string {
+	encountered := make(map[string]bool)
+	unique := []string{}
+
+	for _, v := range slice {
+		if !encountered[v] {
+			encountered[v] = true
+			unique = append(unique, v)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,194 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+)
+
+type mockApiKeyRepo struct {
+	mu   sync.Mutex
+	keys map[int]*repo.ApiKey
+}
+
+func newMockApiKeyRepo() *mockApiKeyRepo {
+	return &mockApiKeyRepo{keys: make(map[int]*repo.ApiKey)}
+}
+
+func (m *mockApiKeyRepo) CreateApiKey(key *repo.ApiKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, permission := range key.Permissions {
+		if !containsString(mockRolePermissions["admin"], permission) {
+			return errors.New("unknown permission")
+		}
+	}
+	key.ID = len(m.keys) + 1
+	key.CreatedAt = time.Now()
+	stored := *key
+	m.keys[key.ID] = &stored
+	return nil
+}
+
+func (m *mockApiKeyRepo) GetApiKeyByHash(hash string) (repo.ApiKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range m.keys {
+		if key.KeyHash == hash {
+			return *key, nil
+		}
+	}
+	return repo.ApiKey{}, errors.New("not found")
+}
+
+func (m *mockApiKeyRepo) GetApiKeys() ([]repo.ApiKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []repo.ApiKey
+	for _, key := range m.keys {
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+func (m *mockApiKeyRepo) TouchApiKey(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if key, ok := m.keys[id]; ok {
+		now := time.Now()
+		key.LastUsedAt = &now
+	}
+	return nil
+}
+
+func (m *mockApiKeyRepo) RevokeApiKey(id int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return 0, nil
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	return 1, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func principalCtx(userID int, login string, role string) context.Context {
+	return bl.WithPrincipal(context.Background(), bl.Principal{UserID: userID, Login: login, Role: role})
+}
+
+func TestApiKeyPermissions(t *testing.T) {
+	created, err := exempl.CreateApiKey(principalCtx(1, "testuser", "admin"), models.ApiKeyCreateRequest{
+		Name:        "importer",
+		Permissions: []string{"movie:write", "movie:write"},
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.Key)
+	assert.Equal(t, created.Key[:len(created.ApiKey.Prefix)], created.ApiKey.Prefix)
+	assert.Equal(t, []string{"movie:write"}, created.ApiKey.Permissions)
+	assert.Equal(t, 1, *created.ApiKey.CreatedBy)
+
+	principal, err := exempl.AuthenticateApiKey(created.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ApiKey.ID, principal.ApiKeyID)
+
+	ctx := bl.WithPrincipal(context.Background(), principal)
+	_, ok := exempl.CheckPermission(ctx, bl.PermMovieWrite)
+	assert.True(t, ok)
+	_, ok = exempl.CheckPermission(ctx, bl.PermMovieDelete)
+	assert.False(t, ok)
+	_, ok = exempl.CheckRole(ctx, bl.RoleAdmin)
+	assert.False(t, ok)
+
+	keys, err := exempl.GetApiKeys()
+	assert.NoError(t, err)
+	for _, key := range keys {
+		if key.ID == created.ApiKey.ID {
+			assert.NotNil(t, key.LastUsedAt, "last use expected")
+		}
+	}
+
+	_, err = exempl.AuthenticateApiKey("bad")
+	assert.ErrorIs(t, err, bl.ErrInvalidApiKey)
+}
+
+func TestApiKeyRevokeAndExpiry(t *testing.T) {
+	ctx := principalCtx(1, "testuser", "admin")
+	expiresAt := time.Now().Add(-time.Minute)
+	_, err := exempl.CreateApiKey(ctx, models.ApiKeyCreateRequest{Name: "expired", ExpiresAt: &expiresAt})
+	assert.ErrorIs(t, err, bl.ErrApiKeyExpiry)
+
+	expiresAt = time.Now().Add(time.Hour)
+	created, err := exempl.CreateApiKey(ctx, models.ApiKeyCreateRequest{Name: "reader", ExpiresAt: &expiresAt})
+	assert.NoError(t, err)
+	_, err = exempl.AuthenticateApiKey(created.Key)
+	assert.NoError(t, err)
+
+	// expiry is checked on use
+	expired := time.Now().Add(-time.Second)
+	mok.ApiKey.(*mockApiKeyRepo).keys[created.ApiKey.ID].ExpiresAt = &expired
+	_, err = exempl.AuthenticateApiKey(created.Key)
+	assert.ErrorIs(t, err, bl.ErrInvalidApiKey)
+
+	created, err = exempl.CreateApiKey(ctx, models.ApiKeyCreateRequest{Name: "revoked"})
+	assert.NoError(t, err)
+	assert.NoError(t, exempl.RevokeApiKey(created.ApiKey.ID))
+	assert.ErrorIs(t, exempl.RevokeApiKey(created.ApiKey.ID), bl.ErrApiKeyNotFound)
+	_, err = exempl.AuthenticateApiKey(created.Key)
+	assert.ErrorIs(t, err, bl.ErrInvalidApiKey)
+}
+
+func TestApiKeyGrantOnlyHeldPermissions(t *testing.T) {
+	mockRolePermissions["keymaster"] = []string{"apikey:manage", "movie:write"}
+	defer delete(mockRolePermissions, "keymaster")
+
+	ctx := principalCtx(100, "keymaster", "keymaster")
+	_, err := exempl.CreateApiKey(ctx, models.ApiKeyCreateRequest{Name: "ok", Permissions: []string{"movie:write"}})
+	assert.NoError(t, err)
+	_, err = exempl.CreateApiKey(ctx, models.ApiKeyCreateRequest{Name: "escalate", Permissions: []string{"user:manage"}})
+	assert.ErrorIs(t, err, bl.ErrPermissionNotHeld)
+}
+
+func TestAuthMiddlewareApiKey(t *testing.T) {
+	created, err := exempl.CreateApiKey(principalCtx(1, "testuser", "admin"), models.ApiKeyCreateRequest{Name: "middleware"})
+	assert.NoError(t, err)
+
+	contr := handlers.NewController(exempl, testOptions, zap.NewExample())
+	var principal bl.Principal
+	protected := contr.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = bl.PrincipalFromContext(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+	req.Header.Set("X-API-Key", created.Key)
+	rec := httptest.NewRecorder()
+	protected(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, created.ApiKey.ID, principal.ApiKeyID)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+	req.Header.Set("X-API-Key", "bad")
+	rec = httptest.NewRecorder()
+	protected(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
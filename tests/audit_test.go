@@ -0,0 +1,189 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+)
+
+type mockAuditRepo struct {
+	mu     sync.Mutex
+	events []repo.AuditEvent
+	fail   error
+}
+
+func newMockAuditRepo() *mockAuditRepo {
+	return &mockAuditRepo{}
+}
+
+func (m *mockAuditRepo) CreateAuditEvent(event *repo.AuditEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail != nil {
+		return m.fail
+	}
+	event.ID = int64(len(m.events) + 1)
+	event.CreatedAt = time.Now()
+	m.events = append(m.events, *event)
+	return nil
+}
+
+func (m *mockAuditRepo) GetAuditEvents(filter repo.AuditFilter, limit int, offset int) ([]repo.AuditEvent, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var matched []repo.AuditEvent
+	for i := len(m.events) - 1; i >= 0; i-- {
+		event := m.events[i]
+		if (filter.EntityType != "" && event.EntityType != filter.EntityType) ||
+			(filter.EntityID != 0 && event.EntityID != filter.EntityID) ||
+			(filter.ActorLogin != "" && event.ActorLogin != filter.ActorLogin) ||
+			(filter.ActorUserID != 0 && (event.ActorUserID == nil || *event.ActorUserID != filter.ActorUserID)) ||
+			(filter.From != nil && event.CreatedAt.Before(*filter.From)) ||
+			(filter.To != nil && !event.CreatedAt.Before(*filter.To)) {
+			continue
+		}
+		matched = append(matched, event)
+	}
+	total := len(matched)
+	if offset >= total {
+		return nil, total, nil
+	}
+	return matched[offset:min(offset+limit, total)], total, nil
+}
+
+func (m *mockAuditRepo) last() repo.AuditEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.events[len(m.events)-1]
+}
+
+func auditCtx(login string, requestID string) context.Context {
+	ctx := principalCtx(1, login, "admin")
+	return bl.WithRequestInfo(ctx, bl.RequestInfo{ID: requestID, IP: testClientIP})
+}
+
+func TestAuditCatalogWrites(t *testing.T) {
+	audit := mok.Audit.(*mockAuditRepo)
+
+	_, err := exempl.UpdateMovie(auditCtx("editor", "req-update"), repo.Movie{ID: 1, Title: "New Title"})
+	assert.NoError(t, err)
+	event := audit.last()
+	assert.Equal(t, "editor", event.ActorLogin)
+	assert.Equal(t, bl.AuditActionUpdate, event.Action)
+	assert.Equal(t, bl.AuditEntityMovie, event.EntityType)
+	assert.Equal(t, 1, event.EntityID)
+	assert.Equal(t, "req-update", event.RequestID)
+	assert.Equal(t, testClientIP, event.IP)
+	var before models.Movie
+	assert.NoError(t, json.Unmarshal(event.Before, &before))
+	assert.Equal(t, "Old Title", before.Title)
+	assert.NotEmpty(t, before.ReleaseDate)
+	assert.NotEmpty(t, event.After)
+
+	_, err = exempl.DeleteMovie(auditCtx("editor", "req-delete"), 1)
+	assert.NoError(t, err)
+	event = audit.last()
+	assert.Equal(t, bl.AuditActionDelete, event.Action)
+	assert.NotEmpty(t, event.Before)
+	assert.Empty(t, event.After)
+
+	_, err = exempl.CreateActor(auditCtx("editor", "req-create"), repo.Actor{Name: "Audited", Gender: "female", BirthDate: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)})
+	assert.NoError(t, err)
+	event = audit.last()
+	assert.Equal(t, bl.AuditActionCreate, event.Action)
+	assert.Equal(t, bl.AuditEntityActor, event.EntityType)
+	assert.Empty(t, event.Before)
+	var after models.Actor
+	assert.NoError(t, json.Unmarshal(event.After, &after))
+	assert.Equal(t, "1990-01-02", after.BirthDate)
+
+	count := len(audit.events)
+	_, err = exempl.CreateActor(auditCtx("editor", "req-failed"), repo.Actor{Name: "err"})
+	assert.Error(t, err)
+	assert.Len(t, audit.events, count, "failed writes are not recorded")
+}
+
+func TestAuditFailureIsReported(t *testing.T) {
+	audit := mok.Audit.(*mockAuditRepo)
+	audit.mu.Lock()
+	audit.fail = errors.New("audit is down")
+	audit.mu.Unlock()
+	defer func() {
+		audit.mu.Lock()
+		audit.fail = nil
+		audit.mu.Unlock()
+	}()
+
+	_, err := exempl.UpdateMovie(auditCtx("editor", "req-lost"), repo.Movie{ID: 1, Title: "New Title"})
+	assert.True(t, errors.Is(err, bl.ErrAuditFailed))
+
+	contr := handlers.NewController(exempl, testOptions, zap.NewExample())
+	req := httptest.NewRequest(http.MethodDelete, "/api/movie?id=1", nil).WithContext(auditCtx("editor", "req-lost"))
+	rec := httptest.NewRecorder()
+	contr.DeleteMovie(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), bl.ErrAuditFailed.Error())
+}
+
+func TestGetAuditEvents(t *testing.T) {
+	_, err := exempl.DeleteActor(auditCtx("auditor-target", "req-query"), "test")
+	assert.NoError(t, err)
+
+	contr := handlers.NewController(exempl, testOptions, zap.NewExample())
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit?"+query, nil)
+		rec := httptest.NewRecorder()
+		contr.GetAuditEvents(rec, req)
+		return rec
+	}
+
+	rec := get("entity=actor&user=auditor-target&from=" + time.Now().Add(-time.Minute).Format(time.RFC3339))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var page models.AuditPage
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Equal(t, 1, page.Total)
+	assert.Equal(t, "req-query", page.Events[0].RequestID)
+	assert.Equal(t, bl.AuditActionDelete, page.Events[0].Action)
+
+	rec = get("user=auditor-target&to=" + time.Now().Add(-time.Minute).Format(time.RFC3339))
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Equal(t, 0, page.Total)
+
+	assert.Equal(t, http.StatusBadRequest, get("from=yesterday").Code)
+	assert.Equal(t, http.StatusBadRequest, get("entityId=-1").Code)
+}
+
+func TestRequestID(t *testing.T) {
+	contr := handlers.NewController(exempl, testOptions, zap.NewExample())
+	var info bl.RequestInfo
+	handler := contr.GlobalMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, _ = bl.RequestInfoFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+	req.Header.Set("X-Request-ID", "trace-42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "trace-42", rec.Header().Get("X-Request-ID"))
+	assert.Equal(t, "trace-42", info.ID)
+	assert.Equal(t, "192.0.2.1", info.IP)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+	req.Header.Set("X-Request-ID", "bad id\n")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Len(t, rec.Header().Get("X-Request-ID"), 32)
+	assert.Equal(t, rec.Header().Get("X-Request-ID"), info.ID)
+}
@@ -1,11 +1,15 @@
 package tests_test
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"slices"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 	"vk-inter-test-go/internal/bl"
@@ -24,6 +28,17 @@ func init() {
 	s.Logger.Info("init")
 }
 
+var testKeys *utilsJwt.KeySet
+
+func init() {
+	var err error
+	testKeys, err = utilsJwt.NewKeySet(utilsJwt.AlgHS256, "test", map[string][]byte{"test": []byte("test-key-test-key-test-key-test-key")})
+	if err != nil {
+		panic(err)
+	}
+	utilsJwt.SetKeySet(testKeys)
+}
+
 type mockMovieRepo struct {
 	mock.Mock
 }
@@ -33,6 +48,16 @@ type mockActorMovieRepo struct{}
 type mockUserRepo struct{}
 type mockRoleRepo struct{}
 
+var (
+	mockRoles           = map[string]int{"user": 1, "admin": 2}
+	mockUserRoles       = map[int]string{1: "admin"}
+	mockRolePermissions = map[string][]string{
+		"admin": {"actor:delete", "actor:write", "apikey:manage", "audit:read", "genre:manage", "movie:delete", "movie:write", "role:manage", "user:manage"},
+		"user":  {},
+	}
+	mockRoleLoads int
+)
+
 func (m mockRoleRepo) GetRoleById(id int) (repo.Role, error) {
 	return repo.Role{
 		ID:   2,
@@ -40,23 +65,147 @@ func (m mockRoleRepo) GetRoleById(id int) (repo.Role, error) {
 	}, nil
 }
 
+func (m mockRoleRepo) GetRoleByName(name string) (repo.Role, error) {
+	id, ok := mockRoles[name]
+	if !ok {
+		return repo.Role{}, errors.New("err")
+	}
+	return repo.Role{ID: id, Name: name}, nil
+}
+
+func (m mockRoleRepo) GetRoles() ([]repo.Role, error) {
+	var roles []repo.Role
+	for name, id := range mockRoles {
+		roles = append(roles, repo.Role{ID: id, Name: name, Permissions: mockRolePermissions[name]})
+	}
+	return roles, nil
+}
+
+func (m mockRoleRepo) CreateRole(role *repo.Role) error {
+	if _, ok := mockRoles[role.Name]; ok {
+		return errors.New("role exists")
+	}
+	role.ID = len(mockRoles) + 1
+	mockRoles[role.Name] = role.ID
+	mockRolePermissions[role.Name] = role.Permissions
+	return nil
+}
+
+func (m mockRoleRepo) SetRolePermissions(roleID int, permissions []string) error {
+	for name, id := range mockRoles {
+		if id == roleID {
+			mockRolePermissions[name] = permissions
+			return nil
+		}
+	}
+	return errors.New("err")
+}
+
+func (m mockRoleRepo) DeleteRoleByName(name string) (int64, error) {
+	if _, ok := mockRoles[name]; !ok {
+		return 0, nil
+	}
+	delete(mockRoles, name)
+	delete(mockRolePermissions, name)
+	return 1, nil
+}
+
+func (m mockRoleRepo) GetPermissions() ([]string, error) {
+	return mockRolePermissions["admin"], nil
+}
+
+func (m mockRoleRepo) GetPermissionsByRoleName(name string) ([]string, error) {
+	permissions, ok := mockRolePermissions[name]
+	if !ok {
+		return nil, errors.New("err")
+	}
+	return permissions, nil
+}
+
+func (m mockRoleRepo) GetRoleByUserId(userID int) (repo.Role, error) {
+	mockRoleLoads++
+	name, ok := mockUserRoles[userID]
+	if !ok || mockDisabled[userID] {
+		return repo.Role{}, errors.New("err")
+	}
+	return repo.Role{ID: mockRoles[name], Name: name}, nil
+}
+
 func (m mockUserRepo) GetUserByLogin(login string) (repo.User, error) {
 	if login != "testuser" {
 		return repo.User{}, errors.New("err")
 	}
-	password, _ := utilsJwt.HashPassword("password")
+	password, ok := mockPasswordHashes[1]
+	if !ok {
+		password = mockBcryptHash
+	}
 
 	return repo.User{
-		Login:  "testuser",
-		Pass:   password,
-		RoleID: 2,
+		ID:       1,
+		Login:    "testuser",
+		Pass:     password,
+		RoleID:   2,
+		Role:     mockUserRoles[1],
+		Disabled: mockDisabled[1],
+
+		MustChangePassword: mockMustChangePassword[1],
+		Email:              mockEmails[1],
+		EmailVerified:      mockEmailVerified[1],
+		DisplayName:        mockDisplayNames[1],
 	}, nil
 }
 
-func (m mockUserRepo) CreateUser(user repo.User) error {
+func (m mockUserRepo) GetUserById(id int) (repo.User, error) {
+	if user, ok := mockExtraUsers[id]; ok {
+		user.Role = mockUserRoles[id]
+		user.Disabled = mockDisabled[id]
+		user.MustChangePassword = mockMustChangePassword[id]
+		user.Email = mockEmails[id]
+		user.EmailVerified = mockEmailVerified[id]
+		user.DisplayName = mockDisplayNames[id]
+		return user, nil
+	}
+	if id != 1 {
+		return repo.User{}, errors.New("err")
+	}
+	return m.GetUserByLogin("testuser")
+}
+
+func (m mockUserRepo) CreateUser(user *repo.User) error {
+	if user.Login == "testuser" {
+		user.ID = 1
+		return nil
+	}
+	for _, existing := range mockExtraUsers {
+		if existing.Login == user.Login {
+			return repo.ErrLoginTaken
+		}
+	}
+	user.ID = 100 + len(mockExtraUsers)
+	mockExtraUsers[user.ID] = *user
+	mockUserRoles[user.ID] = "user"
+	for name, id := range mockRoles {
+		if id == user.RoleID {
+			mockUserRoles[user.ID] = name
+		}
+	}
 	return nil
 }
 
+func (m mockUserRepo) SetUserRole(userID int, roleID int, keep string) (int64, error) {
+	return mockKeepHolder(keep, func() (int64, error) {
+		if _, ok := mockUserRoles[userID]; !ok {
+			return 0, nil
+		}
+		for name, id := range mockRoles {
+			if id == roleID {
+				mockUserRoles[userID] = name
+			}
+		}
+		return 1, nil
+	})
+}
+
 var (
 	mok = &db.DBRepo{
 		User:       &mockUserRepo{},
@@ -64,43 +213,109 @@ var (
 		Actor:      &mockActorRepo{},
 		Movie:      &mockMovieRepo{},
 		MovieActor: &mockActorMovieRepo{},
+		Refresh:    newMockRefreshRepo(),
+		Login:      newMockLoginAttemptRepo(),
+		ApiKey:     newMockApiKeyRepo(),
+		UserToken:  newMockUserTokenRepo(),
+		Mfa:        newMockMfaRepo(),
+		Genre:      newMockGenreRepo(),
+		Review:     newMockReviewRepo(),
+		Audit:      newMockAuditRepo(),
+		Oidc:       newMockOidcRepo(),
 	}
 
-	exempl = bl.NewBL(mok, zap.NewExample())
+	testOptions = config.OptionsSrv{
+		AccessTTL:    time.Hour,
+		RefreshTTL:   time.Hour * 24,
+		RoleCacheTTL: time.Minute,
+
+		PasswordMinLength:  8,
+		PasswordMinClasses: 2,
+
+		EmailVerifyTTL:   time.Hour,
+		PasswordResetTTL: time.Hour,
+
+		MfaIssuer:     "Filmoteka",
+		MfaPendingTTL: time.Minute,
+
+		PasswordHash:  utilsJwt.HashArgon2id,
+		Argon2Memory:  1024,
+		Argon2Time:    1,
+		Argon2Threads: 1,
+		BcryptCost:    bcrypt.MinCost,
+	}
+
+	exempl = newTestBL(testOptions)
+
+	// mockBcryptHash is the legacy hash of "password" stored for testuser.
+	mockBcryptHash, _ = utilsJwt.BcryptHasher{Cost: bcrypt.MinCost}.Hash("password")
 )
 
-func (m mockMovieRepo) CreateMovie(movie *repo.Movie) error {
-	return nil
+func newTestBL(options config.OptionsSrv) *bl.BL {
+	inst, err := bl.NewBL(mok, options, testMailer, zap.NewExample())
+	if err != nil {
+		panic(err)
+	}
+	return inst
 }
 
-func (m mockMovieRepo) GetMovieMapByIDs(movieIDs []int, orderBy string) (map[int]repo.Movie, error) {
+func (m *mockMovieRepo) CreateMovie(movie *repo.Movie, credits []repo.PersonCredit) ([]repo.MovieActor, []repo.Actor, error) {
+	var resolved []repo.MovieActor
+	var created []repo.Actor
+	seen := map[repo.MovieActor]bool{}
+	for _, credit := range credits {
+		if credit.ActorID == 0 {
+			person := credit.Person
+			if err := mok.Actor.CreateActor(&person); err != nil {
+				return nil, nil, err
+			}
+			created = append(created, person)
+			credit.ActorID = person.ID
+		}
+		// the mock persons share ids, so only the crew is checked for duplicates
+		key := repo.MovieActor{ActorID: credit.ActorID, CreditType: credit.CreditType}
+		if credit.CreditType != repo.CreditActor && seen[key] {
+			return nil, nil, repo.ErrCreditExists
+		}
+		seen[key] = true
+		resolved = append(resolved, credit.MovieActor)
+	}
+	for _, credit := range resolved {
+		if credit.CreditType != repo.CreditActor {
+			addMockCrew(movie.ID, credit)
+		}
+	}
+	return resolved, created, nil
+}
+
+func (m *mockMovieRepo) GetMovieMapByIDs(movieIDs []int, orderBy string) (map[int]repo.Movie, error) {
 	res := make(map[int]repo.Movie)
 	res[1] = repo.Movie{
-		ID:              1,
-		Title:           "Oppenheimer",
-		Description:     "The story of J. Robert Oppenheimer's role in the development of the atomic bomb during World War II.",
-		ReleaseDateJson: "2023-07-21",
-		Rating:          8,
+		ID:          1,
+		Title:       "Oppenheimer",
+		Description: "The story of J. Robert Oppenheimer's role in the development of the atomic bomb during World War II.",
+		ReleaseDate: time.Date(2023, 7, 21, 0, 0, 0, 0, time.UTC),
+		Rating:      8,
 	}
 	res[2] = repo.Movie{
-		ID:              2,
-		Title:           "Retreat",
-		Description:     "Kate and Martin escape from personal tragedy to an Island Retreat. Cut off from the outside world, their attempts to recover are shattered when a man is washed ashore, with news of airborne killer disease that is sweeping through Europe.",
-		ReleaseDateJson: "2011-10-14",
-		Rating:          5,
+		ID:          2,
+		Title:       "Retreat",
+		Description: "Kate and Martin escape from personal tragedy to an Island Retreat. Cut off from the outside world, their attempts to recover are shattered when a man is washed ashore, with news of airborne killer disease that is sweeping through Europe.",
+		ReleaseDate: time.Date(2011, 10, 14, 0, 0, 0, 0, time.UTC),
+		Rating:      5,
 	}
 	return res, nil
 }
 
-func (m mockMovieRepo) DeleteMovieById(id int) (int64, error) {
+func (m *mockMovieRepo) DeleteMovieById(id int) (int64, error) {
 	return 1, nil
 }
 
-func (m mockMovieRepo) UpdateMovie(movie repo.Movie) (int64, error) {
+func (m *mockMovieRepo) UpdateMovie(movie repo.Movie) (int64, error) {
 	return 1, nil
 }
 
-func (m mockMovieRepo) GetMovieById(id int) (repo.Movie, error) {
+func (m *mockMovieRepo) GetMovieById(id int) (repo.Movie, error) {
 	if id > 200 {
 		return repo.Movie{}, errors.New("err")
 	}
@@ -113,25 +328,37 @@ func (m mockMovieRepo) GetMovieById(id int) (repo.Movie, error) {
 	}, nil
 }
 
-func (m mockMovieRepo) GetMoviesLikeTitle(title string, orderBy string) ([]repo.Movie, error) {
-	res := []repo.Movie{
-		{ID: 1,
-			Title:           "Oppenheimer",
-			Description:     "The story of J. Robert Oppenheimer's role in the development of the atomic bomb during World War II.",
-			ReleaseDateJson: "2023-07-21",
-			Rating:          8},
+func (m *mockMovieRepo) GetMovies(filter repo.MovieFilter, orderBy string) ([]repo.Movie, error) {
+	all, _ := m.GetMovieMapByIDs(nil, orderBy)
+	var res []repo.Movie
+	for _, id := range []int{1, 2} {
+		movie := all[id]
+		if !strings.Contains(movie.Title, filter.Title) {
+			continue
+		}
+		if len(filter.Actor) > 0 && !hasActor(id, filter.Actor) {
+			continue
+		}
+		if len(filter.Genre) > 0 && !mok.Genre.(*mockGenreRepo).hasGenre(id, filter.Genre) {
+			continue
+		}
+		if len(filter.Director) > 0 && !hasDirector(id) {
+			continue
+		}
+		res = append(res, movie)
 	}
 	return res, nil
 }
 
-func (m mockActorMovieRepo) CreateMovieActorRelation(movieID int, actorIDs []int) error {
-	return nil
-}
-
-func (m mockActorMovieRepo) GetRelationByMovieIDs(movieIDs []int) (map[int][]int, error) {
-	res := make(map[int][]int)
-	res[1] = []int{1}
-	res[2] = []int{1}
+func (m mockActorMovieRepo) GetCastByMovieIDs(movieIDs []int) (map[int][]repo.MovieActor, error) {
+	mockCastMu.Lock()
+	defer mockCastMu.Unlock()
+	res := make(map[int][]repo.MovieActor)
+	for _, movieID := range movieIDs {
+		if cast, ok := mockCast[movieID]; ok {
+			res[movieID] = append([]repo.MovieActor(nil), cast...)
+		}
+	}
 	return res, nil
 }
 
@@ -168,10 +395,10 @@ func (m *mockActorRepo) GetAllActorsLikeName(name string, orderBy string) ([]rep
 	}
 	res := []repo.Actor{
 		{
-			ID:            1,
-			Name:          "Cillian Murphy",
-			Gender:        "male",
-			BirthDateJson: "1976-05-25",
+			ID:        1,
+			Name:      "Cillian Murphy",
+			Gender:    "male",
+			BirthDate: time.Date(1976, 5, 25, 0, 0, 0, 0, time.UTC),
 		},
 	}
 	return res, nil
@@ -182,11 +409,10 @@ func (m *mockActorRepo) GetActorById(id int) (repo.Actor, error) {
 		return repo.Actor{}, errors.New("err")
 	}
 	return repo.Actor{
-		ID:            1,
-		Name:          "test",
-		Gender:        "male",
-		BirthDateJson: "1984-02-24",
-		BirthDate:     time.Now(),
+		ID:        1,
+		Name:      "test",
+		Gender:    "male",
+		BirthDate: time.Date(1984, 2, 24, 0, 0, 0, 0, time.UTC),
 	}, nil
 }
 
@@ -197,6 +423,9 @@ func (m *mockActorRepo) GetActorByName(name string) (repo.Actor, error) {
 	if name == "Actor2" {
 		return repo.Actor{ID: 2, Name: "Actor2"}, nil
 	}
+	if name == "test" {
+		return repo.Actor{ID: 1, Name: "test", Gender: "male"}, nil
+	}
 	return repo.Actor{}, errors.New("err")
 }
 
@@ -204,12 +433,15 @@ func (m *mockActorRepo) GetActorMapByIDs(actorIDs []int) (map[int][]repo.Actor,
 	res := make(map[int][]repo.Actor)
 	res[1] = []repo.Actor{
 		{
-			ID:            1,
-			Name:          "Cillian Murphy",
-			Gender:        "male",
-			BirthDateJson: "1976-05-25",
+			ID:        1,
+			Name:      "Cillian Murphy",
+			Gender:    "male",
+			BirthDate: time.Date(1976, 5, 25, 0, 0, 0, 0, time.UTC),
 		},
 	}
+	if slices.Contains(actorIDs, 2) {
+		res[2] = []repo.Actor{{ID: 2, Name: "Actor2", Gender: "female", BirthDate: time.Date(1983, 6, 20, 0, 0, 0, 0, time.UTC)}}
+	}
 	return res, nil
 }
 
@@ -227,14 +459,13 @@ func (m *mockActorRepo) CreateActor(actor *repo.Actor) error {
 
 func TestCreateActor1(t *testing.T) {
 	actor := repo.Actor{
-		ID:            0,
-		Name:          "test",
-		Gender:        "male",
-		BirthDateJson: "1984-02-24",
-		BirthDate:     time.Time{},
+		ID:        0,
+		Name:      "test",
+		Gender:    "male",
+		BirthDate: time.Date(1984, 2, 24, 0, 0, 0, 0, time.UTC),
 	}
 
-	actorNew, err := exempl.CreateActor(actor)
+	actorNew, err := exempl.CreateActor(context.Background(), actor)
 
 	assert.NoError(t, err, "Unexpected error during actor creation")
 
@@ -242,14 +473,13 @@ func TestCreateActor1(t *testing.T) {
 }
 func TestCreateActor2(t *testing.T) {
 	actor := repo.Actor{
-		ID:            0,
-		Name:          "err",
-		Gender:        "male",
-		BirthDateJson: "1984-02-24",
-		BirthDate:     time.Time{},
+		ID:        0,
+		Name:      "err",
+		Gender:    "male",
+		BirthDate: time.Date(1984, 2, 24, 0, 0, 0, 0, time.UTC),
 	}
 
-	actorNew, err := exempl.CreateActor(actor)
+	actorNew, err := exempl.CreateActor(context.Background(), actor)
 
 	assert.Error(t, err, "Unexpected error during actor creation")
 
@@ -261,38 +491,42 @@ func TestGetAllMoviesByNameActor(t *testing.T) {
 
 	expectedMovies := []models.MovieIo{
 		{
-			Movie: repo.Movie{
-				ID:              1,
-				Title:           "Oppenheimer",
-				Description:     "The story of J. Robert Oppenheimer's role in the development of the atomic bomb during World War II.",
-				ReleaseDateJson: "2023-07-21",
-				Rating:          8,
+			Movie: models.Movie{
+				ID:          1,
+				Title:       "Oppenheimer",
+				Description: "The story of J. Robert Oppenheimer's role in the development of the atomic bomb during World War II.",
+				ReleaseDate: "2023-07-21",
+				Rating:      8,
 			},
-			Actors: []repo.Actor{
-				{
-					ID:            1,
-					Name:          "Cillian Murphy",
-					Gender:        "male",
-					BirthDateJson: "1976-05-25",
-				},
+			Actors: []models.CastMember{
+				{Actor: models.Actor{
+					ID:        1,
+					Name:      "Cillian Murphy",
+					Gender:    "male",
+					BirthDate: "1976-05-25",
+				}},
 			},
+			Crew:   []models.CrewMember{},
+			Genres: []models.Genre{},
 		},
 		{
-			Movie: repo.Movie{
-				ID:              2,
-				Title:           "Retreat",
-				Description:     "Kate and Martin escape from personal tragedy to an Island Retreat. Cut off from the outside world, their attempts to recover are shattered when a man is washed ashore, with news of airborne killer disease that is sweeping through Europe.",
-				ReleaseDateJson: "2011-10-14",
-				Rating:          5,
+			Movie: models.Movie{
+				ID:          2,
+				Title:       "Retreat",
+				Description: "Kate and Martin escape from personal tragedy to an Island Retreat. Cut off from the outside world, their attempts to recover are shattered when a man is washed ashore, with news of airborne killer disease that is sweeping through Europe.",
+				ReleaseDate: "2011-10-14",
+				Rating:      5,
 			},
-			Actors: []repo.Actor{
-				{
-					ID:            1,
-					Name:          "Cillian Murphy",
-					Gender:        "male",
-					BirthDateJson: "1976-05-25",
-				},
+			Actors: []models.CastMember{
+				{Actor: models.Actor{
+					ID:        1,
+					Name:      "Cillian Murphy",
+					Gender:    "male",
+					BirthDate: "1976-05-25",
+				}},
 			},
+			Crew:   []models.CrewMember{},
+			Genres: []models.Genre{},
 		},
 	}
 
@@ -312,27 +546,26 @@ func TestGetAllMoviesByNameActor(t *testing.T) {
 }
 
 func TestDeleteActor1(t *testing.T) {
-	deletedCount, err := exempl.DeleteActor("test")
+	deletedCount, err := exempl.DeleteActor(context.Background(), "test")
 	assert.NoError(t, err, "Unexpected error")
 	assert.Equal(t, int64(1), deletedCount, "Expected one actor to be deleted")
 }
 
 func TestDeleteActor2(t *testing.T) {
-	deletedCount, _ := exempl.DeleteActor("tet")
+	deletedCount, _ := exempl.DeleteActor(context.Background(), "tet")
 	assert.Equal(t, int64(0), deletedCount, "Expected one actor to be deleted")
 }
 
 func TestUpdateActor1(t *testing.T) {
 
 	actor := repo.Actor{
-		ID:            1,
-		Name:          "test",
-		Gender:        "male",
-		BirthDateJson: "1984-02-24",
-		BirthDate:     time.Now(),
+		ID:        1,
+		Name:      "test",
+		Gender:    "male",
+		BirthDate: time.Date(1984, 2, 24, 0, 0, 0, 0, time.UTC),
 	}
 
-	updatedActor, err := exempl.UpdateActor(actor)
+	updatedActor, err := exempl.UpdateActor(context.Background(), actor)
 
 	assert.NoError(t, err, "Unexpected error")
 	assert.Equal(t, actor.ID, updatedActor.ID, "Expected ID to match")
@@ -345,7 +578,7 @@ func TestUpdateActor2(t *testing.T) {
 		ID: 1,
 	}
 
-	updatedActor, err := exempl.UpdateActor(actor)
+	updatedActor, err := exempl.UpdateActor(context.Background(), actor)
 
 	assert.NoError(t, err, "Unexpected error")
 	assert.Equal(t, actor.ID, updatedActor.ID, "Expected ID to match")
@@ -358,7 +591,7 @@ func TestUpdateActor3(t *testing.T) {
 		ID: -1,
 	}
 
-	updatedActor, err := exempl.UpdateActor(actor)
+	updatedActor, err := exempl.UpdateActor(context.Background(), actor)
 
 	assert.Error(t, err, "Unexpected error")
 	assert.Equal(t, 0, updatedActor.ID, "Expected ID to match")
@@ -370,7 +603,7 @@ func TestUpdateActor4(t *testing.T) {
 		Name: "err",
 	}
 
-	updatedActor, err := exempl.UpdateActor(actor)
+	updatedActor, err := exempl.UpdateActor(context.Background(), actor)
 
 	assert.Error(t, err, "Unexpected error")
 	assert.Equal(t, "", updatedActor.Name, "Expected Name to match")
@@ -381,25 +614,25 @@ func TestGetAllActorsLikeName1(t *testing.T) {
 	testOrderBy := "rating"
 
 	expectedActors := []models.ActorIo{
-		{Actor: repo.Actor{
-			ID:            1,
-			Name:          "Cillian Murphy",
-			Gender:        "male",
-			BirthDateJson: "1976-05-25",
-		}, Movies: []repo.Movie{
+		{Actor: models.Actor{
+			ID:        1,
+			Name:      "Cillian Murphy",
+			Gender:    "male",
+			BirthDate: "1976-05-25",
+		}, Movies: []models.Movie{
 			{
-				ID:              1,
-				Title:           "Oppenheimer",
-				Description:     "The story of J. Robert Oppenheimer's role in the development of the atomic bomb during World War II.",
-				ReleaseDateJson: "2023-07-21",
-				Rating:          8,
+				ID:          1,
+				Title:       "Oppenheimer",
+				Description: "The story of J. Robert Oppenheimer's role in the development of the atomic bomb during World War II.",
+				ReleaseDate: "2023-07-21",
+				Rating:      8,
 			},
 			{
-				ID:              2,
-				Title:           "Retreat",
-				Description:     "Kate and Martin escape from personal tragedy to an Island Retreat. Cut off from the outside world, their attempts to recover are shattered when a man is washed ashore, with news of airborne killer disease that is sweeping through Europe.",
-				ReleaseDateJson: "2011-10-14",
-				Rating:          5,
+				ID:          2,
+				Title:       "Retreat",
+				Description: "Kate and Martin escape from personal tragedy to an Island Retreat. Cut off from the outside world, their attempts to recover are shattered when a man is washed ashore, with news of airborne killer disease that is sweeping through Europe.",
+				ReleaseDate: "2011-10-14",
+				Rating:      5,
 			},
 		}},
 	}
@@ -432,19 +665,19 @@ func TestGetAllMoviesByTitle(t *testing.T) {
 	testOrderBy := "rating"
 
 	expectedMovies := []models.MovieIo{
-		{Movie: repo.Movie{
-			ID:              1,
-			Title:           "Oppenheimer",
-			Description:     "The story of J. Robert Oppenheimer's role in the development of the atomic bomb during World War II.",
-			ReleaseDateJson: "2023-07-21",
-			Rating:          8,
-		}, Actors: []repo.Actor{{
-			ID:            1,
-			Name:          "Cillian Murphy",
-			Gender:        "male",
-			BirthDateJson: "1976-05-25",
-		},
+		{Movie: models.Movie{
+			ID:          1,
+			Title:       "Oppenheimer",
+			Description: "The story of J. Robert Oppenheimer's role in the development of the atomic bomb during World War II.",
+			ReleaseDate: "2023-07-21",
+			Rating:      8,
+		}, Actors: []models.CastMember{{Actor: models.Actor{
+			ID:        1,
+			Name:      "Cillian Murphy",
+			Gender:    "male",
+			BirthDate: "1976-05-25",
 		}},
+		}, Crew: []models.CrewMember{}, Genres: []models.Genre{}},
 	}
 
 	actualMovies, err := exempl.GetAllMoviesByTitle(testTitle, testOrderBy)
@@ -453,19 +686,31 @@ func TestGetAllMoviesByTitle(t *testing.T) {
 	assert.ElementsMatch(t, expectedMovies, actualMovies, "Movies do not match")
 }
 
+func TestGetMoviesActorNameOverridesTitle(t *testing.T) {
+	movies, err := exempl.GetMovies(repo.MovieFilter{Title: "Retreat", Actor: "Cillian"}, "rating")
+	assert.NoError(t, err)
+	assert.Len(t, movies, 2, "the title is ignored when an actor name is given")
+
+	movies, err = exempl.GetMovies(repo.MovieFilter{Title: "Retreat", Actor: "Nobody"}, "rating")
+	assert.NoError(t, err)
+	assert.Empty(t, movies)
+}
+
 func TestCreateUser(t *testing.T) {
 	testUser := repo.User{
 		Login: "testuser",
-		Pass:  "password",
+		Pass:  "Str0ng-pass",
 	}
 
 	actualToken, err := exempl.CreateUser(testUser)
 
 	assert.NoError(t, err, "Unexpected error")
-	assert.Equal(t, true, exempl.CheckJwt(actualToken), "Generated token does not match")
-	name, _ := utilsJwt.ExtractUsernameFromToken(actualToken)
+	principal, err := exempl.Authenticate(actualToken.Bearer)
+	assert.NoError(t, err, "Generated token does not match")
+	assert.NotEmpty(t, actualToken.Refresh, "Refresh token expected")
 
-	assert.Equal(t, testUser.Login, name, "names does not match")
+	assert.Equal(t, testUser.Login, principal.Login, "names does not match")
+	assert.Equal(t, 1, principal.UserID, "user id does not match")
 
 }
 
@@ -475,10 +720,11 @@ func TestAuthUser(t *testing.T) {
 		Pass:  "password",
 	}
 
-	actualToken, err := exempl.AuthUser(testUser)
+	actualToken, err := exempl.AuthUser(testUser, testClientIP)
 
 	assert.NoError(t, err, "Unexpected error")
-	assert.Equal(t, true, exempl.CheckJwt(actualToken), "Generated token does not match")
+	_, err = exempl.Authenticate(actualToken.Bearer)
+	assert.NoError(t, err, "Generated token does not match")
 }
 
 func TestSanitize(t *testing.T) {
@@ -511,11 +757,20 @@ func TestSanitize(t *testing.T) {
 	}
 }
 
+func checkRole(userID int, role string) bool {
+	ctx := bl.WithPrincipal(context.Background(), bl.Principal{UserID: userID})
+	_, ok := exempl.CheckRole(ctx, role)
+	return ok
+}
+
 func TestCheckRole(t *testing.T) {
-	assert.True(t, exempl.CheckRole("testuser", "admin"))
-	assert.False(t, exempl.CheckRole("test_error", "admin"))
-	assert.False(t, exempl.CheckRole("test", "admin"))
-	assert.False(t, exempl.CheckRole("", "admin"))
+	assert.True(t, checkRole(1, "admin"))
+	assert.False(t, checkRole(1, "user"))
+	assert.False(t, checkRole(2, "admin"))
+	assert.False(t, checkRole(0, "admin"))
+
+	_, ok := exempl.CheckRole(context.Background(), "admin")
+	assert.False(t, ok, "request without principal must be denied")
 }
 
 func TestUpdateMovie(t *testing.T) {
@@ -528,37 +783,39 @@ func TestUpdateMovie(t *testing.T) {
 		Rating:      5,
 	}
 
-	newMovie, err := exempl.UpdateMovie(repo.Movie{})
+	newMovie, err := exempl.UpdateMovie(context.Background(), repo.Movie{})
 	assert.NoError(t, err)
 	assert.Equal(t, mockMovie.Title, newMovie.Title)
 	assert.Equal(t, mockMovie.Description, newMovie.Description)
 	assert.Equal(t, mockMovie.Rating, newMovie.Rating)
 
 	invalidReleaseDate := "invalid date"
-	_, err = exempl.UpdateMovie(repo.Movie{ID: mockMovie.ID, ReleaseDateJson: invalidReleaseDate})
+	_, err = models.Movie{ID: mockMovie.ID, ReleaseDate: invalidReleaseDate}.ToRepo()
 	assert.Error(t, err)
 
-	_, err = exempl.UpdateMovie(repo.Movie{ID: 999})
+	_, err = exempl.UpdateMovie(context.Background(), repo.Movie{ID: 999})
 	assert.Error(t, err)
 }
 
 func TestCreateMovie(t *testing.T) {
 	mockMovie := models.MovieIo{
-		Movie: repo.Movie{
+		Movie: models.Movie{
 			ID:          1,
 			Title:       "Test Movie",
 			Description: "Test Description",
-			ReleaseDate: time.Now(),
+			ReleaseDate: "2023-07-21",
 			Rating:      8,
 		},
-		Actors: []repo.Actor{
-			{ID: 5, Name: "Actor1"},
-			{ID: 2, Name: "Actor2"},
-			{ID: 1, Name: "Actor3"},
+		Actors: []models.CastMember{
+			{Actor: models.Actor{ID: 5, Name: "Actor1"}},
+			{Actor: models.Actor{ID: 2, Name: "Actor2"}},
+			{Actor: models.Actor{ID: 1, Name: "Actor3"}},
 		},
+		Crew:   []models.CrewMember{},
+		Genres: []models.Genre{},
 	}
 
-	createdMovie, err := exempl.CreateMovie(mockMovie)
+	createdMovie, err := exempl.CreateMovie(context.Background(), mockMovie)
 
 	assert.NoError(t, err)
 	assert.Equal(t, mockMovie, createdMovie)
@@ -566,7 +823,7 @@ func TestCreateMovie(t *testing.T) {
 }
 
 func TestDeleteMovie(t *testing.T) {
-	rowsAffected, err := exempl.DeleteMovie(1)
+	rowsAffected, err := exempl.DeleteMovie(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), rowsAffected)
 
@@ -0,0 +1,219 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	routes "vk-inter-test-go/internal/io"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+	utilsJwt "vk-inter-test-go/internal/utils"
+)
+
+var (
+	mockCastMu sync.Mutex
+	mockCast   = defaultCast()
+)
+
+func defaultCast() map[int][]repo.MovieActor {
+	return map[int][]repo.MovieActor{
+		1: {{MovieID: 1, ActorID: 1, CreditType: repo.CreditActor}},
+		2: {{MovieID: 2, ActorID: 1, CreditType: repo.CreditActor}},
+	}
+}
+
+func cleanupCast() {
+	mockCastMu.Lock()
+	defer mockCastMu.Unlock()
+	mockCast = defaultCast()
+}
+
+// hasActor reports whether a cast member of the movie has name in the name.
+func hasActor(movieID int, name string) bool {
+	mockCastMu.Lock()
+	var actorIDs []int
+	for _, credit := range mockCast[movieID] {
+		actorIDs = append(actorIDs, credit.ActorID)
+	}
+	mockCastMu.Unlock()
+	actors, _ := mok.Actor.GetActorMapByIDs(actorIDs)
+	for _, actorID := range actorIDs {
+		for _, actor := range actors[actorID] {
+			if strings.Contains(actor.Name, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mockChangeCast creates the persons of credits and applies change to the cast
+// of the movie, nothing is kept when change fails.
+func mockChangeCast(movieID int, credits []repo.PersonCredit,
+	change func(cast []repo.MovieActor, credits []repo.MovieActor) ([]repo.MovieActor, error)) (repo.CastChange, error) {
+	mockCastMu.Lock()
+	defer mockCastMu.Unlock()
+	var res repo.CastChange
+	resolved := make([]repo.MovieActor, 0, len(credits))
+	for _, credit := range credits {
+		if credit.ActorID == 0 {
+			person := credit.Person
+			if err := mok.Actor.CreateActor(&person); err != nil {
+				return repo.CastChange{}, err
+			}
+			res.Created = append(res.Created, person)
+			credit.ActorID = person.ID
+		}
+		credit.MovieID = movieID
+		resolved = append(resolved, credit.MovieActor)
+	}
+	res.Before = append([]repo.MovieActor(nil), mockCast[movieID]...)
+	after, err := change(res.Before, resolved)
+	if err != nil {
+		return repo.CastChange{}, err
+	}
+	mockCast[movieID] = after
+	res.After = append([]repo.MovieActor(nil), after...)
+	return res, nil
+}
+
+func (m mockActorMovieRepo) AddMovieActor(movieID int, credit repo.PersonCredit) (repo.CastChange, error) {
+	return mockChangeCast(movieID, []repo.PersonCredit{credit}, func(cast []repo.MovieActor, credits []repo.MovieActor) ([]repo.MovieActor, error) {
+		for _, existing := range cast {
+			if existing.ActorID == credits[0].ActorID {
+				return nil, repo.ErrCreditExists
+			}
+		}
+		return append(append([]repo.MovieActor(nil), cast...), credits[0]), nil
+	})
+}
+
+func (m mockActorMovieRepo) DeleteMovieActor(movieID int, actorID int) (repo.CastChange, error) {
+	return mockChangeCast(movieID, nil, func(cast []repo.MovieActor, _ []repo.MovieActor) ([]repo.MovieActor, error) {
+		for i, credit := range cast {
+			if credit.ActorID == actorID {
+				return append(append([]repo.MovieActor(nil), cast[:i]...), cast[i+1:]...), nil
+			}
+		}
+		return nil, repo.ErrCreditNotFound
+	})
+}
+
+func (m mockActorMovieRepo) ReplaceMovieCast(movieID int, credits []repo.PersonCredit) (repo.CastChange, error) {
+	return mockChangeCast(movieID, credits, func(_ []repo.MovieActor, cast []repo.MovieActor) ([]repo.MovieActor, error) {
+		seen := map[int]bool{}
+		for _, credit := range cast {
+			if seen[credit.ActorID] {
+				return nil, repo.ErrCreditExists
+			}
+			seen[credit.ActorID] = true
+		}
+		return cast, nil
+	})
+}
+
+func TestMovieCast(t *testing.T) {
+	defer cleanupCast()
+	ctx := auditCtx("admin", "req-cast")
+	actor2 := models.Actor{Name: "Actor2", Gender: "female", BirthDate: "1983-06-20"}
+
+	cast, err := exempl.AddMovieActor(ctx, models.MovieActorRequest{MovieID: 1, CastMember: models.CastMember{Actor: actor2, Character: "Kitty", Billing: 1}})
+	assert.NoError(t, err)
+	if assert.Len(t, cast, 2) {
+		assert.Equal(t, "Actor2", cast[0].Name, "billed actors come first")
+		assert.Equal(t, "Kitty", cast[0].Character)
+	}
+	event := mok.Audit.(*mockAuditRepo).last()
+	assert.Equal(t, bl.AuditEntityMovie, event.EntityType)
+	assert.Equal(t, 1, event.EntityID)
+
+	_, err = exempl.AddMovieActor(ctx, models.MovieActorRequest{MovieID: 1, CastMember: models.CastMember{Actor: actor2}})
+	assert.ErrorIs(t, err, bl.ErrActorInCast)
+	_, err = exempl.AddMovieActor(ctx, models.MovieActorRequest{MovieID: 999, CastMember: models.CastMember{Actor: actor2}})
+	assert.ErrorIs(t, err, bl.ErrMovieNotFound)
+
+	cast, err = exempl.RemoveMovieActor(ctx, 1, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, cast, 1) {
+		assert.Equal(t, 2, cast[0].ID)
+	}
+	_, err = exempl.RemoveMovieActor(ctx, 1, 1)
+	assert.ErrorIs(t, err, bl.ErrActorNotInCast)
+
+	_, err = exempl.ReplaceMovieCast(ctx, models.MovieCastRequest{MovieID: 1, Actors: []models.CastMember{{Actor: actor2}, {Actor: actor2}}})
+	assert.ErrorIs(t, err, bl.ErrActorInCast)
+	cast, err = exempl.ReplaceMovieCast(ctx, models.MovieCastRequest{MovieID: 1, Actors: []models.CastMember{{Actor: models.Actor{Name: "test"}, Cameo: true}}})
+	assert.NoError(t, err)
+	if assert.Len(t, cast, 1) {
+		assert.Equal(t, "Cillian Murphy", cast[0].Name)
+		assert.True(t, cast[0].Cameo)
+	}
+	cast, err = exempl.ReplaceMovieCast(ctx, models.MovieCastRequest{MovieID: 1})
+	assert.NoError(t, err)
+	assert.NotNil(t, cast)
+	assert.Empty(t, cast)
+}
+
+func TestMovieCastCreatesPersonsWithTheCast(t *testing.T) {
+	defer cleanupCast()
+	ctx := auditCtx("admin", "req-cast-persons")
+	audit := mok.Audit.(*mockAuditRepo)
+	newcomer := models.Actor{Name: "Newcomer", Gender: "male", BirthDate: "1990-01-01"}
+
+	events := len(audit.events)
+	_, err := exempl.ReplaceMovieCast(ctx, models.MovieCastRequest{MovieID: 1, Actors: []models.CastMember{{Actor: newcomer}, {Actor: newcomer}}})
+	assert.ErrorIs(t, err, bl.ErrActorInCast)
+	assert.Len(t, audit.events, events, "persons of a failed change are neither kept nor audited")
+	_, err = exempl.ReplaceMovieCast(ctx, models.MovieCastRequest{MovieID: 1, Actors: []models.CastMember{{Actor: models.Actor{Name: "Unknown", BirthDate: "someday"}}}})
+	assert.ErrorIs(t, err, bl.ErrInvalidActor)
+
+	_, err = exempl.ReplaceMovieCast(ctx, models.MovieCastRequest{MovieID: 1, Actors: []models.CastMember{{Actor: newcomer}}})
+	assert.NoError(t, err)
+	if assert.Len(t, audit.events, events+2) {
+		created := audit.events[events]
+		assert.Equal(t, bl.AuditEntityActor, created.EntityType)
+		assert.Equal(t, bl.AuditActionCreate, created.Action)
+		assert.Contains(t, string(created.After), "Newcomer")
+		assert.Equal(t, bl.AuditEntityMovie, audit.last().EntityType)
+	}
+}
+
+func TestMovieCastEndpoints(t *testing.T) {
+	defer cleanupCast()
+	mux := routes.SetupRoutes(handlers.NewController(exempl, testOptions, zap.NewExample()))
+	admin, err := utilsJwt.GenerateToken(time.Hour, utilsJwt.TokenClaims{Subject: "testuser", UserID: 1})
+	assert.NoError(t, err)
+
+	call := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+admin)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call(http.MethodPost, "/api/movie/cast", `{"movieId":1,"name":"Actor2","gender":"female","birthDate":"1983-06-20","character":"Kitty","billing":1}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var cast []models.CastMember
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cast))
+	assert.Len(t, cast, 2)
+
+	assert.Equal(t, http.StatusConflict, call(http.MethodPost, "/api/movie/cast", `{"movieId":1,"name":"Actor2","gender":"female","birthDate":"1983-06-20"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/movie/cast", `{"movieId":1,"name":"Actor2","gender":"female","birthDate":"1983-06-20","billing":-1}`).Code)
+	assert.Equal(t, http.StatusOK, call(http.MethodDelete, "/api/movie/cast?movieId=1&actorId=2", "").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, "/api/movie/cast?movieId=1&actorId=2", "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodDelete, "/api/movie/cast?movieId=1", "").Code)
+	assert.Equal(t, http.StatusOK, call(http.MethodPut, "/api/movie/cast", `{"movieId":1,"actors":[]}`).Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodPut, "/api/movie/cast", `{"movieId":999,"actors":[]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodGet, "/api/movie/cast", "").Code)
+}
@@ -0,0 +1,141 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/ioutils"
+	"vk-inter-test-go/internal/io/models"
+)
+
+var (
+	mockCrewMu sync.Mutex
+	mockCrew   = map[int][]repo.MovieActor{}
+)
+
+func addMockCrew(movieID int, credit repo.MovieActor) {
+	mockCrewMu.Lock()
+	defer mockCrewMu.Unlock()
+	credit.MovieID = movieID
+	mockCrew[movieID] = append(mockCrew[movieID], credit)
+}
+
+func (m mockActorMovieRepo) GetCrewByMovieIDs(movieIDs []int) (map[int][]repo.MovieActor, error) {
+	mockCrewMu.Lock()
+	defer mockCrewMu.Unlock()
+	res := make(map[int][]repo.MovieActor)
+	for _, movieID := range movieIDs {
+		if crew, ok := mockCrew[movieID]; ok {
+			res[movieID] = crew
+		}
+	}
+	return res, nil
+}
+
+func hasDirector(movieID int) bool {
+	mockCrewMu.Lock()
+	defer mockCrewMu.Unlock()
+	for _, credit := range mockCrew[movieID] {
+		if credit.CreditType == repo.CreditDirector {
+			return true
+		}
+	}
+	return false
+}
+
+func cleanupCrew() {
+	mockCrewMu.Lock()
+	defer mockCrewMu.Unlock()
+	mockCrew = map[int][]repo.MovieActor{}
+}
+
+func TestMovieCrew(t *testing.T) {
+	defer cleanupCrew()
+
+	director := models.Actor{Name: "Christopher Nolan", Gender: "male", BirthDate: "1970-07-30"}
+	movie := models.MovieIo{
+		Movie:  models.Movie{ID: 1, Title: "Oppenheimer", ReleaseDate: "2023-07-21", Rating: 8},
+		Actors: []models.CastMember{{Actor: models.Actor{Name: "test"}}},
+		Crew: []models.CrewMember{
+			{Actor: director, Job: repo.CreditDirector},
+			{Actor: director, Job: repo.CreditWriter},
+		},
+	}
+	assert.True(t, ioutils.MovieJsonValidate(models.MovieIo{Movie: movie.Movie, Crew: movie.Crew}))
+	assert.False(t, ioutils.MovieJsonValidate(models.MovieIo{Movie: movie.Movie, Crew: []models.CrewMember{{Actor: director, Job: repo.CreditActor}}}),
+		"the cast is not part of the crew")
+	assert.False(t, ioutils.MovieJsonValidate(models.MovieIo{Movie: movie.Movie, Crew: []models.CrewMember{
+		{Actor: director, Job: repo.CreditDirector}, {Actor: director, Job: repo.CreditDirector},
+	}}), "a person is credited once per job")
+	assert.False(t, ioutils.MovieJsonValidate(models.MovieIo{Movie: movie.Movie, Actors: []models.CastMember{{Actor: director}, {Actor: director}}}),
+		"a person is credited once in the cast")
+
+	created, err := exempl.CreateMovie(context.Background(), movie)
+	assert.NoError(t, err)
+	assert.Len(t, created.Crew, 2)
+	assert.Equal(t, 1, created.Crew[0].ID, "the person is created")
+
+	movies, err := exempl.GetMovies(repo.MovieFilter{Director: "Nolan"}, "")
+	assert.NoError(t, err)
+	if assert.Len(t, movies, 1) {
+		assert.Equal(t, 1, movies[0].Movie.ID)
+		var jobs []string
+		for _, member := range movies[0].Crew {
+			jobs = append(jobs, member.Job)
+		}
+		assert.ElementsMatch(t, []string{repo.CreditDirector, repo.CreditWriter}, jobs)
+		assert.NotEmpty(t, movies[0].Actors, "the cast is listed separately")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/movie?director=Nolan&title=Retreat", nil)
+	rec := httptest.NewRecorder()
+	handlers.NewController(exempl, testOptions, zap.NewExample()).GetMovies(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code, "filters are combined")
+}
+
+func TestCreateMovieDuplicateCrew(t *testing.T) {
+	defer cleanupCrew()
+
+	// the names differ, so validation lets it through, but both name the same
+	// person in the mock
+	movie := models.MovieIo{
+		Movie: models.Movie{ID: 2, Title: "Retreat", ReleaseDate: "2023-07-21", Rating: 8},
+		Crew: []models.CrewMember{
+			{Actor: models.Actor{Name: "test"}, Job: repo.CreditDirector},
+			{Actor: models.Actor{Name: "Actor1"}, Job: repo.CreditDirector},
+		},
+	}
+	_, err := exempl.CreateMovie(context.Background(), movie)
+	assert.ErrorIs(t, err, bl.ErrDuplicateCredit)
+	assert.False(t, hasDirector(2), "no credits are kept")
+}
+
+func TestMovieCastBilling(t *testing.T) {
+	movie := models.MovieIo{
+		Movie: models.Movie{ID: 1, Title: "Oppenheimer", ReleaseDate: "2023-07-21", Rating: 8},
+		Actors: []models.CastMember{
+			{Actor: models.Actor{Name: "Actor2", Gender: "female", BirthDate: "1983-06-20"}, Character: "Kitty Oppenheimer", Billing: 3},
+			{Actor: models.Actor{Name: "test", Gender: "male", BirthDate: "1976-05-25"}, Character: "J. Robert Oppenheimer", Billing: 1},
+			{Actor: models.Actor{Name: "Actor1", Gender: "male", BirthDate: "1958-08-13"}, Character: "Himself", Cameo: true},
+		},
+	}
+	assert.True(t, ioutils.MovieJsonValidate(movie))
+	assert.False(t, ioutils.MovieJsonValidate(models.MovieIo{Movie: movie.Movie, Actors: []models.CastMember{{Actor: movie.Actors[0].Actor, Billing: -1}}}))
+
+	created, err := exempl.CreateMovie(context.Background(), movie)
+	assert.NoError(t, err)
+	var characters []string
+	for _, member := range created.Actors {
+		characters = append(characters, member.Character)
+	}
+	assert.Equal(t, []string{"J. Robert Oppenheimer", "Kitty Oppenheimer", "Himself"}, characters, "billed actors come first")
+	assert.True(t, created.Actors[2].Cameo)
+}
@@ -0,0 +1,175 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	routes "vk-inter-test-go/internal/io"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+	utilsJwt "vk-inter-test-go/internal/utils"
+)
+
+type mockGenreRepo struct {
+	mu          sync.Mutex
+	genres      map[string]int
+	movieGenres map[int][]string
+}
+
+func newMockGenreRepo() *mockGenreRepo {
+	return &mockGenreRepo{genres: map[string]int{}, movieGenres: map[int][]string{}}
+}
+
+func (m *mockGenreRepo) CreateGenre(genre *repo.Genre) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.genres[genre.Name]; ok {
+		return repo.ErrGenreTaken
+	}
+	genre.ID = len(m.genres) + 1
+	m.genres[genre.Name] = genre.ID
+	return nil
+}
+
+func (m *mockGenreRepo) GetGenres() ([]repo.Genre, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var genres []repo.Genre
+	for name, id := range m.genres {
+		genres = append(genres, repo.Genre{ID: id, Name: name})
+	}
+	sort.Slice(genres, func(i, j int) bool { return genres[i].Name < genres[j].Name })
+	return genres, nil
+}
+
+func (m *mockGenreRepo) SetMovieGenres(movieID int, names []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range names {
+		if _, ok := m.genres[name]; !ok {
+			return repo.ErrUnknownGenre
+		}
+	}
+	m.movieGenres[movieID] = names
+	return nil
+}
+
+func (m *mockGenreRepo) GetGenresByMovieIDs(movieIDs []int) (map[int][]repo.Genre, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make(map[int][]repo.Genre)
+	for _, movieID := range movieIDs {
+		for _, name := range m.movieGenres[movieID] {
+			res[movieID] = append(res[movieID], repo.Genre{ID: m.genres[name], Name: name})
+		}
+	}
+	return res, nil
+}
+
+func (m *mockGenreRepo) hasGenre(movieID int, name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, genre := range m.movieGenres[movieID] {
+		if genre == name {
+			return true
+		}
+	}
+	return false
+}
+
+func cleanupGenres() {
+	genres := mok.Genre.(*mockGenreRepo)
+	genres.mu.Lock()
+	defer genres.mu.Unlock()
+	genres.genres = map[string]int{}
+	genres.movieGenres = map[int][]string{}
+}
+
+func TestGenres(t *testing.T) {
+	defer cleanupGenres()
+	ctx := auditCtx("admin", "req-genre")
+
+	genre, err := exempl.CreateGenre(ctx, models.GenreCreateRequest{Name: " Sci-Fi "})
+	assert.NoError(t, err)
+	assert.Equal(t, "sci-fi", genre.Name, "genre names are stored in lower case")
+	assert.Equal(t, bl.AuditEntityGenre, mok.Audit.(*mockAuditRepo).last().EntityType)
+
+	_, err = exempl.CreateGenre(ctx, models.GenreCreateRequest{Name: "SCI-FI"})
+	assert.ErrorIs(t, err, bl.ErrGenreExists)
+	_, err = exempl.CreateGenre(ctx, models.GenreCreateRequest{Name: "  "})
+	assert.ErrorIs(t, err, bl.ErrInvalidGenre)
+	_, err = exempl.CreateGenre(ctx, models.GenreCreateRequest{Name: "драма"})
+	assert.NoError(t, err)
+
+	genres, err := exempl.SetMovieGenres(ctx, models.MovieGenresRequest{MovieID: 1, Genres: []string{"Sci-Fi", "sci-fi", "драма"}})
+	assert.NoError(t, err)
+	assert.Len(t, genres, 2, "duplicates are assigned once")
+	event := mok.Audit.(*mockAuditRepo).last()
+	assert.Equal(t, bl.AuditEntityMovie, event.EntityType)
+	assert.Equal(t, 1, event.EntityID)
+
+	_, err = exempl.SetMovieGenres(ctx, models.MovieGenresRequest{MovieID: 1, Genres: []string{"western"}})
+	assert.ErrorIs(t, err, bl.ErrGenreNotFound)
+	_, err = exempl.SetMovieGenres(ctx, models.MovieGenresRequest{MovieID: 999, Genres: []string{"sci-fi"}})
+	assert.ErrorIs(t, err, bl.ErrMovieNotFound)
+
+	movies, err := exempl.GetMovies(repo.MovieFilter{Genre: "SCI-FI"}, "")
+	assert.NoError(t, err)
+	if assert.Len(t, movies, 1) {
+		assert.Equal(t, "Oppenheimer", movies[0].Movie.Title)
+		assert.Len(t, movies[0].Genres, 2)
+	}
+
+	all, err := exempl.GetGenres()
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+func TestGenreEndpointsAdminOnly(t *testing.T) {
+	defer cleanupGenres()
+	defer cleanupExtraUsers()
+	mux := routes.SetupRoutes(handlers.NewController(exempl, testOptions, zap.NewExample()))
+
+	user, err := exempl.CreateUserByAdmin(models.UserCreateRequest{Login: "viewer", Pass: "Passw0rd!x", Role: bl.RoleUser})
+	assert.NoError(t, err)
+	viewer, err := utilsJwt.GenerateToken(time.Hour, utilsJwt.TokenClaims{Subject: user.Login, UserID: user.ID})
+	assert.NoError(t, err)
+	admin, err := utilsJwt.GenerateToken(time.Hour, utilsJwt.TokenClaims{Subject: "testuser", UserID: 1})
+	assert.NoError(t, err)
+
+	call := func(method string, path string, bearer string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusForbidden, call(http.MethodPost, "/api/genre", viewer, `{"name":"noir"}`).Code)
+	assert.Equal(t, http.StatusForbidden, call(http.MethodPut, "/api/movie/genres", viewer, `{"movieId":1,"genres":["noir"]}`).Code)
+
+	rec := call(http.MethodPost, "/api/genre", admin, `{"name":"noir"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, http.StatusConflict, call(http.MethodPost, "/api/genre", admin, `{"name":"Noir"}`).Code)
+	assert.Equal(t, http.StatusOK, call(http.MethodPut, "/api/movie/genres", admin, `{"movieId":1,"genres":["noir"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPut, "/api/movie/genres", admin, `{"movieId":1,"genres":["western"]}`).Code)
+
+	rec = call(http.MethodGet, "/api/movie?genre=noir", viewer, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var movies []models.MovieIo
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &movies))
+	if assert.Len(t, movies, 1) {
+		assert.Equal(t, []models.Genre{{ID: 1, Name: "noir"}}, movies[0].Genres)
+	}
+	assert.Equal(t, http.StatusNotFound, call(http.MethodGet, "/api/movie?genre=western", viewer, "").Code)
+}
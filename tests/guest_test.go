@@ -0,0 +1,87 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/config"
+	routes "vk-inter-test-go/internal/io"
+	"vk-inter-test-go/internal/io/http/handlers"
+)
+
+func guestRoutes(options config.OptionsSrv) http.Handler {
+	inst := newTestBL(options)
+	return routes.SetupRoutes(handlers.NewController(inst, options, zap.NewExample()))
+}
+
+func TestGuestReadOnly(t *testing.T) {
+	options := testOptions
+	options.GuestAccess = true
+	options.GuestRateLimit = 10
+	options.GuestRateWindow = time.Minute
+	mux := guestRoutes(options)
+
+	call := func(method string, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/api/movie"))
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/api/actor"))
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodPost, "/api/movie"))
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodDelete, "/api/actor?name=test"))
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodGet, "/api/admin/users"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+	req.Header.Set("Authorization", "Bearer bad")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "bad credentials are not downgraded to the guest")
+
+	req = httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+	rec = httptest.NewRecorder()
+	guestRoutes(testOptions).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "guest access is off by default")
+}
+
+func TestGuestRateLimit(t *testing.T) {
+	options := testOptions
+	options.GuestAccess = true
+	options.GuestRateLimit = 2
+	options.GuestRateWindow = time.Minute
+	mux := guestRoutes(options)
+
+	call := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, call("198.51.100.1").Code)
+	assert.Equal(t, http.StatusOK, call("198.51.100.1").Code)
+	rec := call("198.51.100.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, call("198.51.100.2").Code, "addresses are limited separately")
+}
+
+func TestGuestHasNoPermissions(t *testing.T) {
+	ctx := bl.WithPrincipal(context.Background(), bl.Principal{Login: bl.GuestLogin, Guest: true})
+	_, ok := exempl.CheckPermission(ctx, bl.PermMovieWrite)
+	assert.False(t, ok)
+	_, ok = exempl.CheckRole(ctx, bl.RoleAdmin)
+	assert.False(t, ok)
+
+	_, err := exempl.AuthenticateGuest(testClientIP)
+	assert.ErrorIs(t, err, bl.ErrGuestDisabled)
+}
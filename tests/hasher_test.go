@@ -0,0 +1,104 @@
+package tests_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/utils"
+)
+
+var testArgon2 = utils.Argon2idHasher{Memory: 1024, Time: 1, Threads: 1}
+
+func TestArgon2idHasher(t *testing.T) {
+	hash, err := testArgon2.Hash("password")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), hash)
+
+	assert.NoError(t, testArgon2.Verify(hash, "password"))
+	assert.ErrorIs(t, testArgon2.Verify(hash, "wrong"), utils.ErrPasswordMismatch)
+	assert.False(t, testArgon2.NeedsRehash(hash))
+
+	// the parameters come from the hash, a hasher with other settings still verifies it
+	stronger := utils.Argon2idHasher{Memory: 2048, Time: 2, Threads: 1}
+	assert.NoError(t, stronger.Verify(hash, "password"))
+	assert.True(t, stronger.NeedsRehash(hash))
+
+	other, err := testArgon2.Hash("password")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other, "salt expected")
+
+	for _, malformed := range []string{"", "$argon2id$v=19$m=1024,t=1,p=1$salt", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5", "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=1024,t=1,p=0$c2FsdA$a2V5"} {
+		assert.ErrorIs(t, testArgon2.Verify(malformed, "password"), utils.ErrUnknownHash, malformed)
+	}
+}
+
+func TestPasswordHasherMigration(t *testing.T) {
+	legacy := utils.BcryptHasher{Cost: bcrypt.MinCost}
+	bcryptHash, err := legacy.Hash("password")
+	assert.NoError(t, err)
+
+	hasher, err := utils.NewPasswordHasher(utils.HashArgon2id, testArgon2, legacy)
+	assert.NoError(t, err)
+
+	assert.NoError(t, hasher.Verify(bcryptHash, "password"))
+	assert.ErrorIs(t, hasher.Verify(bcryptHash, "wrong"), utils.ErrPasswordMismatch)
+	assert.True(t, hasher.NeedsRehash(bcryptHash))
+
+	hash, err := hasher.Hash("password")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$"))
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	assert.ErrorIs(t, hasher.Verify("plain", "plain"), utils.ErrUnknownHash)
+
+	_, err = utils.NewPasswordHasher("md5", testArgon2, legacy)
+	assert.Error(t, err)
+}
+
+func TestInvalidArgon2Options(t *testing.T) {
+	for _, params := range []utils.Argon2idHasher{
+		{Memory: 1024, Time: 0, Threads: 1},
+		{Memory: 1024, Time: 1, Threads: 0},
+		{Memory: 8, Time: 1, Threads: 4},
+	} {
+		options := testOptions
+		options.Argon2Memory, options.Argon2Time, options.Argon2Threads = params.Memory, params.Time, params.Threads
+		_, err := bl.NewBL(mok, options, testMailer, zap.NewExample())
+		assert.Error(t, err, "%+v", params)
+	}
+}
+
+func TestAuthUserRehash(t *testing.T) {
+	defer delete(mockPasswordHashes, 1)
+	delete(mockPasswordHashes, 1)
+
+	// a disabled user is refused before the hash is touched
+	mockDisabled[1] = true
+	_, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	mockDisabled[1] = false
+	assert.True(t, errors.Is(err, bl.ErrUserDisabled))
+	_, ok := mockPasswordHashes[1]
+	assert.False(t, ok, "the hash of a disabled user is kept")
+
+	_, err = exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+	rehashed, ok := mockPasswordHashes[1]
+	assert.True(t, ok, "bcrypt hash expected to be replaced")
+	assert.True(t, strings.HasPrefix(rehashed, "$argon2id$v=19$m=1024,t=1,p=1$"), rehashed)
+
+	_, err = exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+	assert.Equal(t, rehashed, mockPasswordHashes[1], "current hash is kept")
+
+	// a failed login does not touch the hash
+	_, err = exempl.AuthUser(repo.User{Login: "testuser", Pass: "wrong"}, testClientIP)
+	assert.Error(t, err)
+	assert.Equal(t, rehashed, mockPasswordHashes[1])
+}
@@ -0,0 +1,150 @@
+package tests_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"vk-inter-test-go/internal/config"
+	utilsJwt "vk-inter-test-go/internal/utils"
+)
+
+func TestKeyRotation(t *testing.T) {
+	oldKeys, err := utilsJwt.NewKeySet(utilsJwt.AlgHS256, "old", map[string][]byte{"old": []byte("old-key-old-key-old-key-old-key-old")})
+	assert.NoError(t, err)
+	utilsJwt.SetKeySet(oldKeys)
+	defer utilsJwt.SetKeySet(testKeys)
+
+	oldToken, err := utilsJwt.GenerateToken(time.Hour, utilsJwt.TokenClaims{Subject: "testuser", UserID: 1})
+	assert.NoError(t, err)
+
+	rotated, err := utilsJwt.NewKeySet(utilsJwt.AlgHS256, "new", map[string][]byte{
+		"new": []byte("new-key-new-key-new-key-new-key-new"),
+		"old": []byte("old-key-old-key-old-key-old-key-old"),
+	})
+	assert.NoError(t, err)
+	utilsJwt.SetKeySet(rotated)
+
+	claims, err := utilsJwt.ValidateToken(oldToken)
+	assert.NoError(t, err, "token signed with a retired key must stay valid")
+	assert.Equal(t, "testuser", claims.Subject)
+
+	newToken, err := utilsJwt.GenerateToken(time.Hour, utilsJwt.TokenClaims{Subject: "testuser", UserID: 1})
+	assert.NoError(t, err)
+
+	dropped, err := utilsJwt.NewKeySet(utilsJwt.AlgHS256, "new", map[string][]byte{"new": []byte("new-key-new-key-new-key-new-key-new")})
+	assert.NoError(t, err)
+	utilsJwt.SetKeySet(dropped)
+
+	_, err = utilsJwt.ValidateToken(oldToken)
+	assert.Error(t, err, "token signed with a removed key must be rejected")
+	_, err = utilsJwt.ValidateToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestNewKeySetUnknownActive(t *testing.T) {
+	_, err := utilsJwt.NewKeySet(utilsJwt.AlgHS256, "missing", map[string][]byte{"a": []byte("a-key-a-key-a-key-a-key-a-key-a-key")})
+	assert.Error(t, err)
+}
+
+func TestJwtKeysOptions(t *testing.T) {
+	opts := config.OptionsSrv{JwtKeys: []string{"k1:0123456789abcdef0123456789abcdef", "k2:fedcba9876543210fedcba9876543210"}}
+	active, keys, err := opts.LoadJwtKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", active)
+	assert.Len(t, keys, 2)
+
+	opts.JwtActiveKid = "k2"
+	active, _, err = opts.LoadJwtKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, "k2", active)
+
+	_, _, err = config.OptionsSrv{JwtKeys: []string{"k1:short"}}.LoadJwtKeys()
+	assert.Error(t, err)
+	_, _, err = config.OptionsSrv{}.LoadJwtKeys()
+	assert.Error(t, err)
+}
+
+func pemKeys(t *testing.T, private interface{}, public interface{}) ([]byte, []byte) {
+	privateDer, err := x509.MarshalPKCS8PrivateKey(private)
+	assert.NoError(t, err)
+	publicDer, err := x509.MarshalPKIXPublicKey(public)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDer}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer})
+}
+
+func TestAsymmetricSigning(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	rsaPrivate, rsaPublic := pemKeys(t, rsaKey, &rsaKey.PublicKey)
+
+	edPublicKey, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	edPrivate, edPublic := pemKeys(t, edKey, edPublicKey)
+
+	testCases := []struct {
+		alg     string
+		private []byte
+		public  []byte
+		kty     string
+	}{
+		{utilsJwt.AlgRS256, rsaPrivate, rsaPublic, "RSA"},
+		{utilsJwt.AlgEdDSA, edPrivate, edPublic, "OKP"},
+	}
+	defer utilsJwt.SetKeySet(testKeys)
+
+	for _, tc := range testCases {
+		t.Run(tc.alg, func(t *testing.T) {
+			keys, err := utilsJwt.NewKeySet(tc.alg, "k1", map[string][]byte{"k1": tc.private})
+			assert.NoError(t, err)
+			utilsJwt.SetKeySet(keys)
+
+			token, err := utilsJwt.GenerateToken(time.Hour, utilsJwt.TokenClaims{Subject: "testuser", UserID: 1})
+			assert.NoError(t, err)
+			claims, err := utilsJwt.ValidateToken(token)
+			assert.NoError(t, err)
+			assert.Equal(t, "testuser", claims.Subject)
+			assert.Equal(t, 1, claims.UserID)
+
+			jwks := utilsJwt.PublicJWKS()
+			assert.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tc.kty, jwks.Keys[0].Kty)
+			assert.Equal(t, tc.alg, jwks.Keys[0].Alg)
+			assert.Equal(t, "k1", jwks.Keys[0].Kid)
+
+			retired, err := utilsJwt.NewKeySet(tc.alg, "k1", map[string][]byte{"k1": tc.public})
+			assert.Error(t, err, "public key must not be accepted as the active key")
+			assert.Nil(t, retired)
+		})
+	}
+}
+
+func TestRejectAlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	rsaPrivate, rsaPublic := pemKeys(t, rsaKey, &rsaKey.PublicKey)
+	defer utilsJwt.SetKeySet(testKeys)
+
+	keys, err := utilsJwt.NewKeySet(utilsJwt.AlgRS256, "k1", map[string][]byte{"k1": rsaPrivate})
+	assert.NoError(t, err)
+	utilsJwt.SetKeySet(keys)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "admin", "exp": time.Now().Add(time.Hour).Unix()})
+	forged.Header["kid"] = "k1"
+	token, err := forged.SignedString(rsaPublic)
+	assert.NoError(t, err)
+
+	_, err = utilsJwt.ValidateToken(token)
+	assert.Error(t, err, "HMAC token signed with the public key must be rejected")
+}
+
+func TestPublicJWKSHidesSecrets(t *testing.T) {
+	assert.Empty(t, utilsJwt.PublicJWKS().Keys)
+}
@@ -0,0 +1,76 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+)
+
+func TestAuthUserStatus(t *testing.T) {
+	contr := handlers.NewController(exempl, testOptions, zap.NewExample())
+
+	testCases := []struct {
+		name       string
+		method     string
+		body       string
+		status     int
+		deprecated bool
+	}{
+		{"post", http.MethodPost, `{"login":"testuser","pass":"password"}`, http.StatusOK, false},
+		{"deprecated get", http.MethodGet, `{"login":"testuser","pass":"password"}`, http.StatusOK, true},
+		{"wrong pass", http.MethodPost, `{"login":"testuser","pass":"wrong"}`, http.StatusUnauthorized, false},
+		{"unknown login", http.MethodPost, `{"login":"nobody","pass":"password"}`, http.StatusUnauthorized, false},
+		{"unsupported characters", http.MethodPost, `{"login":"test;user","pass":"password"}`, http.StatusBadRequest, false},
+		{"invalid json", http.MethodPost, `{"login":`, http.StatusBadRequest, false},
+		{"wrong method", http.MethodPut, `{"login":"testuser","pass":"password"}`, http.StatusBadRequest, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/login", bytes.NewBufferString(tc.body))
+			rec := httptest.NewRecorder()
+			contr.AuthUser(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.deprecated, rec.Header().Get("Deprecation") == "true")
+			if tc.status == http.StatusOK {
+				var tokens models.TokenResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tokens))
+				assert.NotEmpty(t, tokens.Bearer)
+			}
+		})
+	}
+}
+
+func TestCreateUserStatus(t *testing.T) {
+	defer cleanupExtraUsers()
+	contr := handlers.NewController(exempl, testOptions, zap.NewExample())
+
+	testCases := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"created", `{"login":"newcomer","pass":"Str0ng-pass"}`, http.StatusOK},
+		{"existing login", `{"login":"newcomer","pass":"Str0ng-pass"}`, http.StatusConflict},
+		{"weak password", `{"login":"another","pass":"password"}`, http.StatusBadRequest},
+		{"unsupported characters", `{"login":"new;comer","pass":"Str0ng-pass"}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/create/user", bytes.NewBufferString(tc.body))
+			rec := httptest.NewRecorder()
+			contr.CreateUser(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+)
+
+var mockDisplayNames = map[int]string{}
+
+func (m mockUserRepo) SetDisplayName(userID int, name string) (int64, error) {
+	if _, ok := mockUserRoles[userID]; !ok {
+		return 0, nil
+	}
+	mockDisplayNames[userID] = name
+	return 1, nil
+}
+
+func (m mockUserRepo) DeleteAccount(userID int, keep string) (int64, error) {
+	rows, err := m.DeleteUserById(userID, keep)
+	if err != nil {
+		return 0, err
+	}
+	keys := mok.ApiKey.(*mockApiKeyRepo)
+	keys.mu.Lock()
+	for id, key := range keys.keys {
+		if key.CreatedBy != nil && *key.CreatedBy == userID {
+			delete(keys.keys, id)
+		}
+	}
+	keys.mu.Unlock()
+	delete(mockDisplayNames, userID)
+	return rows, nil
+}
+
+func (m *mockRefreshRepo) GetUserRefreshTokens(userID int) ([]repo.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var tokens []repo.RefreshToken
+	for _, token := range m.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, *token)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *mockOidcRepo) GetUserIdentities(userID int) ([]repo.UserIdentity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var identities []repo.UserIdentity
+	for key, id := range m.identities {
+		if id == userID {
+			issuer, subject, _ := strings.Cut(key, " ")
+			identities = append(identities, repo.UserIdentity{Issuer: issuer, Subject: subject, UserID: id})
+		}
+	}
+	return identities, nil
+}
+
+func TestProfile(t *testing.T) {
+	defer delete(mockDisplayNames, 1)
+	ctx := principalCtx(1, "testuser", "admin")
+
+	profile, err := exempl.GetProfile(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "testuser", profile.Login)
+	assert.Equal(t, "admin", profile.Role)
+	assert.Empty(t, profile.DisplayName)
+
+	name := "  Test User  "
+	profile, err = exempl.UpdateProfile(ctx, models.ProfileUpdateRequest{DisplayName: &name})
+	assert.NoError(t, err)
+	assert.Equal(t, "Test User", profile.DisplayName)
+
+	invalid := "bad\nname"
+	_, err = exempl.UpdateProfile(ctx, models.ProfileUpdateRequest{DisplayName: &invalid})
+	assert.ErrorIs(t, err, bl.ErrInvalidDisplayName)
+	long := strings.Repeat("я", 101)
+	_, err = exempl.UpdateProfile(ctx, models.ProfileUpdateRequest{DisplayName: &long})
+	assert.ErrorIs(t, err, bl.ErrInvalidDisplayName)
+
+	_, err = exempl.GetProfile(principalCtx(0, bl.GuestLogin, ""))
+	assert.ErrorIs(t, err, bl.ErrUserNotFound)
+}
+
+func TestUserJsonHidesPassword(t *testing.T) {
+	user, err := mok.User.GetUserById(1)
+	assert.NoError(t, err)
+	contr := handlers.NewController(exempl, testOptions, zap.NewExample())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil).WithContext(principalCtx(1, "testuser", "admin"))
+	contr.GetMe(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), user.Pass)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/login", bytes.NewBufferString(`{"login":"testuser","pass":"password"}`))
+	rec = httptest.NewRecorder()
+	contr.AuthUser(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code, "credentials are still accepted in the request body")
+}
+
+func TestExportUserData(t *testing.T) {
+	ctx := principalCtx(1, "testuser", "admin")
+	_, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+	_, err = exempl.UpdateMovie(bl.WithRequestInfo(ctx, bl.RequestInfo{ID: "req-export"}), repo.Movie{ID: 1, Title: "Exported"})
+	assert.NoError(t, err)
+	// a deleted user who had the same login before
+	previous := bl.WithRequestInfo(principalCtx(99, "testuser", "admin"), bl.RequestInfo{ID: "req-previous"})
+	_, err = exempl.UpdateMovie(previous, repo.Movie{ID: 1, Title: "Previous"})
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/me/export", nil).WithContext(ctx)
+	handlers.NewController(exempl, testOptions, zap.NewExample()).ExportMe(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "attachment")
+	assert.NotContains(t, rec.Body.String(), "$argon2id", "password hashes are not exported")
+
+	var export models.UserExport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &export))
+	assert.Equal(t, "testuser", export.Profile.Login)
+	assert.NotEmpty(t, export.Sessions)
+	assert.NotNil(t, export.ApiKeys)
+	found := false
+	for _, event := range export.AuditEvents {
+		assert.NotEqual(t, "req-previous", event.RequestID, "changes of another user with the same login are not exported")
+		found = found || event.RequestID == "req-export"
+	}
+	assert.True(t, found, "the changes made by the user are exported")
+	assert.WithinDuration(t, time.Now(), export.ExportedAt, time.Minute)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	defer cleanupExtraUsers()
+	created, err := exempl.CreateUserByAdmin(models.UserCreateRequest{Login: "leaving", Pass: "Passw0rd!x", Role: "admin"})
+	assert.NoError(t, err)
+	ctx := principalCtx(created.ID, "leaving", "admin")
+	key, err := exempl.CreateApiKey(ctx, models.ApiKeyCreateRequest{Name: "leaving-key", Permissions: []string{"movie:write"}})
+	assert.NoError(t, err)
+
+	err = exempl.DeleteAccount(ctx, models.AccountDeleteRequest{Pass: "wrong"})
+	assert.ErrorIs(t, err, bl.ErrWrongPassword)
+
+	assert.NoError(t, exempl.DeleteAccount(ctx, models.AccountDeleteRequest{Pass: "Passw0rd!x"}))
+	_, err = mok.User.GetUserById(created.ID)
+	assert.Error(t, err)
+	_, err = exempl.AuthenticateApiKey(key.Key)
+	assert.Error(t, err, "api keys of the user are deleted")
+	_, err = exempl.GetProfile(ctx)
+	assert.ErrorIs(t, err, bl.ErrUserNotFound)
+
+	err = exempl.DeleteAccount(principalCtx(1, "testuser", "admin"), models.AccountDeleteRequest{Pass: "password"})
+	assert.ErrorIs(t, err, bl.ErrLastAdmin)
+}
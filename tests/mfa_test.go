@@ -0,0 +1,233 @@
+package tests_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+	utilsJwt "vk-inter-test-go/internal/utils"
+)
+
+type mockMfaRepo struct {
+	mu       sync.Mutex
+	mfa      map[int]*repo.Mfa
+	recovery map[int]map[string]bool
+}
+
+func newMockMfaRepo() *mockMfaRepo {
+	return &mockMfaRepo{mfa: make(map[int]*repo.Mfa), recovery: make(map[int]map[string]bool)}
+}
+
+func (m *mockMfaRepo) GetMfa(userID int) (repo.Mfa, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mfa, ok := m.mfa[userID]
+	if !ok {
+		return repo.Mfa{UserID: userID}, nil
+	}
+	return *mfa, nil
+}
+
+func (m *mockMfaRepo) SetMfaSecret(userID int, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mfa, ok := m.mfa[userID]; ok && mfa.Enabled {
+		return nil
+	}
+	m.mfa[userID] = &repo.Mfa{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (m *mockMfaRepo) EnableMfa(userID int, recoveryCodeHashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mfa, ok := m.mfa[userID]; ok {
+		mfa.Enabled = true
+	}
+	m.recovery[userID] = make(map[string]bool)
+	for _, hash := range recoveryCodeHashes {
+		m.recovery[userID][hash] = true
+	}
+	return nil
+}
+
+func (m *mockMfaRepo) DeleteMfa(userID int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.mfa[userID]; !ok {
+		return 0, nil
+	}
+	delete(m.mfa, userID)
+	delete(m.recovery, userID)
+	return 1, nil
+}
+
+func (m *mockMfaRepo) UseMfaStep(userID int, step int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mfa, ok := m.mfa[userID]
+	if !ok || mfa.LastStep >= step {
+		return 0, nil
+	}
+	mfa.LastStep = step
+	return 1, nil
+}
+
+func (m *mockMfaRepo) UseRecoveryCode(userID int, hash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.recovery[userID][hash] {
+		return 0, nil
+	}
+	delete(m.recovery[userID], hash)
+	return 1, nil
+}
+
+// enrollMfa enables the second factor of the user and returns the secret and
+// the recovery codes.
+func enrollMfa(t *testing.T, inst *bl.BL, userID int, login string, role string) (string, []string) {
+	ctx := principalCtx(userID, login, role)
+	enrollment, err := inst.StartMfaEnrollment(ctx)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/Filmoteka:"+login+"?"))
+
+	code, err := utilsJwt.TOTPCode(enrollment.Secret, utilsJwt.TOTPStep(time.Now()))
+	assert.NoError(t, err)
+	enabled, err := inst.ConfirmMfaEnrollment(ctx, code)
+	assert.NoError(t, err)
+	assert.Len(t, enabled.RecoveryCodes, 10)
+	assert.NotEmpty(t, enabled.Tokens.Bearer)
+	return enrollment.Secret, enabled.RecoveryCodes
+}
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 SHA1 test vectors, truncated to six digits.
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range testCases {
+		code, err := utilsJwt.TOTPCode(secret, utilsJwt.TOTPStep(time.Unix(tc.unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.code, code)
+	}
+
+	step, ok := utilsJwt.VerifyTOTP(secret, "287082", time.Unix(89, 0), 1)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), step)
+	_, ok = utilsJwt.VerifyTOTP(secret, "287082", time.Unix(150, 0), 1)
+	assert.False(t, ok)
+}
+
+func TestMfaLogin(t *testing.T) {
+	defer mok.Mfa.DeleteMfa(1)
+	secret, recoveryCodes := enrollMfa(t, exempl, 1, "testuser", "admin")
+
+	_, err := exempl.StartMfaEnrollment(principalCtx(1, "testuser", "admin"))
+	assert.ErrorIs(t, err, bl.ErrMfaAlreadyEnabled)
+
+	pending, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+	assert.True(t, pending.MfaRequired)
+	assert.Empty(t, pending.Bearer)
+	assert.Empty(t, pending.Refresh)
+
+	_, err = exempl.Authenticate(pending.MfaToken)
+	assert.Error(t, err, "the mfa token is not an access token")
+
+	// The code used for the enrollment cannot be replayed.
+	used, _ := utilsJwt.TOTPCode(secret, utilsJwt.TOTPStep(time.Now()))
+	_, err = exempl.CompleteMfaLogin(models.MfaLoginRequest{MfaToken: pending.MfaToken, Code: used}, testClientIP)
+	assert.ErrorIs(t, err, bl.ErrInvalidMfaCode)
+
+	next, _ := utilsJwt.TOTPCode(secret, utilsJwt.TOTPStep(time.Now())+1)
+	tokens, err := exempl.CompleteMfaLogin(models.MfaLoginRequest{MfaToken: pending.MfaToken, Code: next}, testClientIP)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.Bearer)
+
+	recovery := strings.ToUpper(recoveryCodes[0])
+	_, err = exempl.CompleteMfaLogin(models.MfaLoginRequest{MfaToken: pending.MfaToken, RecoveryCode: recovery}, testClientIP)
+	assert.NoError(t, err)
+	_, err = exempl.CompleteMfaLogin(models.MfaLoginRequest{MfaToken: pending.MfaToken, RecoveryCode: recovery}, testClientIP)
+	assert.ErrorIs(t, err, bl.ErrInvalidMfaCode)
+
+	_, err = exempl.CompleteMfaLogin(models.MfaLoginRequest{MfaToken: tokens.Bearer, Code: next}, testClientIP)
+	assert.ErrorIs(t, err, bl.ErrInvalidMfaToken)
+
+	err = exempl.DisableMfa(principalCtx(1, "testuser", "admin"), models.MfaCodeRequest{RecoveryCode: recoveryCodes[1]})
+	assert.NoError(t, err)
+	pending, err = exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+	assert.False(t, pending.MfaRequired)
+	assert.NotEmpty(t, pending.Bearer)
+}
+
+func TestMfaMandatory(t *testing.T) {
+	defer mok.Mfa.DeleteMfa(1)
+	options := testOptions
+	options.MfaRequired = true
+	inst := newTestBL(options)
+	contr := handlers.NewController(inst, testOptions, zap.NewExample())
+
+	tokens, err := inst.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+	principal, err := inst.Authenticate(tokens.Bearer)
+	assert.NoError(t, err)
+	assert.True(t, principal.MustEnrollMfa)
+
+	call := func(middleware func(http.HandlerFunc) http.HandlerFunc, bearer string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		rec := httptest.NewRecorder()
+		middleware(func(w http.ResponseWriter, r *http.Request) {})(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusForbidden, call(contr.AuthMiddleware, tokens.Bearer))
+	assert.Equal(t, http.StatusOK, call(contr.MfaEnrollAuthMiddleware, tokens.Bearer))
+
+	_, recoveryCodes := enrollMfa(t, inst, 1, "testuser", "admin")
+	err = inst.DisableMfa(principalCtx(1, "testuser", "admin"), models.MfaCodeRequest{RecoveryCode: recoveryCodes[0]})
+	assert.ErrorIs(t, err, bl.ErrMfaMandatory)
+
+	pending, err := inst.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+	tokens, err = inst.CompleteMfaLogin(models.MfaLoginRequest{MfaToken: pending.MfaToken, RecoveryCode: recoveryCodes[1]}, testClientIP)
+	assert.NoError(t, err)
+	principal, err = inst.Authenticate(tokens.Bearer)
+	assert.NoError(t, err)
+	assert.False(t, principal.MustEnrollMfa)
+	assert.Equal(t, http.StatusOK, call(contr.AuthMiddleware, tokens.Bearer))
+}
+
+func TestMfaNotMandatoryForReadOnlyRole(t *testing.T) {
+	defer cleanupExtraUsers()
+	defer mok.Role.DeleteRoleByName("auditor")
+	assert.NoError(t, mok.Role.CreateRole(&repo.Role{Name: "auditor", Permissions: []string{bl.PermAuditRead}}))
+	mockUserRoles[1] = "auditor"
+	options := testOptions
+	options.MfaRequired = true
+	inst := newTestBL(options)
+
+	tokens, err := inst.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+	assert.False(t, tokens.MfaRequired)
+	principal, err := inst.Authenticate(tokens.Bearer)
+	assert.NoError(t, err)
+	assert.Equal(t, "auditor", principal.Role)
+	assert.False(t, principal.MustEnrollMfa, "reading the audit log does not require a second factor")
+}
@@ -0,0 +1,58 @@
+package tests_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/http/handlers"
+)
+
+func TestAuthMiddlewareHeaders(t *testing.T) {
+	contr := handlers.NewController(exempl, testOptions, zap.NewExample())
+	tokens, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+
+	var principal bl.Principal
+	protected := contr.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = bl.PrincipalFromContext(r.Context())
+	})
+
+	testCases := []struct {
+		name       string
+		header     string
+		value      string
+		status     int
+		deprecated bool
+	}{
+		{"authorization", "Authorization", "Bearer " + tokens.Bearer, http.StatusOK, false},
+		{"lowercase scheme", "Authorization", "bearer " + tokens.Bearer, http.StatusOK, false},
+		{"deprecated header", "Bearer", tokens.Bearer, http.StatusOK, true},
+		{"wrong scheme", "Authorization", "Basic " + tokens.Bearer, http.StatusUnauthorized, false},
+		{"bad token", "Authorization", "Bearer bad", http.StatusUnauthorized, false},
+		{"no token", "", "", http.StatusUnauthorized, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			principal = bl.Principal{}
+			req := httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+			if len(tc.header) > 0 {
+				req.Header.Set(tc.header, tc.value)
+			}
+			rec := httptest.NewRecorder()
+			protected(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.deprecated, rec.Header().Get("Deprecation") == "true")
+			if tc.status == http.StatusOK {
+				assert.Equal(t, "testuser", principal.Login)
+				assert.Equal(t, 1, principal.UserID)
+			}
+		})
+	}
+}
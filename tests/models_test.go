@@ -0,0 +1,50 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+)
+
+func TestMappers(t *testing.T) {
+	movie := repo.Movie{ID: 3, Title: "Heat", ReleaseDate: time.Date(1995, 12, 15, 0, 0, 0, 0, time.UTC), Rating: 9}
+	dto := models.MovieFromRepo(movie)
+	assert.Equal(t, "1995-12-15", dto.ReleaseDate)
+	back, err := dto.ToRepo()
+	assert.NoError(t, err)
+	assert.Equal(t, movie, back)
+
+	actor, err := models.Actor{ID: 1, Name: "Al Pacino"}.ToRepo()
+	assert.NoError(t, err)
+	assert.True(t, actor.BirthDate.IsZero(), "an empty date is left zero")
+	assert.Empty(t, models.ActorFromRepo(actor).BirthDate)
+
+	_, err = models.Actor{BirthDate: "25.04.1940"}.ToRepo()
+	assert.Error(t, err)
+}
+
+func TestCatalogResponses(t *testing.T) {
+	contr := handlers.NewController(exempl, testOptions, zap.NewExample())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/api/actor", bytes.NewBufferString(`{"ID":1,"name":"test"}`))
+	contr.UpdateActor(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var actor models.Actor
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &actor))
+	assert.Equal(t, "1984-02-24", actor.BirthDate)
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPut, "/api/movie", bytes.NewBufferString(`{"ID":1,"releaseDate":"invalid date"}`))
+	contr.UpdateMovie(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
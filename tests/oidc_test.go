@@ -0,0 +1,357 @@
+package tests_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/config"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/utils"
+)
+
+type mockOidcRepo struct {
+	mu         sync.Mutex
+	states     map[string]repo.OidcLoginState
+	identities map[string]int
+}
+
+func newMockOidcRepo() *mockOidcRepo {
+	return &mockOidcRepo{states: make(map[string]repo.OidcLoginState), identities: make(map[string]int)}
+}
+
+func (m *mockOidcRepo) CreateLoginState(state *repo.OidcLoginState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[state.StateHash] = *state
+	return nil
+}
+
+func (m *mockOidcRepo) UseLoginState(hash string) (repo.OidcLoginState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.states[hash]
+	delete(m.states, hash)
+	if !ok || !state.ExpiresAt.After(time.Now()) {
+		return repo.OidcLoginState{}, errors.New("no rows")
+	}
+	return state, nil
+}
+
+func (m *mockOidcRepo) GetIdentityUserId(issuer string, subject string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.identities[issuer+" "+subject], nil
+}
+
+func (m *mockOidcRepo) CreateIdentity(issuer string, subject string, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.identities[issuer+" "+subject] = userID
+	return nil
+}
+
+// testProvider is a stand-in OpenID Connect provider: it serves discovery,
+// the key set and the token endpoint, the login page is skipped by authorize.
+type testProvider struct {
+	srv *httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]testAuthorization
+}
+
+type testAuthorization struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	p := &testProvider{key: key, codes: make(map[string]testAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.srv.URL,
+			"authorization_endpoint": p.srv.URL + "/authorize",
+			"token_endpoint":         p.srv.URL + "/token",
+			"jwks_uri":               p.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "provider-key",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", p.token)
+	p.srv = httptest.NewServer(mux)
+	t.Cleanup(p.srv.Close)
+	return p
+}
+
+// authorize plays the user logging in at the provider: it checks the login
+// url and returns the code and state the provider redirects back with. The
+// claims are put into the id token, nonce and standard claims are filled in.
+func (p *testProvider) authorize(t *testing.T, loginURL string, claims jwt.MapClaims) (string, string) {
+	assert.True(t, strings.HasPrefix(loginURL, p.srv.URL+"/authorize?"))
+	parsed, err := url.Parse(loginURL)
+	assert.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "filmoteka", query.Get("client_id"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Contains(t, query.Get("scope"), "openid")
+
+	full := jwt.MapClaims{
+		"iss":   p.srv.URL,
+		"aud":   []string{"filmoteka"},
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+	for name, value := range claims {
+		full[name] = value
+	}
+	code, err := utils.NewOpaqueToken()
+	assert.NoError(t, err)
+	p.mu.Lock()
+	p.codes[code] = testAuthorization{challenge: query.Get("code_challenge"), claims: full}
+	p.mu.Unlock()
+	return code, query.Get("state")
+}
+
+func (p *testProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	auth, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, auth.claims)
+	token.Header["kid"] = "provider-key"
+	idToken, _ := token.SignedString(p.key)
+	json.NewEncoder(w).Encode(map[string]string{"access_token": "opaque", "token_type": "Bearer", "id_token": idToken})
+}
+
+func (p *testProvider) options() config.OptionsSrv {
+	options := testOptions
+	options.OidcIssuer = p.srv.URL
+	options.OidcClientID = "filmoteka"
+	options.OidcRedirectURL = "https://app.example/api/login/oidc/callback"
+	options.OidcScopes = []string{"openid", "profile", "email"}
+	options.OidcLoginClaim = "preferred_username"
+	options.OidcRoleClaim = "groups"
+	options.OidcRoleMap = []string{"film-admins:admin"}
+	options.OidcDefaultRole = "user"
+	options.OidcStateTTL = time.Minute
+	return options
+}
+
+func oidcLogin(t *testing.T, inst *bl.BL, provider *testProvider, claims jwt.MapClaims) (bl.Principal, error) {
+	loginURL, _, err := inst.StartOidcLogin(context.Background())
+	assert.NoError(t, err)
+	code, state := provider.authorize(t, loginURL, claims)
+	tokens, err := inst.CompleteOidcLogin(context.Background(), code, state)
+	if err != nil {
+		return bl.Principal{}, err
+	}
+	return inst.Authenticate(tokens.Bearer)
+}
+
+func TestOidcLogin(t *testing.T) {
+	defer cleanupExtraUsers()
+	provider := newTestProvider(t)
+	inst := newTestBL(provider.options())
+
+	principal, err := oidcLogin(t, inst, provider, jwt.MapClaims{
+		"sub":                "ext-alice",
+		"preferred_username": "alice",
+		"groups":             []string{"staff", "film-admins"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", principal.Login)
+	assert.Equal(t, "admin", principal.Role)
+
+	again, err := oidcLogin(t, inst, provider, jwt.MapClaims{
+		"sub":                "ext-alice",
+		"preferred_username": "alice-renamed",
+		"groups":             "staff",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, principal.UserID, again.UserID, "the identity stays linked to the user")
+	assert.Equal(t, "alice", again.Login)
+	assert.Equal(t, "user", again.Role, "the role follows the groups")
+}
+
+func TestOidcLoginLinksVerifiedEmail(t *testing.T) {
+	defer cleanupEmails()
+	defer cleanupExtraUsers()
+	assert.NoError(t, mok.User.CreateUser(&repo.User{Login: "taken"}))
+	mockEmails[1] = "admin@example.com"
+	mockEmailVerified[1] = true
+	provider := newTestProvider(t)
+	adminClaims := jwt.MapClaims{
+		"sub":            "ext-admin",
+		"email":          "admin@example.com",
+		"email_verified": true,
+		"groups":         []string{"film-admins"},
+	}
+
+	principal, err := oidcLogin(t, newTestBL(provider.options()), provider, adminClaims)
+	assert.ErrorIs(t, err, bl.ErrOidcLogin, "accounts are not linked by email unless enabled")
+	assert.Zero(t, principal.UserID)
+
+	options := provider.options()
+	options.OidcLinkEmail = true
+	inst := newTestBL(options)
+
+	_, err = oidcLogin(t, inst, provider, jwt.MapClaims{
+		"sub":                "ext-unverified",
+		"preferred_username": "taken",
+		"email":              "admin@example.com",
+	})
+	assert.ErrorIs(t, err, bl.ErrOidcLoginTaken, "an unverified email does not link accounts")
+
+	principal, err = oidcLogin(t, inst, provider, adminClaims)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, principal.UserID)
+	assert.Equal(t, "testuser", principal.Login)
+}
+
+func TestOidcUnknownRole(t *testing.T) {
+	provider := newTestProvider(t)
+	options := provider.options()
+	options.OidcRoleMap = []string{"film-admins:superuser"}
+	_, err := bl.NewBL(mok, options, testMailer, zap.NewExample())
+	assert.ErrorContains(t, err, "superuser")
+
+	options = provider.options()
+	options.OidcDefaultRole = "nobody"
+	_, err = bl.NewBL(mok, options, testMailer, zap.NewExample())
+	assert.ErrorContains(t, err, "nobody")
+}
+
+func TestOidcLoginRejected(t *testing.T) {
+	provider := newTestProvider(t)
+	inst := newTestBL(provider.options())
+	ctx := context.Background()
+
+	loginURL, _, err := inst.StartOidcLogin(ctx)
+	assert.NoError(t, err)
+	code, state := provider.authorize(t, loginURL, jwt.MapClaims{"sub": "ext-bob", "preferred_username": "bob"})
+	_, err = inst.CompleteOidcLogin(ctx, code, "forged")
+	assert.ErrorIs(t, err, bl.ErrInvalidOidcState)
+	_, err = inst.CompleteOidcLogin(ctx, "wrong-code", state)
+	assert.ErrorIs(t, err, bl.ErrOidcLogin)
+	_, err = inst.CompleteOidcLogin(ctx, code, state)
+	assert.ErrorIs(t, err, bl.ErrInvalidOidcState, "a state can be used only once")
+
+	// The code is bound to the PKCE challenge of the login it was issued for.
+	first, _, err := inst.StartOidcLogin(ctx)
+	assert.NoError(t, err)
+	second, _, err := inst.StartOidcLogin(ctx)
+	assert.NoError(t, err)
+	code, _ = provider.authorize(t, first, jwt.MapClaims{"sub": "ext-bob", "preferred_username": "bob"})
+	_, state = provider.authorize(t, second, jwt.MapClaims{})
+	_, err = inst.CompleteOidcLogin(ctx, code, state)
+	assert.ErrorIs(t, err, bl.ErrOidcLogin)
+
+	for name, claims := range map[string]jwt.MapClaims{
+		"nonce":    {"sub": "ext-bob", "preferred_username": "bob", "nonce": "replayed"},
+		"audience": {"sub": "ext-bob", "preferred_username": "bob", "aud": "another-client"},
+		"expired":  {"sub": "ext-bob", "preferred_username": "bob", "exp": time.Now().Add(-time.Minute).Unix()},
+		"issuer":   {"sub": "ext-bob", "preferred_username": "bob", "iss": "https://evil.example"},
+		"login":    {"sub": "ext-bob"},
+	} {
+		_, err := oidcLogin(t, inst, provider, claims)
+		assert.ErrorIs(t, err, bl.ErrOidcLogin, name)
+	}
+
+	_, _, err = exempl.StartOidcLogin(ctx)
+	assert.ErrorIs(t, err, bl.ErrOidcDisabled)
+}
+
+func TestOidcHandlers(t *testing.T) {
+	provider := newTestProvider(t)
+	contr := handlers.NewController(newTestBL(provider.options()), provider.options(), zap.NewExample())
+
+	start := func() (string, string, *http.Cookie) {
+		req := httptest.NewRequest(http.MethodGet, "/api/login/oidc", nil)
+		rec := httptest.NewRecorder()
+		contr.OidcLogin(rec, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+		var binding *http.Cookie
+		for _, cookie := range rec.Result().Cookies() {
+			if cookie.Name == "oidc_state" {
+				binding = cookie
+			}
+		}
+		if assert.NotNil(t, binding, "the login is bound to the browser") {
+			assert.True(t, binding.HttpOnly)
+			assert.Equal(t, http.SameSiteLaxMode, binding.SameSite)
+		}
+		code, state := provider.authorize(t, rec.Header().Get("Location"), jwt.MapClaims{"sub": "ext-carol", "preferred_username": "carol"})
+		return code, state, binding
+	}
+	callback := func(code string, state string, binding *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/login/oidc/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state), nil)
+		if binding != nil {
+			req.AddCookie(&http.Cookie{Name: binding.Name, Value: binding.Value})
+		}
+		rec := httptest.NewRecorder()
+		contr.OidcCallback(rec, req)
+		return rec
+	}
+	defer cleanupExtraUsers()
+
+	code, state, _ := start()
+	assert.Equal(t, http.StatusBadRequest, callback(code, state, nil).Code, "a callback without the cookie of the login is rejected")
+	_, _, other := start()
+	code, state, _ = start()
+	assert.Equal(t, http.StatusBadRequest, callback(code, state, other).Code, "a login started in another browser is rejected")
+
+	code, state, binding := start()
+	rec := callback(code, state, binding)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "bearer")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/login/oidc/callback?error=access_denied&state="+url.QueryEscape(state), nil)
+	rec = httptest.NewRecorder()
+	contr.OidcCallback(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	disabled := handlers.NewController(exempl, testOptions, zap.NewExample())
+	req = httptest.NewRequest(http.MethodGet, "/api/login/oidc", nil)
+	rec = httptest.NewRecorder()
+	disabled.OidcLogin(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,260 @@
+package tests_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/mail"
+)
+
+var (
+	mockEmails        = map[int]string{}
+	mockEmailVerified = map[int]bool{}
+
+	testMailer = &mockMailer{}
+)
+
+type mockMailer struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (m *mockMailer) Send(msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+var mailTokenRe = regexp.MustCompile(`(?m)^([A-Za-z0-9_-]{43})$`)
+
+// lastToken returns the token of the last message sent to the address.
+func (m *mockMailer) lastToken(to string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return mailTokenRe.FindString(m.messages[i].Body)
+		}
+	}
+	return ""
+}
+
+type mockUserTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[int]*repo.UserToken
+}
+
+func newMockUserTokenRepo() *mockUserTokenRepo {
+	return &mockUserTokenRepo{tokens: make(map[int]*repo.UserToken)}
+}
+
+func (m *mockUserTokenRepo) CreateUserToken(token *repo.UserToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token.ID = len(m.tokens) + 1
+	token.CreatedAt = time.Now()
+	stored := *token
+	m.tokens[token.ID] = &stored
+	return nil
+}
+
+func (m *mockUserTokenRepo) find(hash string, purpose string) *repo.UserToken {
+	for _, token := range m.tokens {
+		if token.TokenHash == hash && token.Purpose == purpose && token.UsedAt == nil && token.ExpiresAt.After(time.Now()) {
+			return token
+		}
+	}
+	return nil
+}
+
+func (m *mockUserTokenRepo) GetValidUserToken(hash string, purpose string) (repo.UserToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token := m.find(hash, purpose)
+	if token == nil {
+		return repo.UserToken{}, errors.New("not found")
+	}
+	return *token, nil
+}
+
+func (m *mockUserTokenRepo) UseUserToken(hash string, purpose string) (repo.UserToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token := m.find(hash, purpose)
+	if token == nil {
+		return repo.UserToken{}, errors.New("not found")
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	return *token, nil
+}
+
+func (m *mockUserTokenRepo) InvalidateUserTokens(userID int, purpose string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows int64
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &now
+			rows++
+		}
+	}
+	return rows, nil
+}
+
+func (m mockUserRepo) GetUserByEmail(email string) (repo.User, error) {
+	for id, e := range mockEmails {
+		if strings.EqualFold(e, email) && mockEmailVerified[id] {
+			return m.GetUserById(id)
+		}
+	}
+	return repo.User{}, errors.New("not found")
+}
+
+func (m mockUserRepo) SetUserEmail(userID int, email string) (int64, error) {
+	if _, ok := mockUserRoles[userID]; !ok {
+		return 0, nil
+	}
+	mockEmails[userID] = email
+	mockEmailVerified[userID] = false
+	return 1, nil
+}
+
+func (m mockUserRepo) SetEmailVerified(userID int, email string) (int64, error) {
+	if mockEmails[userID] != email {
+		return 0, nil
+	}
+	for id, e := range mockEmails {
+		if id != userID && mockEmailVerified[id] && strings.EqualFold(e, email) {
+			return 0, repo.ErrEmailTaken
+		}
+	}
+	mockEmailVerified[userID] = true
+	return 1, nil
+}
+
+func cleanupEmails() {
+	for id := range mockEmails {
+		delete(mockEmails, id)
+		delete(mockEmailVerified, id)
+	}
+	delete(mockPasswordHashes, 1)
+}
+
+func TestSetAndVerifyEmail(t *testing.T) {
+	defer cleanupEmails()
+	ctx := principalCtx(1, "testuser", "admin")
+
+	assert.ErrorIs(t, exempl.SetEmail(ctx, "not an email"), bl.ErrInvalidEmail)
+	assert.ErrorIs(t, exempl.SetEmail(ctx, "Name <user@example.com>"), bl.ErrInvalidEmail)
+
+	assert.NoError(t, exempl.SetEmail(ctx, "user@example.com"))
+	first := testMailer.lastToken("user@example.com")
+	assert.NotEmpty(t, first)
+	assert.NoError(t, exempl.SetEmail(ctx, "user@example.com"))
+	second := testMailer.lastToken("user@example.com")
+
+	// only the latest token is valid, and only once
+	assert.ErrorIs(t, exempl.VerifyEmail(first), bl.ErrInvalidUserToken)
+	assert.NoError(t, exempl.VerifyEmail(second))
+	assert.ErrorIs(t, exempl.VerifyEmail(second), bl.ErrInvalidUserToken)
+
+	user, err := exempl.GetUser(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "user@example.com", user.Email)
+	assert.True(t, user.EmailVerified)
+
+	// a token for an email that has been changed since does not verify the new one
+	assert.NoError(t, exempl.SetEmail(ctx, "old@example.com"))
+	stale := testMailer.lastToken("old@example.com")
+	assert.NoError(t, exempl.SetEmail(ctx, "new@example.com"))
+	assert.ErrorIs(t, exempl.VerifyEmail(stale), bl.ErrInvalidUserToken)
+
+	// an unverified email of another user does not block the address
+	mockEmails[2] = "squatted@example.com"
+	assert.NoError(t, exempl.SetEmail(ctx, "SQUATTED@example.com"))
+	assert.NoError(t, exempl.VerifyEmail(testMailer.lastToken("SQUATTED@example.com")))
+
+	// a verified email of another user is only reported to the owner of the address
+	mockEmails[2] = "taken@example.com"
+	mockEmailVerified[2] = true
+	assert.NoError(t, exempl.SetEmail(ctx, "TAKEN@example.com"))
+	assert.ErrorIs(t, exempl.VerifyEmail(testMailer.lastToken("TAKEN@example.com")), bl.ErrEmailTaken)
+}
+
+func TestPasswordReset(t *testing.T) {
+	defer cleanupEmails()
+	ctx := principalCtx(1, "testuser", "admin")
+	assert.NoError(t, exempl.SetEmail(ctx, "reset@example.com"))
+
+	// unverified emails do not get a reset token
+	sent := len(testMailer.messages)
+	assert.NoError(t, exempl.RequestPasswordReset("reset@example.com"))
+	assert.NoError(t, exempl.RequestPasswordReset("unknown@example.com"))
+	assert.Len(t, testMailer.messages, sent)
+
+	assert.NoError(t, exempl.VerifyEmail(testMailer.lastToken("reset@example.com")))
+	tokens, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+
+	assert.NoError(t, exempl.RequestPasswordReset("Reset@Example.com"))
+	token := testMailer.lastToken("reset@example.com")
+	assert.NotEmpty(t, token)
+
+	err = exempl.ConfirmPasswordReset(models.PasswordResetConfirmRequest{Token: token, NewPass: "short"})
+	assert.ErrorIs(t, err, bl.ErrWeakPassword)
+	// a weak password does not use the token up
+	assert.NoError(t, exempl.ConfirmPasswordReset(models.PasswordResetConfirmRequest{Token: token, NewPass: "Res3t-password"}))
+	err = exempl.ConfirmPasswordReset(models.PasswordResetConfirmRequest{Token: token, NewPass: "An0ther-password"})
+	assert.ErrorIs(t, err, bl.ErrInvalidUserToken)
+
+	_, err = exempl.RefreshToken(tokens.Refresh)
+	assert.Error(t, err, "sessions are revoked")
+	_, err = exempl.AuthUser(repo.User{Login: "testuser", Pass: "Res3t-password"}, testClientIP)
+	assert.NoError(t, err)
+}
+
+func TestPasswordResetTokenExpires(t *testing.T) {
+	defer cleanupEmails()
+	mockEmails[1], mockEmailVerified[1] = "expire@example.com", true
+
+	assert.NoError(t, exempl.RequestPasswordReset("expire@example.com"))
+	token := testMailer.lastToken("expire@example.com")
+	for _, stored := range mok.UserToken.(*mockUserTokenRepo).tokens {
+		stored.ExpiresAt = time.Now().Add(-time.Second)
+	}
+	err := exempl.ConfirmPasswordReset(models.PasswordResetConfirmRequest{Token: token, NewPass: "Res3t-password"})
+	assert.ErrorIs(t, err, bl.ErrInvalidUserToken)
+}
+
+func TestOutboxMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer, err := mail.NewOutboxMailer(dir, "noreply@example.com")
+	assert.NoError(t, err)
+
+	assert.NoError(t, mailer.Send(mail.Message{To: "user@example.com", Subject: "Hello", Body: "line 1\nline 2"}))
+	assert.NoError(t, mailer.Send(mail.Message{To: "../user@example.com", Subject: "Second", Body: "body"}))
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "From: noreply@example.com\r\n")
+	assert.Contains(t, string(content), "To: user@example.com\r\n")
+	assert.Contains(t, string(content), "Subject: Hello\r\n")
+	assert.True(t, strings.HasSuffix(string(content), "\r\n\r\nline 1\r\nline 2"))
+}
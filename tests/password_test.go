@@ -0,0 +1,150 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+	"vk-inter-test-go/internal/utils"
+)
+
+var (
+	mockPasswordHashes     = map[int]string{}
+	mockMustChangePassword = map[int]bool{}
+)
+
+func (m mockUserRepo) SetMustChangePassword(userID int, mustChange bool) (int64, error) {
+	if _, ok := mockUserRoles[userID]; !ok {
+		return 0, nil
+	}
+	mockMustChangePassword[userID] = mustChange
+	return 1, nil
+}
+
+func (m mockUserRepo) UpdatePassword(userID int, hash string) (int64, error) {
+	if _, ok := mockUserRoles[userID]; !ok {
+		return 0, nil
+	}
+	mockPasswordHashes[userID] = hash
+	delete(mockMustChangePassword, userID)
+	return 1, nil
+}
+
+func (m mockUserRepo) SetPasswordHash(userID int, hash string) (int64, error) {
+	if _, ok := mockUserRoles[userID]; !ok {
+		return 0, nil
+	}
+	mockPasswordHashes[userID] = hash
+	return 1, nil
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := utils.NewPasswordPolicy(8, 2, []string{"Movies2024"})
+
+	testCases := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"valid", "Str0ng-pass", true},
+		{"too short", "Ab1", false},
+		{"single class", "onlylowercase", false},
+		{"common", "Password1", false},
+		{"denylisted", "movies2024", false},
+		{"same as login", "TestUser1", false},
+		{"unicode", "пароль-длинный", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Check(tc.password, "testuser1")
+			assert.Equal(t, tc.valid, err == nil, "unexpected result: %v", err)
+		})
+	}
+}
+
+func TestCreateUserWeakPassword(t *testing.T) {
+	_, err := exempl.CreateUser(repo.User{Login: "weak", Pass: "password"})
+	assert.ErrorIs(t, err, bl.ErrWeakPassword)
+
+	_, err = exempl.CreateUserByAdmin(models.UserCreateRequest{Login: "weak", Pass: "password"})
+	assert.ErrorIs(t, err, bl.ErrWeakPassword)
+}
+
+func TestChangePassword(t *testing.T) {
+	defer delete(mockPasswordHashes, 1)
+
+	tokens, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+	principal, err := exempl.Authenticate(tokens.Bearer)
+	assert.NoError(t, err)
+	ctx := bl.WithPrincipal(context.Background(), principal)
+
+	_, err = exempl.ChangePassword(ctx, models.PasswordChangeRequest{OldPass: "wrong", NewPass: "N3w-password"})
+	assert.ErrorIs(t, err, bl.ErrWrongPassword)
+
+	_, err = exempl.ChangePassword(ctx, models.PasswordChangeRequest{OldPass: "password", NewPass: "short"})
+	assert.ErrorIs(t, err, bl.ErrWeakPassword)
+
+	_, err = exempl.ChangePassword(context.Background(), models.PasswordChangeRequest{OldPass: "password", NewPass: "N3w-password"})
+	assert.ErrorIs(t, err, bl.ErrUserNotFound)
+
+	changed, err := exempl.ChangePassword(ctx, models.PasswordChangeRequest{OldPass: "password", NewPass: "N3w-password"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, changed.Bearer)
+
+	// the sessions issued before the change are revoked
+	_, err = exempl.RefreshToken(tokens.Refresh)
+	assert.Error(t, err)
+	_, err = exempl.RefreshToken(changed.Refresh)
+	assert.NoError(t, err)
+
+	_, err = exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.Error(t, err)
+	_, err = exempl.AuthUser(repo.User{Login: "testuser", Pass: "N3w-password"}, testClientIP)
+	assert.NoError(t, err)
+}
+
+func TestMustChangePassword(t *testing.T) {
+	defer delete(mockPasswordHashes, 1)
+	defer delete(mockMustChangePassword, 1)
+
+	isTrue := true
+	_, err := exempl.UpdateUserByAdmin(models.UserUpdateRequest{ID: 1, MustChangePassword: &isTrue})
+	assert.NoError(t, err)
+
+	tokens, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+	principal, err := exempl.Authenticate(tokens.Bearer)
+	assert.NoError(t, err)
+	assert.True(t, principal.MustChangePassword)
+
+	contr := handlers.NewController(exempl, testOptions, zap.NewExample())
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.Bearer)
+	rec := httptest.NewRecorder()
+	contr.AuthMiddleware(handler)(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/user/password", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.Bearer)
+	rec = httptest.NewRecorder()
+	contr.PasswordChangeAuthMiddleware(handler)(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	ctx := bl.WithPrincipal(context.Background(), principal)
+	changed, err := exempl.ChangePassword(ctx, models.PasswordChangeRequest{OldPass: "password", NewPass: "N3w-password"})
+	assert.NoError(t, err)
+	principal, err = exempl.Authenticate(changed.Bearer)
+	assert.NoError(t, err)
+	assert.False(t, principal.MustChangePassword)
+}
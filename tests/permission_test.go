@@ -0,0 +1,61 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+)
+
+func checkPermission(userID int, permission string) bool {
+	ctx := bl.WithPrincipal(context.Background(), bl.Principal{UserID: userID})
+	_, ok := exempl.CheckPermission(ctx, permission)
+	return ok
+}
+
+func TestCheckPermission(t *testing.T) {
+	assert.True(t, checkPermission(1, bl.PermMovieWrite))
+	assert.True(t, checkPermission(1, bl.PermRoleManage))
+	assert.False(t, checkPermission(1, "movie:unknown"))
+	assert.False(t, checkPermission(2, bl.PermMovieWrite))
+
+	_, ok := exempl.CheckPermission(context.Background(), bl.PermMovieWrite)
+	assert.False(t, ok, "request without principal must be denied")
+}
+
+func TestCustomRolePermissions(t *testing.T) {
+	mockUserRoles[3] = "editor"
+	defer func() {
+		delete(mockUserRoles, 3)
+		_, _ = exempl.DeleteRole("editor")
+	}()
+
+	role, err := exempl.CreateRole(repo.Role{Name: "editor", Permissions: []string{bl.PermMovieWrite, bl.PermActorWrite, bl.PermMovieWrite}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bl.PermActorWrite, bl.PermMovieWrite}, role.Permissions, "permissions must be deduplicated")
+
+	assert.True(t, checkPermission(3, bl.PermMovieWrite))
+	assert.False(t, checkPermission(3, bl.PermMovieDelete))
+
+	_, err = exempl.UpdateRolePermissions(repo.Role{Name: "editor", Permissions: []string{bl.PermMovieDelete}})
+	assert.NoError(t, err)
+	assert.False(t, checkPermission(3, bl.PermMovieWrite), "permission change must drop the cache")
+	assert.True(t, checkPermission(3, bl.PermMovieDelete))
+}
+
+func TestBuiltinRoles(t *testing.T) {
+	_, err := exempl.UpdateRolePermissions(repo.Role{Name: bl.RoleAdmin})
+	assert.True(t, errors.Is(err, bl.ErrBuiltinRole))
+
+	_, err = exempl.DeleteRole(bl.RoleAdmin)
+	assert.True(t, errors.Is(err, bl.ErrBuiltinRole))
+	_, err = exempl.DeleteRole(bl.RoleUser)
+	assert.True(t, errors.Is(err, bl.ErrBuiltinRole))
+
+	rows, err := exempl.DeleteRole("missing")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), rows)
+}
@@ -0,0 +1,231 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	routes "vk-inter-test-go/internal/io"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+	utilsJwt "vk-inter-test-go/internal/utils"
+)
+
+type mockReviewRepo struct {
+	mu      sync.Mutex
+	nextID  int
+	reviews map[int]repo.Review
+}
+
+func newMockReviewRepo() *mockReviewRepo {
+	return &mockReviewRepo{reviews: map[int]repo.Review{}}
+}
+
+func (m *mockReviewRepo) CreateReview(review *repo.Review) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.reviews {
+		if existing.MovieID == review.MovieID && existing.UserID == review.UserID {
+			return repo.ErrReviewTaken
+		}
+	}
+	m.nextID++
+	review.ID = m.nextID
+	review.CreatedAt = time.Now()
+	review.UpdatedAt = review.CreatedAt
+	m.reviews[review.ID] = *review
+	return nil
+}
+
+func (m *mockReviewRepo) GetReviewById(id int) (repo.Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	review, ok := m.reviews[id]
+	if !ok {
+		return repo.Review{}, errors.New("not found")
+	}
+	return review, nil
+}
+
+func (m *mockReviewRepo) GetUserReviews(userID int) ([]repo.Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var reviews []repo.Review
+	for id := m.nextID; id > 0; id-- {
+		if review, ok := m.reviews[id]; ok && review.UserID == userID {
+			reviews = append(reviews, review)
+		}
+	}
+	return reviews, nil
+}
+
+func (m *mockReviewRepo) UpdateReview(review *repo.Review) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing, ok := m.reviews[review.ID]
+	if !ok || existing.UserID != review.UserID {
+		return 0, nil
+	}
+	review.UpdatedAt = time.Now()
+	m.reviews[review.ID] = *review
+	return 1, nil
+}
+
+func (m *mockReviewRepo) DeleteReview(id int, userID int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if review, ok := m.reviews[id]; !ok || review.UserID != userID {
+		return 0, nil
+	}
+	delete(m.reviews, id)
+	return 1, nil
+}
+
+func (m *mockReviewRepo) GetScoresByMovieIDs(movieIDs []int) (map[int]repo.MovieScore, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sums := map[int]int{}
+	scores := map[int]repo.MovieScore{}
+	for _, review := range m.reviews {
+		sums[review.MovieID] += review.Score
+		score := scores[review.MovieID]
+		score.Votes++
+		scores[review.MovieID] = score
+	}
+	res := make(map[int]repo.MovieScore)
+	for _, movieID := range movieIDs {
+		if score, ok := scores[movieID]; ok {
+			score.Average = math.Round(float64(sums[movieID])/float64(score.Votes)*10) / 10
+			res[movieID] = score
+		}
+	}
+	return res, nil
+}
+
+func cleanupReviews() {
+	reviews := mok.Review.(*mockReviewRepo)
+	reviews.mu.Lock()
+	defer reviews.mu.Unlock()
+	reviews.reviews = map[int]repo.Review{}
+}
+
+func TestReviews(t *testing.T) {
+	defer cleanupReviews()
+	defer cleanupExtraUsers()
+	other, err := exempl.CreateUserByAdmin(models.UserCreateRequest{Login: "critic", Pass: "Passw0rd!x", Role: bl.RoleUser})
+	assert.NoError(t, err)
+	ctx := principalCtx(1, "testuser", "admin")
+	otherCtx := principalCtx(other.ID, "critic", bl.RoleUser)
+
+	review, err := exempl.CreateReview(ctx, models.ReviewCreateRequest{MovieID: 2, Score: 9, Review: "  Tense.  "})
+	assert.NoError(t, err)
+	assert.Equal(t, "Tense.", review.Review)
+	_, err = exempl.CreateReview(ctx, models.ReviewCreateRequest{MovieID: 2, Score: 3})
+	assert.ErrorIs(t, err, bl.ErrReviewExists, "a movie is rated once")
+	_, err = exempl.CreateReview(ctx, models.ReviewCreateRequest{MovieID: 1, Score: 11})
+	assert.ErrorIs(t, err, bl.ErrInvalidReview)
+	_, err = exempl.CreateReview(ctx, models.ReviewCreateRequest{MovieID: 999, Score: 5})
+	assert.ErrorIs(t, err, bl.ErrMovieNotFound)
+	_, err = exempl.CreateReview(principalCtx(0, bl.GuestLogin, ""), models.ReviewCreateRequest{MovieID: 1, Score: 5})
+	assert.ErrorIs(t, err, bl.ErrUserNotFound)
+	_, err = exempl.CreateReview(otherCtx, models.ReviewCreateRequest{MovieID: 2, Score: 6})
+	assert.NoError(t, err)
+
+	// the order is made by the query, the mock keeps its own
+	movies, err := exempl.GetMovies(repo.MovieFilter{}, "votes")
+	assert.NoError(t, err)
+	if assert.Len(t, movies, 2) {
+		for _, movie := range movies {
+			if movie.Movie.ID != 2 {
+				assert.Zero(t, movie.Votes)
+				assert.Zero(t, movie.UserScore)
+				continue
+			}
+			assert.Equal(t, 7.5, movie.UserScore)
+			assert.Equal(t, 2, movie.Votes)
+			assert.Equal(t, 5, movie.Movie.Rating, "the editorial rating is kept")
+		}
+	}
+
+	_, err = exempl.UpdateReview(otherCtx, models.ReviewUpdateRequest{ID: review.ID, Score: 1})
+	assert.ErrorIs(t, err, bl.ErrReviewNotFound, "reviews of other users cannot be edited")
+	empty := ""
+	updated, err := exempl.UpdateReview(ctx, models.ReviewUpdateRequest{ID: review.ID, Score: 7, Review: &empty})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, updated.Score)
+	assert.Empty(t, updated.Review)
+
+	mine, err := exempl.GetMyReviews(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, mine, 1) {
+		assert.Equal(t, 7, mine[0].Score)
+	}
+
+	assert.ErrorIs(t, exempl.DeleteReview(otherCtx, review.ID), bl.ErrReviewNotFound)
+	assert.NoError(t, exempl.DeleteReview(ctx, review.ID))
+	mine, err = exempl.GetMyReviews(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, mine)
+	assert.Empty(t, mine)
+
+	export, err := exempl.ExportUserData(otherCtx)
+	assert.NoError(t, err)
+	assert.Len(t, export.Reviews, 1, "reviews are exported")
+}
+
+func TestReviewEndpoints(t *testing.T) {
+	defer cleanupReviews()
+	mux := routes.SetupRoutes(handlers.NewController(exempl, testOptions, zap.NewExample()))
+	token, err := utilsJwt.GenerateToken(time.Hour, utilsJwt.TokenClaims{Subject: "testuser", UserID: 1})
+	assert.NoError(t, err)
+
+	call := func(method string, path string, bearer string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodPost, "/api/review", "", `{"movieId":1,"score":8}`).Code)
+	rec := call(http.MethodPost, "/api/review", token, `{"movieId":1,"score":8,"review":"Loud."}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var review models.ReviewIo
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &review))
+	assert.Equal(t, http.StatusConflict, call(http.MethodPost, "/api/review", token, `{"movieId":1,"score":4}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/api/review", token, `{"movieId":2,"score":0}`).Code)
+
+	rec = call(http.MethodGet, "/api/movie?title=Oppenheimer", token, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var movies []models.MovieIo
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &movies))
+	if assert.Len(t, movies, 1) {
+		assert.Equal(t, 8.0, movies[0].UserScore)
+		assert.Equal(t, 1, movies[0].Votes)
+	}
+
+	assert.Equal(t, http.StatusOK, call(http.MethodPatch, "/api/review", token, `{"ID":`+strconv.Itoa(review.ID)+`,"score":10}`).Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodPatch, "/api/review", token, `{"ID":999,"score":10}`).Code)
+	rec = call(http.MethodGet, "/api/review", token, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var mine []models.ReviewIo
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &mine))
+	if assert.Len(t, mine, 1) {
+		assert.Equal(t, 10, mine[0].Score)
+	}
+	assert.Equal(t, http.StatusOK, call(http.MethodDelete, "/api/review?id="+strconv.Itoa(review.ID), token, "").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, "/api/review?id="+strconv.Itoa(review.ID), token, "").Code)
+}
@@ -0,0 +1,58 @@
+package tests_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+)
+
+func TestRoleClaim(t *testing.T) {
+	tokens, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+
+	principal, err := exempl.Authenticate(tokens.Bearer)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", principal.Role)
+}
+
+func TestRoleResolverCache(t *testing.T) {
+	options := testOptions
+	options.RoleCacheTTL = time.Hour
+	inst := newTestBL(options)
+	ctx := bl.WithPrincipal(context.Background(), bl.Principal{UserID: 1, Role: "admin"})
+
+	mockRoleLoads = 0
+	for i := 0; i < 3; i++ {
+		_, ok := inst.CheckRole(ctx, "admin")
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 1, mockRoleLoads, "role must be loaded once and then served from cache")
+
+	assert.NoError(t, inst.SetUserRole(1, "user"))
+	defer func() { mockUserRoles[1] = "admin" }()
+
+	principal, ok := inst.CheckRole(ctx, "admin")
+	assert.False(t, ok, "role change must apply despite the stale token claim")
+	assert.Equal(t, "user", principal.Role)
+	assert.Equal(t, 2, mockRoleLoads)
+
+	assert.Error(t, inst.SetUserRole(1, "unknown"))
+	assert.Error(t, inst.SetUserRole(42, "admin"))
+}
+
+func TestRoleResolverExpiry(t *testing.T) {
+	options := testOptions
+	options.RoleCacheTTL = time.Nanosecond
+	inst := newTestBL(options)
+	ctx := bl.WithPrincipal(context.Background(), bl.Principal{UserID: 1})
+
+	mockRoleLoads = 0
+	inst.CheckRole(ctx, "admin")
+	time.Sleep(time.Millisecond)
+	inst.CheckRole(ctx, "admin")
+	assert.Equal(t, 2, mockRoleLoads, "expired entry must be reloaded")
+}
@@ -0,0 +1,164 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/io/http/handlers"
+	"vk-inter-test-go/internal/io/models"
+)
+
+func cookieController() *handlers.Controller {
+	options := testOptions
+	options.SessionCookies = true
+	options.CorsOrigins = []string{"https://app.example"}
+	return handlers.NewController(exempl, options, zap.NewExample())
+}
+
+func responseCookies(rec *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range rec.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	return cookies
+}
+
+func cookieLogin(t *testing.T, contr *handlers.Controller) (map[string]*http.Cookie, models.TokenResponse) {
+	req := httptest.NewRequest(http.MethodPost, "/api/login?session=cookie", bytes.NewBufferString(`{"login":"testuser","pass":"password"}`))
+	rec := httptest.NewRecorder()
+	contr.AuthUser(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var tokens models.TokenResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tokens))
+	return responseCookies(rec), tokens
+}
+
+func TestCookieSessionLogin(t *testing.T) {
+	cookies, tokens := cookieLogin(t, cookieController())
+
+	assert.Empty(t, tokens.Bearer, "tokens are not exposed to JavaScript")
+	assert.Empty(t, tokens.Refresh)
+	assert.NotEmpty(t, tokens.CsrfToken)
+
+	for _, name := range []string{"access_token", "refresh_token"} {
+		cookie := cookies[name]
+		if assert.NotNil(t, cookie, name) {
+			assert.True(t, cookie.HttpOnly)
+			assert.True(t, cookie.Secure)
+			assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+		}
+	}
+	if assert.NotNil(t, cookies["csrf_token"]) {
+		assert.False(t, cookies["csrf_token"].HttpOnly)
+		assert.Equal(t, tokens.CsrfToken, cookies["csrf_token"].Value)
+	}
+
+	// Without session=cookie the login answers with the tokens as before.
+	req := httptest.NewRequest(http.MethodPost, "/api/login", bytes.NewBufferString(`{"login":"testuser","pass":"password"}`))
+	rec := httptest.NewRecorder()
+	cookieController().AuthUser(rec, req)
+	assert.Empty(t, rec.Result().Cookies())
+}
+
+func TestCookieSessionCsrf(t *testing.T) {
+	contr := cookieController()
+	cookies, tokens := cookieLogin(t, contr)
+
+	protected := contr.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {})
+	call := func(method string, csrf string, names ...string) int {
+		req := httptest.NewRequest(method, "/api/movie", nil)
+		for _, name := range names {
+			req.AddCookie(cookies[name])
+		}
+		if len(csrf) > 0 {
+			req.Header.Set("X-CSRF-Token", csrf)
+		}
+		rec := httptest.NewRecorder()
+		protected(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "", "access_token"))
+	assert.Equal(t, http.StatusForbidden, call(http.MethodPost, "", "access_token", "csrf_token"))
+	assert.Equal(t, http.StatusForbidden, call(http.MethodPost, "forged", "access_token", "csrf_token"))
+	assert.Equal(t, http.StatusForbidden, call(http.MethodPost, tokens.CsrfToken, "access_token"))
+	assert.Equal(t, http.StatusOK, call(http.MethodPost, tokens.CsrfToken, "access_token", "csrf_token"))
+
+	disabled := handlers.NewController(exempl, testOptions, zap.NewExample())
+	req := httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+	req.AddCookie(cookies["access_token"])
+	rec := httptest.NewRecorder()
+	disabled.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {})(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "cookies are ignored unless enabled")
+}
+
+func TestCookieSessionRefreshAndLogout(t *testing.T) {
+	contr := cookieController()
+	cookies, tokens := cookieLogin(t, contr)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/token/refresh", nil)
+	req.AddCookie(cookies["refresh_token"])
+	req.AddCookie(cookies["csrf_token"])
+	rec := httptest.NewRecorder()
+	contr.RefreshToken(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "csrf header is required")
+
+	req.Header.Set("X-CSRF-Token", tokens.CsrfToken)
+	rec = httptest.NewRecorder()
+	contr.RefreshToken(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	refreshed := responseCookies(rec)
+	assert.NotEqual(t, cookies["refresh_token"].Value, refreshed["refresh_token"].Value)
+	var body models.TokenResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Empty(t, body.Refresh)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/logout", nil)
+	req.AddCookie(refreshed["refresh_token"])
+	req.AddCookie(refreshed["csrf_token"])
+	req.Header.Set("X-CSRF-Token", body.CsrfToken)
+	rec = httptest.NewRecorder()
+	contr.Logout(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	for name, cookie := range responseCookies(rec) {
+		assert.True(t, cookie.MaxAge < 0, name+" is cleared")
+	}
+}
+
+func TestCorsAllowList(t *testing.T) {
+	handler := cookieController().GlobalMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	call := func(method string, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/movie", nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call(http.MethodGet, "https://app.example")
+	assert.Equal(t, "https://app.example", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	rec = call(http.MethodGet, "https://evil.example")
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	rec = call(http.MethodOptions, "https://app.example")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "X-CSRF-Token")
+
+	open := handlers.NewController(exempl, testOptions, zap.NewExample()).GlobalMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+	rec = httptest.NewRecorder()
+	open.ServeHTTP(rec, req)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,202 @@
+package tests_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/http/handlers"
+)
+
+const testClientIP = "192.0.2.1"
+
+type mockLoginAttemptRepo struct {
+	mu       sync.Mutex
+	attempts map[[2]string]*repo.LoginAttempt
+}
+
+func newMockLoginAttemptRepo() *mockLoginAttemptRepo {
+	return &mockLoginAttemptRepo{attempts: make(map[[2]string]*repo.LoginAttempt)}
+}
+
+func (m *mockLoginAttemptRepo) GetLoginAttempt(scope string, subject string) (repo.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	attempt, ok := m.attempts[[2]string{scope, subject}]
+	if !ok {
+		return repo.LoginAttempt{Scope: scope, Subject: subject}, nil
+	}
+	return *attempt, nil
+}
+
+func (m *mockLoginAttemptRepo) RecordLoginFailure(scope string, subject string, window time.Duration) (repo.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{scope, subject}
+	attempt, ok := m.attempts[key]
+	if !ok {
+		attempt = &repo.LoginAttempt{Scope: scope, Subject: subject}
+		m.attempts[key] = attempt
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = time.Now()
+	return *attempt, nil
+}
+
+func (m *mockLoginAttemptRepo) SetLoginBlockedUntil(scope string, subject string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if attempt, ok := m.attempts[[2]string{scope, subject}]; ok {
+		attempt.BlockedUntil = &until
+	}
+	return nil
+}
+
+func (m *mockLoginAttemptRepo) DeleteLoginAttempt(scope string, subject string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{scope, subject}
+	if _, ok := m.attempts[key]; !ok {
+		return 0, nil
+	}
+	delete(m.attempts, key)
+	return 1, nil
+}
+
+func (m *mockLoginAttemptRepo) GetBlockedLoginAttempts() ([]repo.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var attempts []repo.LoginAttempt
+	for _, attempt := range m.attempts {
+		if attempt.BlockedUntil != nil && attempt.BlockedUntil.After(time.Now()) {
+			attempts = append(attempts, *attempt)
+		}
+	}
+	return attempts, nil
+}
+
+func throttledBL(backoffBase time.Duration, maxFailures int, ipMaxFailures int) *bl.BL {
+	options := testOptions
+	options.LoginBackoffAfter = 2
+	options.LoginBackoffBase = backoffBase
+	options.LoginMaxFailures = maxFailures
+	options.LoginIPMaxFailures = ipMaxFailures
+	options.LoginLockout = 2 * time.Hour
+	options.LoginFailureWindow = time.Hour
+	mok.Login = newMockLoginAttemptRepo()
+	return newTestBL(options)
+}
+
+func TestLoginBackoff(t *testing.T) {
+	defer func() { mok.Login = newMockLoginAttemptRepo() }()
+	b := throttledBL(time.Minute, 0, 0)
+	wrong := repo.User{Login: "testuser", Pass: "wrong"}
+	right := repo.User{Login: "testuser", Pass: "password"}
+
+	var throttled *bl.LoginThrottledError
+	for i := 0; i < 2; i++ {
+		_, err := b.AuthUser(wrong, testClientIP)
+		assert.Error(t, err)
+		assert.False(t, errors.As(err, &throttled), "attempt %d should not be throttled", i+1)
+	}
+
+	// the second failure starts the backoff, even the right password is refused
+	_, err := b.AuthUser(right, testClientIP)
+	assert.True(t, errors.As(err, &throttled))
+	assert.InDelta(t, time.Minute.Seconds(), throttled.RetryAfter.Seconds(), 1)
+
+	assert.NoError(t, b.Unlock(repo.LoginScopeLogin, "testuser"))
+	assert.NoError(t, b.Unlock(repo.LoginScopeIP, testClientIP))
+	assert.ErrorIs(t, b.Unlock(repo.LoginScopeLogin, "testuser"), bl.ErrLockoutNotFound)
+
+	_, err = b.AuthUser(right, testClientIP)
+	assert.NoError(t, err)
+}
+
+func TestLoginBackoffGrows(t *testing.T) {
+	defer func() { mok.Login = newMockLoginAttemptRepo() }()
+	b := throttledBL(time.Minute, 0, 0)
+
+	for i := 0; i < 3; i++ {
+		_, _ = mok.Login.RecordLoginFailure(repo.LoginScopeLogin, "testuser", time.Hour)
+	}
+	_, _ = b.AuthUser(repo.User{Login: "testuser", Pass: "wrong"}, "")
+
+	var throttled *bl.LoginThrottledError
+	_, err := b.AuthUser(repo.User{Login: "testuser", Pass: "password"}, "")
+	assert.True(t, errors.As(err, &throttled))
+	// 4 failures: 1m doubled twice
+	assert.InDelta(t, (4 * time.Minute).Seconds(), throttled.RetryAfter.Seconds(), 1)
+}
+
+func TestLoginLockout(t *testing.T) {
+	defer func() { mok.Login = newMockLoginAttemptRepo() }()
+	b := throttledBL(0, 3, 0)
+
+	for i := 0; i < 3; i++ {
+		_, err := b.AuthUser(repo.User{Login: "testuser", Pass: "wrong"}, testClientIP)
+		assert.Error(t, err)
+	}
+
+	var throttled *bl.LoginThrottledError
+	_, err := b.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.True(t, errors.As(err, &throttled))
+	assert.InDelta(t, (2 * time.Hour).Seconds(), throttled.RetryAfter.Seconds(), 1)
+
+	lockouts, err := b.GetLockouts()
+	assert.NoError(t, err)
+	if assert.Len(t, lockouts, 1) {
+		assert.Equal(t, repo.LoginScopeLogin, lockouts[0].Scope)
+		assert.Equal(t, "testuser", lockouts[0].Subject)
+		assert.Equal(t, 3, lockouts[0].Failures)
+	}
+
+	assert.NoError(t, b.Unlock(repo.LoginScopeLogin, "testuser"))
+	_, err = b.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+}
+
+func TestLoginIPLockout(t *testing.T) {
+	defer func() { mok.Login = newMockLoginAttemptRepo() }()
+	b := throttledBL(0, 0, 3)
+
+	// guessing different logins from one address
+	for _, login := range []string{"alice", "bob", "carol"} {
+		_, err := b.AuthUser(repo.User{Login: login, Pass: "password"}, testClientIP)
+		assert.Error(t, err)
+	}
+
+	var throttled *bl.LoginThrottledError
+	_, err := b.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.True(t, errors.As(err, &throttled))
+
+	_, err = b.AuthUser(repo.User{Login: "testuser", Pass: "password"}, "198.51.100.7")
+	assert.NoError(t, err)
+}
+
+func TestLoginThrottledResponse(t *testing.T) {
+	defer func() { mok.Login = newMockLoginAttemptRepo() }()
+	b := throttledBL(0, 1, 0)
+	contr := handlers.NewController(b, testOptions, zap.NewExample())
+
+	login := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/login", bytes.NewBufferString(`{"login":"testuser","pass":"wrong"}`))
+		req.RemoteAddr = testClientIP + ":40000"
+		rec := httptest.NewRecorder()
+		contr.AuthUser(rec, req)
+		return rec
+	}
+
+	assert.NotEqual(t, http.StatusTooManyRequests, login().Code)
+	rec := login()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "7200", rec.Header().Get("Retry-After"))
+}
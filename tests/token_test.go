@@ -0,0 +1,101 @@
+package tests_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+)
+
+type mockRefreshRepo struct {
+	mu     sync.Mutex
+	tokens map[int]*repo.RefreshToken
+}
+
+func newMockRefreshRepo() *mockRefreshRepo {
+	return &mockRefreshRepo{tokens: make(map[int]*repo.RefreshToken)}
+}
+
+func (m *mockRefreshRepo) CreateRefreshToken(token *repo.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token.ID = len(m.tokens) + 1
+	token.CreatedAt = time.Now().UTC()
+	stored := *token
+	m.tokens[token.ID] = &stored
+	return nil
+}
+
+func (m *mockRefreshRepo) GetRefreshTokenByHash(hash string) (repo.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, token := range m.tokens {
+		if token.TokenHash == hash {
+			return *token, nil
+		}
+	}
+	return repo.RefreshToken{}, errors.New("not found")
+}
+
+func (m *mockRefreshRepo) RevokeRefreshToken(id int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token, ok := m.tokens[id]
+	if !ok || token.RevokedAt != nil {
+		return 0, nil
+	}
+	now := time.Now().UTC()
+	token.RevokedAt = &now
+	return 1, nil
+}
+
+func (m *mockRefreshRepo) RevokeRefreshTokenFamily(family string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows int64
+	now := time.Now().UTC()
+	for _, token := range m.tokens {
+		if token.Family == family && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			rows++
+		}
+	}
+	return rows, nil
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	tokens, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+
+	rotated, err := exempl.RefreshToken(tokens.Refresh)
+	assert.NoError(t, err)
+	assert.NotEqual(t, tokens.Refresh, rotated.Refresh, "refresh token must rotate")
+	_, err = exempl.Authenticate(rotated.Bearer)
+	assert.NoError(t, err)
+
+	_, err = exempl.RefreshToken(tokens.Refresh)
+	assert.True(t, errors.Is(err, bl.ErrRefreshTokenReused), "reuse of a rotated token must be detected")
+
+	_, err = exempl.RefreshToken(rotated.Refresh)
+	assert.Error(t, err, "reuse must revoke the whole session")
+}
+
+func TestRefreshTokenUnknown(t *testing.T) {
+	_, err := exempl.RefreshToken("unknown")
+	assert.True(t, errors.Is(err, bl.ErrInvalidRefreshToken))
+}
+
+func TestLogout(t *testing.T) {
+	tokens, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+
+	assert.NoError(t, exempl.Logout(tokens.Refresh))
+
+	_, err = exempl.RefreshToken(tokens.Refresh)
+	assert.Error(t, err, "refresh after logout must fail")
+	assert.Error(t, exempl.Logout("unknown"))
+}
@@ -0,0 +1,189 @@
+package tests_test
+
+import (
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"vk-inter-test-go/internal/bl"
+	"vk-inter-test-go/internal/db/repo"
+	"vk-inter-test-go/internal/io/models"
+)
+
+var (
+	mockExtraUsers = map[int]repo.User{}
+	mockDisabled   = map[int]bool{}
+)
+
+func (m mockUserRepo) GetUsers(search string, limit int, offset int) ([]repo.User, int, error) {
+	user, _ := m.GetUserById(1)
+	users := []repo.User{user}
+	for id := range mockExtraUsers {
+		user, _ := m.GetUserById(id)
+		users = append(users, user)
+	}
+	total := len(users)
+	if offset >= total {
+		return nil, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return users[offset:end], total, nil
+}
+
+func (m mockUserRepo) SetUserDisabled(userID int, disabled bool, keep string) (int64, error) {
+	return mockKeepHolder(keep, func() (int64, error) {
+		if _, ok := mockUserRoles[userID]; !ok {
+			return 0, nil
+		}
+		mockDisabled[userID] = disabled
+		return 1, nil
+	})
+}
+
+func (m mockUserRepo) DeleteUserById(id int, keep string) (int64, error) {
+	return mockKeepHolder(keep, func() (int64, error) {
+		if _, ok := mockUserRoles[id]; !ok {
+			return 0, nil
+		}
+		delete(mockExtraUsers, id)
+		delete(mockUserRoles, id)
+		delete(mockDisabled, id)
+		return 1, nil
+	})
+}
+
+func mockHolders(permission string) int {
+	var count int
+	for id, role := range mockUserRoles {
+		if mockDisabled[id] {
+			continue
+		}
+		for _, p := range mockRolePermissions[role] {
+			if p == permission {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// mockKeepHolder applies change and undoes it when it leaves no enabled user
+// with the permission keep, like the transaction of the repo.
+func mockKeepHolder(keep string, change func() (int64, error)) (int64, error) {
+	before := mockHolders(keep)
+	roles, disabled, users := maps.Clone(mockUserRoles), maps.Clone(mockDisabled), maps.Clone(mockExtraUsers)
+	rows, err := change()
+	if err == nil && keep != "" && before > 0 && mockHolders(keep) == 0 {
+		mockUserRoles, mockDisabled, mockExtraUsers = roles, disabled, users
+		return 0, repo.ErrLastHolder
+	}
+	return rows, err
+}
+
+func (m *mockRefreshRepo) RevokeUserRefreshTokens(userID int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows int64
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &token.CreatedAt
+			rows++
+		}
+	}
+	return rows, nil
+}
+
+func cleanupExtraUsers() {
+	for id := range mockExtraUsers {
+		_, _ = mockUserRepo{}.DeleteUserById(id, "")
+	}
+	mockDisabled[1] = false
+	mockUserRoles[1] = "admin"
+}
+
+func TestLastAdminGuard(t *testing.T) {
+	defer cleanupExtraUsers()
+
+	err := exempl.DeleteUser(1)
+	assert.True(t, errors.Is(err, bl.ErrLastAdmin), "the only admin must not be deleted")
+
+	disable := true
+	_, err = exempl.UpdateUserByAdmin(models.UserUpdateRequest{ID: 1, Disabled: &disable})
+	assert.True(t, errors.Is(err, bl.ErrLastAdmin), "the only admin must not be disabled")
+
+	demote := "user"
+	_, err = exempl.UpdateUserByAdmin(models.UserUpdateRequest{ID: 1, Role: &demote})
+	assert.True(t, errors.Is(err, bl.ErrLastAdmin), "the only admin must not be demoted")
+	assert.Equal(t, "admin", mockUserRoles[1], "the refused change is rolled back")
+
+	second, err := exempl.CreateUserByAdmin(models.UserCreateRequest{Login: "second", Pass: "Str0ng-pass", Role: "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", second.Role)
+
+	user, err := exempl.UpdateUserByAdmin(models.UserUpdateRequest{ID: second.ID, Disabled: &disable})
+	assert.NoError(t, err, "one of two admins can be disabled")
+	assert.True(t, user.Disabled)
+
+	err = exempl.DeleteUser(1)
+	assert.True(t, errors.Is(err, bl.ErrLastAdmin), "disabled admins do not count")
+
+	assert.NoError(t, exempl.DeleteUser(second.ID))
+	assert.True(t, errors.Is(exempl.DeleteUser(second.ID), bl.ErrUserNotFound))
+}
+
+func TestDisabledUser(t *testing.T) {
+	defer cleanupExtraUsers()
+
+	second, err := exempl.CreateUserByAdmin(models.UserCreateRequest{Login: "second", Pass: "Str0ng-pass", Role: "admin"})
+	assert.NoError(t, err)
+
+	tokens, err := exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.NoError(t, err)
+
+	disable := true
+	_, err = exempl.UpdateUserByAdmin(models.UserUpdateRequest{ID: 1, Disabled: &disable})
+	assert.NoError(t, err)
+
+	_, err = exempl.AuthUser(repo.User{Login: "testuser", Pass: "password"}, testClientIP)
+	assert.True(t, errors.Is(err, bl.ErrUserDisabled))
+	_, err = exempl.RefreshToken(tokens.Refresh)
+	assert.Error(t, err, "sessions of a disabled user must be revoked")
+	assert.False(t, checkPermission(1, bl.PermMovieWrite), "disabled user must lose permissions")
+	_, err = exempl.Authenticate(tokens.Bearer)
+	assert.True(t, errors.Is(err, bl.ErrUserDisabled), "access tokens of a disabled user must be rejected")
+	_, err = exempl.GetProfile(principalCtx(1, "testuser", "admin"))
+	assert.True(t, errors.Is(err, bl.ErrUserDisabled))
+
+	enable := false
+	_, err = exempl.UpdateUserByAdmin(models.UserUpdateRequest{ID: 1, Disabled: &enable})
+	assert.NoError(t, err)
+	assert.True(t, checkPermission(1, bl.PermMovieWrite))
+	_, err = exempl.Authenticate(tokens.Bearer)
+	assert.NoError(t, err)
+	assert.NoError(t, exempl.DeleteUser(second.ID))
+}
+
+func TestGetUsersPaging(t *testing.T) {
+	defer cleanupExtraUsers()
+
+	for _, login := range []string{"u1", "u2"} {
+		_, err := exempl.CreateUserByAdmin(models.UserCreateRequest{Login: login, Pass: "Str0ng-pass"})
+		assert.NoError(t, err)
+	}
+
+	page, err := exempl.GetUsers("", 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	assert.Len(t, page.Users, 2)
+
+	page, err = exempl.GetUsers("", 2, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page.Users, 1)
+
+	_, err = exempl.GetUser(42)
+	assert.True(t, errors.Is(err, bl.ErrUserNotFound))
+}